@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/infracli"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/probe"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+	"github.com/spf13/cobra"
+)
+
+// defaultReadyTimeout es el tiempo máximo de espera de check --wait y run --ready
+const defaultReadyTimeout = 2 * time.Minute
+
+var checkCmd = &cobra.Command{
+	Use:   "check [service1] [service2] ... or 'all'",
+	Short: "Check that services accept clients by speaking their protocol",
+	Long: `Check from the host that services accept clients, speaking each protocol
+on the published ports: the MySQL handshake, the PostgreSQL startup message,
+MongoDB's hello, Redis PING, Elasticsearch's cluster health, Kibana's status
+and the Neo4j Bolt handshake. Other services only check that their port
+accepts connections.
+
+Unlike Docker healthchecks this also verifies that the port mapping reaches the
+service. Exits with a non-zero status when a service is not ready.
+
+Examples:
+  infracli check postgres
+  infracli check all
+  infracli check mysql redis --wait --timeout 1m
+  infracli check postgres --instance ci`,
+	ValidArgsFunction: completeServices(0, true),
+	Run: func(cmd *cobra.Command, args []string) {
+		wait, _ := cmd.Flags().GetBool("wait")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		instanceName, _ := cmd.Flags().GetString("instance")
+
+		if len(args) == 0 {
+			logger.Errorf("Error: You must specify at least one service or 'all'")
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			os.Exit(1)
+		}
+
+		services := availableServices
+		if len(args) != 1 || args[0] != "all" {
+			services = selectServices(args, availableServices)
+		}
+
+		var targets []probe.Target
+		opts := stackOptions()
+		for _, service := range services {
+			found, err := serviceProbeTargets(service, basePath, instanceName, opts)
+			if err != nil {
+				logger.Errorf("Error: %v", err)
+				os.Exit(1)
+			}
+			if len(found) == 0 {
+				logger.Warnf("%s publishes no port to check", service)
+			}
+			targets = append(targets, found...)
+		}
+		if len(targets) == 0 {
+			os.Exit(1)
+		}
+
+		var results []probe.Result
+		if wait {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			// Los resultados ya muestran qué falta si se agota el tiempo
+			results, _ = probe.Wait(ctx, targets, probe.DefaultInterval)
+			cancel()
+		} else {
+			results = probe.RunAll(context.Background(), targets)
+		}
+
+		printProbeResults(os.Stdout, results)
+		for _, result := range results {
+			if !result.Ready() {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// serviceProbeTargets devuelve las comprobaciones de un servicio o de una de
+// sus instancias, con las contraseñas de los secretos guardados
+func serviceProbeTargets(service, basePath, instanceName string, opts stack.Options) ([]probe.Target, error) {
+	content, err := serviceComposeContent(service, basePath, instanceName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return infracli.ConnectionFromCompose(service, content).Probes(), nil
+}
+
+// serviceComposeContent devuelve el docker-compose.yml de un servicio o de una
+// de sus instancias con las contraseñas de los secretos guardados
+func serviceComposeContent(service, basePath, instanceName string, opts stack.Options) (string, error) {
+	path := filepath.Join(basePath, service, compose.FileName)
+	if instanceName != "" {
+		inst, err := loadInstance(service, instanceName)
+		if err != nil {
+			return "", err
+		}
+		path = inst.composeFile()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	values, err := stack.ServiceSecrets(service, opts)
+	if err != nil {
+		return "", fmt.Errorf("error reading the secrets of %s: %v", service, err)
+	}
+	return compose.SetEnvironment(string(data), values), nil
+}
+
+// waitReady espera a que las comprobaciones pasen y registra el resultado
+func waitReady(targets []probe.Target, timeout time.Duration) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	logger.Infof("Waiting for %s to accept connections...", strings.Join(names, ", "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	results, err := probe.Wait(ctx, targets, probe.DefaultInterval)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return err
+	}
+	for _, result := range results {
+		logger.Infof("%s is ready: %s", result.Target.Name, result.Detail)
+	}
+	return nil
+}
+
+// printProbeResults muestra una tabla con el resultado de cada comprobación
+func printProbeResults(w io.Writer, results []probe.Result) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tPROTOCOL\tADDRESS\tSTATUS\tTIME\tDETAIL")
+	for _, result := range results {
+		status, detail := "ready", result.Detail
+		if !result.Ready() {
+			status, detail = "failed", result.Err.Error()
+			if errors.Is(result.Err, probe.ErrNotReady) {
+				status = "starting"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Target.Name, result.Target.Protocol, result.Target.Address, status, result.Duration.Round(time.Millisecond), detail)
+	}
+	tw.Flush()
+}
+
+func init() {
+	checkCmd.Flags().Bool("wait", false, "Retry until every service is ready or the timeout expires")
+	checkCmd.Flags().Duration("timeout", defaultReadyTimeout, "Maximum time to wait with --wait")
+	checkCmd.Flags().String("instance", "", "Check an isolated instance instead of the service")
+	checkCmd.RegisterFlagCompletionFunc("instance", completeInstances)
+	RootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/probe"
+)
+
+// TestWaitReadyTimeout comprueba que run --ready se rinde al vencer
+// --ready-timeout aunque el servicio siga respondiendo que no está listo
+func TestWaitReadyTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				// PING llega como un array de un bulk string: tres líneas
+				for i := 0; i < 3; i++ {
+					if _, err := reader.ReadString('\n'); err != nil {
+						return
+					}
+				}
+				io.WriteString(conn, "-LOADING Redis is loading the dataset in memory\r\n")
+			}()
+		}
+	}()
+
+	targets := []probe.Target{{Name: "redis", Protocol: probe.Redis, Address: listener.Addr().String()}}
+	start := time.Now()
+	err = waitReady(targets, 500*time.Millisecond)
+
+	if err == nil {
+		t.Fatal("expected waitReady to time out")
+	}
+	if !strings.Contains(err.Error(), "timed out waiting for redis") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("waitReady returned after %s with a 500ms timeout", elapsed)
+	}
+}
+
+func TestWaitReadyNoTargets(t *testing.T) {
+	if err := waitReady(nil, time.Millisecond); err != nil {
+		t.Errorf("waitReady without targets: %v", err)
+	}
+}
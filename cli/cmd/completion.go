@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate the shell completion script",
+	Long: `Generate the completion script for your shell. Service names, 'all' and
+the containers of each service are completed from the services path.
+
+Bash (requires the bash-completion package):
+  source <(infracli completion bash)
+  # Load it in every session
+  infracli completion bash > ~/.local/share/bash-completion/completions/infracli
+
+Zsh:
+  source <(infracli completion zsh)
+  # Load it in every session
+  infracli completion zsh > "${fpath[1]}/_infracli"
+
+Fish:
+  infracli completion fish > ~/.config/fish/completions/infracli.fish
+
+PowerShell:
+  infracli completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// El script se escribe en stdout sin banner para poder cargarlo con source
+		var err error
+		switch args[0] {
+		case "bash":
+			err = RootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			err = RootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = RootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			err = RootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		if err != nil {
+			logger.Errorf("Error generating the completion script: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// completeServices completa nombres de servicio sin repetir los ya escritos.
+// maxArgs limita cuántos servicios acepta el comando (0 sin límite) y
+// allowAll ofrece también 'all' como primer argumento.
+func completeServices(maxArgs int, allowAll bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if (maxArgs > 0 && len(args) >= maxArgs) || (len(args) == 1 && args[0] == "all") {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		// La completación no pasa por PersistentPreRunE, así que los flags
+		// globales como --services-path se aplican aquí
+		applyConfigOverrides(cmd)
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var completions []string
+		if allowAll && len(args) == 0 {
+			completions = append(completions, "all")
+		}
+		for _, service := range availableServices {
+			if !containsString(args, service) {
+				completions = append(completions, service)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeContainers completa el flag --container con los servicios compose y
+// los container_name de los servicios ya escritos
+func completeContainers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	applyConfigOverrides(cmd)
+	basePath, err := config.GetServicesPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	seen := make(map[string]bool)
+	for _, service := range args {
+		content, err := compose.Read(filepath.Join(basePath, service))
+		if err != nil {
+			continue
+		}
+		for composeService := range compose.Images(content) {
+			seen[composeService] = true
+		}
+		for composeService, containerName := range compose.ContainerNames(content) {
+			seen[composeService] = true
+			seen[containerName] = true
+		}
+	}
+
+	containers := make([]string, 0, len(seen))
+	for name := range seen {
+		containers = append(containers, name)
+	}
+	sort.Strings(containers)
+	return containers, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeInstances completa el flag --instance con las instancias guardadas
+// del servicio escrito
+func completeInstances(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	applyConfigOverrides(cmd)
+	instances, err := listInstances(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, inst := range instances {
+		if strings.HasPrefix(inst.Name, toComplete) {
+			names = append(names, inst.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	RootCmd.AddCommand(completionCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"os/exec"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/infracli"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+)
+
+// composeError es un fallo de docker-compose junto con la salida que produjo
+type composeError struct {
+	err    error
+	output string
+}
+
+func (e *composeError) Error() string {
+	return e.err.Error()
+}
+
+// composeCommand ejecuta docker-compose en el directorio de un servicio.
+// En modo debug la salida se registra línea a línea; si no, se conserva
+// para mostrarla solo si el comando falla.
+func composeCommand(service, servicePath string, args ...string) error {
+	cmd := exec.Command("docker-compose", args...)
+	cmd.Dir = servicePath
+
+	logger.Debugf("Running docker-compose %s in %s", strings.Join(args, " "), servicePath)
+
+	if logger.Enabled(slog.LevelDebug) {
+		out := logger.Writer(slog.LevelDebug, "service", service)
+		defer out.Close()
+		cmd.Stdout = out
+		cmd.Stderr = out
+		return cmd.Run()
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return &composeError{err: err, output: strings.TrimSpace(string(output))}
+	}
+	return nil
+}
+
+// logComposeError registra el fallo de una operación y la salida de docker-compose
+func logComposeError(action, service string, err error) {
+	logger.Errorf("Error %s %s: %v", action, service, err)
+
+	var composeErr *composeError
+	if errors.As(err, &composeErr) && composeErr.output != "" {
+		logger.Errorf("%s", composeErr.output)
+	}
+}
+
+// stackOptions prepara los servicios preguntando en la terminal la frase de
+// paso de los secretos cuando hace falta. Cada llamada desbloquea el almacén
+// una sola vez, así que un comando obtiene las opciones al empezar y las usa
+// en todos sus servicios.
+func stackOptions() stack.Options {
+	return stack.Options{
+		Passphrase:    secretsPassphrase,
+		NewPassphrase: newSecretsPassphrase,
+		HookEnv:       infracli.HookEnv,
+		Secrets:       &stack.SecretsCache{},
+	}
+}
@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
 	"github.com/spf13/cobra"
 )
 
@@ -14,28 +21,36 @@ var configCmd = &cobra.Command{
 	Long: `View or update InfraCLI configuration settings.
 This command allows you to see the current configuration and where it's stored.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Cargar la configuración actual
-		cfg, err := config.LoadConfig()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
-			return
-		}
-
-		// Obtener la ruta del archivo de configuración
-		configPath, err := config.GetConfigFilePath()
+		// Cargar la configuración efectiva junto con el origen de cada valor
+		resolved, err := config.LoadResolved()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting config path: %v\n", err)
+			logger.Errorf("Error loading configuration: %v", err)
 			return
 		}
+		cfg := resolved.Config
 
 		fmt.Println("Current InfraCLI Configuration:")
 		fmt.Println("-------------------------------")
-		fmt.Printf("Configuration file: %s\n\n", configPath)
-		fmt.Printf("Services path: %s\n", cfg.ServicesPath)
-		fmt.Printf("Excluded directories: %v\n", cfg.ExcludedDirs)
+		fmt.Printf("Configuration file: %s\n", resolved.UserFile)
+		if exists, _ := config.UserConfigExists(); !exists {
+			fmt.Println("  (not created yet, using defaults; run 'infracli init' to create it)")
+		}
+		if resolved.ProjectFile != "" {
+			fmt.Printf("Project file: %s\n", resolved.ProjectFile)
+		}
+		fmt.Println()
+		for _, field := range config.Fields() {
+			value := field.Get(cfg)
+			if field.Kind == config.StringListField {
+				value = "[" + strings.ReplaceAll(value, "\n", ", ") + "]"
+			}
+			fmt.Printf("%s: %s (from %s)\n", field.Key, value, resolved.Origins[field.Key])
+		}
 
-		fmt.Println("\nTo modify the configuration, edit the file directly or use:")
-		fmt.Println("  infracli config set-path <new-services-path>")
+		fmt.Println("\nTo modify the configuration use:")
+		fmt.Println("  infracli config set <key> <value>")
+		fmt.Println("  infracli config edit")
+		fmt.Printf("\nAvailable keys: %s\n", strings.Join(config.FieldKeys(), ", "))
 	},
 }
 
@@ -48,10 +63,10 @@ This path should point to the directory containing your infrastructure service d
 	Run: func(cmd *cobra.Command, args []string) {
 		newPath := args[0]
 
-		// Cargar la configuración actual
-		cfg, err := config.LoadConfig()
+		// Cargar la configuración del archivo de usuario
+		cfg, err := config.LoadUserConfig()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			logger.Errorf("Error loading configuration: %v", err)
 			return
 		}
 
@@ -61,7 +76,7 @@ This path should point to the directory containing your infrastructure service d
 
 		// Guardar la configuración
 		if err := config.SaveConfig(cfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Error saving configuration: %v\n", err)
+			logger.Errorf("Error saving configuration: %v", err)
 			return
 		}
 
@@ -69,7 +84,326 @@ This path should point to the directory containing your infrastructure service d
 	},
 }
 
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print the value of a configuration key",
+	Long: `Print the value of a configuration key.
+List values are printed one item per line.
+
+Examples:
+  infracli config get servicesPath
+  infracli config get excludedDirs`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		field, err := config.LookupField(args[0])
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		if value := field.Get(cfg); value != "" {
+			fmt.Println(value)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [key] [value...]",
+	Short: "Set the value of a configuration key",
+	Long: `Set the value of a configuration key.
+List keys accept several values; use --add or --remove to modify
+the list instead of replacing it.
+
+Examples:
+  infracli config set servicesPath ~/infrastructure/services
+  infracli config set excludedDirs config scripts
+  infracli config set excludedDirs --add node_modules
+  infracli config set excludedDirs --remove cmd`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		add, _ := cmd.Flags().GetBool("add")
+		remove, _ := cmd.Flags().GetBool("remove")
+		if add && remove {
+			logger.Errorf("Error: --add and --remove cannot be used together")
+			return
+		}
+
+		field, err := config.LookupField(args[0])
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		cfg, err := config.LoadUserConfig()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		values := args[1:]
+		switch {
+		case add:
+			err = field.Add(cfg, values)
+		case remove:
+			err = field.Remove(cfg, values)
+		default:
+			err = field.Set(cfg, values)
+		}
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		if err := config.Validate(cfg); err != nil {
+			logger.Errorf("Invalid configuration: %v", err)
+			return
+		}
+
+		if err := config.SaveConfig(cfg); err != nil {
+			logger.Errorf("Error saving configuration: %v", err)
+			return
+		}
+
+		fmt.Printf("Updated %s\n", field.Key)
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset [key]",
+	Short: "Restore a configuration key to its default value",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		field, err := config.LookupField(args[0])
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		cfg, err := config.LoadUserConfig()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		field.Unset(cfg)
+
+		if err := config.SaveConfig(cfg); err != nil {
+			logger.Errorf("Error saving configuration: %v", err)
+			return
+		}
+
+		fmt.Printf("%s restored to its default value\n", field.Key)
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the configuration file in your editor",
+	Long: `Open the configuration file in $VISUAL or $EDITOR (vi by default).
+The edited file is validated before being saved; if it is invalid you can
+re-open the editor or discard the changes.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := config.GetConfigFilePath()
+		if err != nil {
+			logger.Errorf("Error getting config path: %v", err)
+			return
+		}
+
+		// Si el archivo aún no existe se parte de los valores por defecto
+		original, err := os.ReadFile(configPath)
+		if os.IsNotExist(err) {
+			original, err = json.MarshalIndent(config.GetDefaultConfig(), "", "  ")
+		}
+		if err != nil {
+			logger.Errorf("Error reading config file: %v", err)
+			return
+		}
+
+		// Editar una copia temporal para no dejar un archivo inválido en su lugar
+		tmp, err := os.CreateTemp("", "infracli-*.json")
+		if err != nil {
+			logger.Errorf("Error creating temporary file: %v", err)
+			return
+		}
+		tmpPath := tmp.Name()
+		defer os.Remove(tmpPath)
+
+		_, err = tmp.Write(original)
+		tmp.Close()
+		if err != nil {
+			logger.Errorf("Error writing temporary file: %v", err)
+			return
+		}
+
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			if err := openEditor(tmpPath); err != nil {
+				logger.Errorf("Error running editor: %v", err)
+				return
+			}
+
+			err := config.ValidateFile(tmpPath)
+			if err == nil {
+				break
+			}
+
+			logger.Errorf("Invalid configuration: %v", err)
+			if !askYesNo(reader, "Re-open the editor?", true) {
+				fmt.Println("Changes discarded")
+				return
+			}
+		}
+
+		edited, err := os.ReadFile(tmpPath)
+		if err != nil {
+			logger.Errorf("Error reading edited file: %v", err)
+			return
+		}
+
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes made")
+			return
+		}
+
+		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+			logger.Errorf("Error creating config directory: %v", err)
+			return
+		}
+
+		if err := os.WriteFile(configPath, edited, 0644); err != nil {
+			logger.Errorf("Error writing config file: %v", err)
+			return
+		}
+
+		fmt.Printf("Configuration saved to %s\n", configPath)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration file for errors",
+	Long: `Check the configuration file for unknown keys and invalid values.
+Exits with a non-zero status when the configuration is invalid.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := config.GetConfigFilePath()
+		if err != nil {
+			logger.Errorf("Error getting config path: %v", err)
+			os.Exit(1)
+		}
+
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			fmt.Printf("No configuration file at %s, the defaults are in use\n", configPath)
+			return
+		}
+
+		if err := config.ValidateFile(configPath); err != nil {
+			logger.Errorf("Invalid configuration: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Configuration at %s is valid\n", configPath)
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the configuration file to the current version",
+	Long: `Rewrite a configuration file written by an older version of infracli in the
+current format, keeping the original next to it as infracli.json.v<version>.bak.
+
+Older files are also read without this command, upgraded in memory. Project
+files (.infracli.json) are never rewritten.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := config.GetConfigFilePath()
+		if err != nil {
+			logger.Errorf("Error getting config path: %v", err)
+			return
+		}
+
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			fmt.Printf("No configuration file at %s, the defaults are in use\n", configPath)
+			return
+		}
+
+		backupPath, err := config.MigrateFile(configPath)
+		if err != nil {
+			logger.Errorf("Error migrating configuration: %v", err)
+			return
+		}
+		if backupPath == "" {
+			fmt.Printf("Configuration at %s is already at version %d\n", configPath, config.CurrentVersion)
+			return
+		}
+
+		fmt.Printf("Migrated %s to config version %d (backup at %s)\n", configPath, config.CurrentVersion, backupPath)
+	},
+}
+
+var configResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Restore the default configuration",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+
+		if !force {
+			reader := bufio.NewReader(os.Stdin)
+			if !askYesNo(reader, "This will overwrite your current configuration. Continue?", false) {
+				fmt.Println("Reset cancelled")
+				return
+			}
+		}
+
+		if err := config.SaveConfig(config.GetDefaultConfig()); err != nil {
+			logger.Errorf("Error saving configuration: %v", err)
+			return
+		}
+
+		fmt.Println("Configuration restored to defaults")
+	},
+}
+
+// openEditor abre el archivo indicado en el editor del usuario y espera a que termine
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// El editor puede incluir argumentos, por ejemplo "code --wait"
+	parts := strings.Fields(editor)
+	editorCmd := exec.Command(parts[0], append(parts[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+
+	return editorCmd.Run()
+}
+
 func init() {
+	configSetCmd.Flags().Bool("add", false, "Append the values to a list key")
+	configSetCmd.Flags().Bool("remove", false, "Remove the values from a list key")
+	configResetCmd.Flags().BoolP("force", "f", false, "Do not ask for confirmation")
+
 	configCmd.AddCommand(configSetPathCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configResetCmd)
 	RootCmd.AddCommand(configCmd)
 }
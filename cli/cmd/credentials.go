@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+	"github.com/spf13/cobra"
+)
+
+var rotateCredentialsCmd = &cobra.Command{
+	Use:   "rotate-credentials [service]",
+	Short: "Generate new random passwords for a service",
+	Long: `Generate new random passwords for the known credential variables of a service
+(POSTGRES_PASSWORD, MYSQL_PASSWORD, MONGO_INITDB_ROOT_PASSWORD, NEO4J_AUTH...)
+and store them as secrets.
+
+Databases only read these variables when their data is created, so the new
+passwords take effect once the service's volumes are recreated. --reset does it
+right away: it stops the service, removes its volumes and starts it again.
+
+To generate them automatically on the first run of a service:
+  infracli config set credentials.generate postgres mysql
+
+Examples:
+  infracli rotate-credentials postgres
+  infracli rotate-credentials mysql --reset`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeServices(1, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		reset, _ := cmd.Flags().GetBool("reset")
+
+		service, err := resolveServiceName(args[0])
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		content, err := compose.Read(filepath.Join(basePath, service))
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		names := stack.DeclaredCredentials(content)
+		if len(names) == 0 {
+			logger.Errorf("Error: %s has no known credential variables (%s)", service, strings.Join(stack.CredentialVars, ", "))
+			return
+		}
+
+		opts := stackOptions()
+		if err := stack.GenerateCredentials(service, content, names, opts); err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		logger.Infof("Generated new credentials for %s: %s", service, strings.Join(names, ", "))
+
+		if reset {
+			if stopService(service, basePath, true, opts) == nil {
+				runService(service, basePath, opts)
+			}
+			return
+		}
+
+		if hasData, err := stack.HasData(service, basePath, stack.Options{}); err == nil && hasData {
+			logger.Warnf("%s keeps the previous credentials in its existing data; recreate it with: infracli rotate-credentials %s --reset", service, service)
+		}
+	},
+}
+
+func init() {
+	rotateCredentialsCmd.Flags().Bool("reset", false, "Remove the service's volumes and start it again so the new credentials take effect")
+	RootCmd.AddCommand(rotateCredentialsCmd)
+}
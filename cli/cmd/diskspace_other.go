@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package cmd
+
+import "fmt"
+
+// freeSpace no está disponible en esta plataforma
+func freeSpace(path string) (uint64, error) {
+	return 0, fmt.Errorf("not supported on this platform")
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package cmd
+
+import "syscall"
+
+// freeSpace devuelve los bytes disponibles para usuarios sin privilegios en
+// el sistema de archivos que contiene path
+func freeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,495 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// minFreeSpace y lowFreeSpace son los umbrales de espacio libre para volúmenes
+	minFreeSpace = 2 << 30
+	lowFreeSpace = 10 << 30
+	// elasticsearchMapCount es el mínimo de vm.max_map_count que exige Elasticsearch
+	elasticsearchMapCount = 262144
+	// doctorTimeout limita cada consulta al demonio
+	doctorTimeout = 5 * time.Second
+)
+
+// diagnosisStatus es el resultado de una comprobación de doctor
+type diagnosisStatus string
+
+const (
+	diagnosisPass diagnosisStatus = "PASS"
+	diagnosisWarn diagnosisStatus = "WARN"
+	diagnosisFail diagnosisStatus = "FAIL"
+)
+
+// diagnosis es el resultado de una comprobación con la forma de solucionarla
+type diagnosis struct {
+	Name    string
+	Status  diagnosisStatus
+	Message string
+	Hint    string
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose problems with the environment",
+	Long: `Check the environment infracli needs and explain how to fix what is wrong:
+
+  - the docker and docker-compose binaries and their versions
+  - that the Docker daemon is reachable and the socket can be used
+  - that the services path exists and contains services
+  - the free disk space where Docker keeps volumes
+  - that the host ports of every service are free
+  - vm.max_map_count, which Elasticsearch needs to be at least 262144
+
+Exits with a non-zero status when a check fails.
+
+Examples:
+  infracli doctor`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var results []diagnosis
+		results = append(results, checkDockerCLI(), checkComposeCLI())
+
+		daemon, info, daemonResult := checkDaemon()
+		results = append(results, daemonResult)
+
+		basePath, services, pathResult := checkServicesPath()
+		results = append(results, pathResult)
+
+		results = append(results, checkDiskSpace(daemon, info))
+		results = append(results, checkPorts(daemon, basePath, services)...)
+		if elastic := elasticsearchServices(basePath, services); len(elastic) > 0 {
+			results = append(results, checkMaxMapCount(info, elastic))
+		}
+
+		if printDiagnoses(os.Stdout, results) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// checkDockerCLI comprueba el cliente docker. infracli habla con el demonio
+// por su API, así que sin él solo se pierden comandos como 'docker exec'.
+func checkDockerCLI() diagnosis {
+	result := diagnosis{Name: "Docker CLI"}
+	if _, err := exec.LookPath("docker"); err != nil {
+		result.Status = diagnosisWarn
+		result.Message = "docker not found in PATH"
+		result.Hint = "Install Docker (https://docs.docker.com/get-docker/) to use commands such as 'docker exec'"
+		return result
+	}
+
+	output, err := exec.Command("docker", "version", "--format", "{{.Client.Version}}").Output()
+	version := strings.TrimSpace(string(output))
+	if version == "" {
+		// Sin demonio docker version termina con error pero muestra la versión del cliente
+		output, _ = exec.Command("docker", "--version").Output()
+		version = strings.TrimSpace(string(output))
+	}
+	if version == "" {
+		result.Status = diagnosisWarn
+		result.Message = fmt.Sprintf("cannot get the docker version: %v", err)
+		return result
+	}
+
+	result.Status = diagnosisPass
+	result.Message = "docker " + strings.TrimPrefix(version, "Docker version ")
+	return result
+}
+
+// checkComposeCLI comprueba el binario docker-compose que usan run, down y restart
+func checkComposeCLI() diagnosis {
+	result := diagnosis{Name: "Docker Compose"}
+	if _, err := exec.LookPath("docker-compose"); err != nil {
+		result.Status = diagnosisFail
+		result.Message = "docker-compose not found in PATH"
+		result.Hint = "Install Docker Compose, or link the Compose plugin into your PATH, e.g.\n" +
+			"ln -s /usr/libexec/docker/cli-plugins/docker-compose /usr/local/bin/docker-compose"
+		return result
+	}
+
+	output, err := exec.Command("docker-compose", "version", "--short").Output()
+	if err != nil {
+		result.Status = diagnosisFail
+		result.Message = fmt.Sprintf("docker-compose version failed: %v", err)
+		result.Hint = "Reinstall Docker Compose"
+		return result
+	}
+
+	version := strings.TrimPrefix(strings.TrimSpace(string(output)), "v")
+	result.Status = diagnosisPass
+	result.Message = "docker-compose " + version
+	if strings.HasPrefix(version, "1.") {
+		result.Status = diagnosisWarn
+		result.Hint = "Compose v1 is no longer maintained; install Compose v2"
+	}
+	return result
+}
+
+// checkDaemon comprueba que el demonio responde. Devuelve el cliente y la
+// información del demonio, o nil si no se puede usar.
+func checkDaemon() (*engine.Client, *engine.SystemInfo, diagnosis) {
+	result := diagnosis{Name: "Docker daemon"}
+
+	client, err := engine.NewFromEnv()
+	if err != nil {
+		result.Status = diagnosisFail
+		result.Message = err.Error()
+		result.Hint = "Fix DOCKER_HOST or unset it to use " + engine.DefaultHost
+		return nil, nil, result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	info, err := client.Info(ctx)
+	if err == nil {
+		result.Status = diagnosisPass
+		result.Message = fmt.Sprintf("Docker %s on %s (%s)", info.ServerVersion, info.OperatingSystem, client.Host())
+		return client, info, result
+	}
+
+	return nil, nil, daemonFailure(client.Host(), err)
+}
+
+// daemonFailure explica por qué no se puede usar el demonio de host
+func daemonFailure(host string, err error) diagnosis {
+	result := diagnosis{Name: "Docker daemon", Status: diagnosisFail}
+	result.Message = fmt.Sprintf("cannot reach %s: %v", host, err)
+
+	socketPath := ""
+	if u, parseErr := url.Parse(host); parseErr == nil && u.Scheme == "unix" {
+		socketPath = u.Path
+	}
+	switch {
+	case errors.Is(err, syscall.EACCES) || errors.Is(err, os.ErrPermission):
+		result.Message = fmt.Sprintf("permission denied on %s", socketPath)
+		result.Hint = "Add your user to the docker group and log in again:\nsudo usermod -aG docker $USER"
+	case socketPath != "" && !fileExists(socketPath):
+		result.Message = fmt.Sprintf("%s does not exist", socketPath)
+		result.Hint = "Start Docker (Docker Desktop, or 'sudo systemctl start docker'), or point DOCKER_HOST to your daemon"
+	default:
+		result.Hint = "Check that Docker is running and that DOCKER_HOST points to it"
+	}
+	return result
+}
+
+// checkServicesPath comprueba la ruta de servicios y devuelve los servicios que contiene
+func checkServicesPath() (string, []string, diagnosis) {
+	result := diagnosis{Name: "Services path"}
+
+	resolved, err := config.LoadResolved()
+	if err != nil {
+		result.Status = diagnosisFail
+		result.Message = err.Error()
+		result.Hint = "Fix the configuration with 'infracli config edit' or 'infracli config validate'"
+		return "", nil, result
+	}
+	basePath, err := config.ExpandPath(resolved.Config.ServicesPath)
+	if err != nil {
+		result.Status = diagnosisFail
+		result.Message = err.Error()
+		return "", nil, result
+	}
+	origin := resolved.Origins["servicesPath"]
+
+	stat, err := os.Stat(basePath)
+	if err != nil || !stat.IsDir() {
+		result.Status = diagnosisFail
+		result.Message = fmt.Sprintf("%s (from %s) does not exist", basePath, origin)
+		result.Hint = "Point infracli to the directory with the services:\n" +
+			"infracli config set-path /path/to/infrastructure/services\n" +
+			"or run 'infracli init', or set INFRACLI_SERVICES_PATH"
+		if origin == config.OriginDefault {
+			result.Hint = "The default path is ~/Development/infrastructure/services. " + result.Hint
+		}
+		return "", nil, result
+	}
+
+	services, err := config.ListServices(basePath, resolved.Config.ExcludedDirs)
+	if err != nil {
+		result.Status = diagnosisFail
+		result.Message = err.Error()
+		return basePath, nil, result
+	}
+	if len(services) == 0 {
+		result.Status = diagnosisWarn
+		result.Message = fmt.Sprintf("%s (from %s) has no services", basePath, origin)
+		result.Hint = "Each service is a subdirectory with a docker-compose.yml"
+		return basePath, nil, result
+	}
+
+	result.Status = diagnosisPass
+	result.Message = fmt.Sprintf("%s (from %s): %d services", basePath, origin, len(services))
+	return basePath, services, result
+}
+
+// checkDiskSpace comprueba el espacio libre del disco donde el demonio guarda
+// los volúmenes. Solo se puede medir si el demonio se ejecuta en esta máquina.
+func checkDiskSpace(client *engine.Client, info *engine.SystemInfo) diagnosis {
+	result := diagnosis{Name: "Disk space"}
+	if info == nil {
+		result.Status = diagnosisWarn
+		result.Message = "skipped: the Docker daemon is not reachable"
+		return result
+	}
+
+	if !strings.HasPrefix(client.Host(), "unix://") || runtime.GOOS != "linux" || strings.Contains(info.OperatingSystem, "Docker Desktop") {
+		result.Status = diagnosisWarn
+		result.Message = fmt.Sprintf("cannot measure %s: Docker runs in a VM or on another host", info.DockerRootDir)
+		result.Hint = "Check the disk size of the VM in Docker Desktop settings, and free space with 'docker system prune'"
+		return result
+	}
+
+	free, err := freeSpace(info.DockerRootDir)
+	if err != nil {
+		result.Status = diagnosisWarn
+		result.Message = fmt.Sprintf("cannot measure %s: %v", info.DockerRootDir, err)
+		return result
+	}
+
+	result.Message = fmt.Sprintf("%s free in %s", humanBytes(free), info.DockerRootDir)
+	switch {
+	case free < minFreeSpace:
+		result.Status = diagnosisFail
+	case free < lowFreeSpace:
+		result.Status = diagnosisWarn
+	default:
+		result.Status = diagnosisPass
+	}
+	if result.Status != diagnosisPass {
+		result.Hint = "Free space with 'docker system prune' or remove unused volumes with 'infracli down <service> -d'"
+	}
+	return result
+}
+
+// checkPorts comprueba que los puertos del host de cada servicio estén libres
+// o los use el propio servicio, y que dos servicios no publiquen el mismo
+func checkPorts(client *engine.Client, basePath string, services []string) []diagnosis {
+	if len(services) == 0 {
+		return nil
+	}
+
+	// Puertos que ya publican los contenedores de cada servicio
+	owners := make(map[int]string)
+	if client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		defer cancel()
+		if containers, err := client.ComposeContainers(ctx); err == nil {
+			groups := engine.GroupByService(containers, basePath, services)
+			for service, group := range groups {
+				for _, container := range group {
+					for _, port := range container.Ports {
+						if port.PublicPort != 0 {
+							owners[port.PublicPort] = service
+						}
+					}
+				}
+			}
+		}
+	}
+
+	ports := make(map[int][]string)
+	for _, service := range services {
+		for _, port := range serviceHostPorts(filepath.Join(basePath, service)) {
+			ports[port] = append(ports[port], service)
+		}
+	}
+
+	var problems []string
+	var hints []string
+	numbers := make([]int, 0, len(ports))
+	for port := range ports {
+		numbers = append(numbers, port)
+	}
+	sort.Ints(numbers)
+
+	for _, port := range numbers {
+		users := ports[port]
+		if len(users) > 1 {
+			problems = append(problems, fmt.Sprintf("%d is published by %s", port, strings.Join(users, " and ")))
+			hints = append(hints, fmt.Sprintf("Only one of %s can run at a time unless you change port %d in one of them", strings.Join(users, ", "), port))
+		}
+		// Un puerto ocupado por el propio servicio no es un problema
+		if _, ok := owners[port]; ok {
+			continue
+		}
+		if !portFree(port) {
+			problems = append(problems, fmt.Sprintf("%d (%s) is in use by another process", port, strings.Join(users, ", ")))
+			hints = append(hints, portHint(port))
+		}
+	}
+
+	result := diagnosis{Name: "Ports"}
+	if len(problems) == 0 {
+		result.Status = diagnosisPass
+		result.Message = fmt.Sprintf("%d host ports available", len(ports))
+		return []diagnosis{result}
+	}
+	result.Status = diagnosisFail
+	result.Message = strings.Join(problems, "; ")
+	result.Hint = strings.Join(hints, "\n")
+	return []diagnosis{result}
+}
+
+// serviceHostPorts devuelve los puertos del host que publica un servicio
+func serviceHostPorts(servicePath string) []int {
+	content, err := compose.Read(servicePath)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[int]bool)
+	var ports []int
+	containers := compose.Images(content)
+	for container := range containers {
+		for _, mapping := range compose.Ports(content, "  "+container+":") {
+			// "127.0.0.1:8080:80" o "8080:80"
+			parts := strings.Split(mapping, ":")
+			if len(parts) < 2 {
+				continue
+			}
+			port, err := strconv.Atoi(strings.TrimSpace(parts[len(parts)-2]))
+			if err == nil && !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
+// portFree indica si se puede escuchar en el puerto en todas las interfaces,
+// como hace Docker al publicarlo
+func portFree(port int) bool {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+func portHint(port int) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf("Find the process with 'netstat -ano | findstr :%d' and stop it, or change the port in docker-compose.yml", port)
+	}
+	return fmt.Sprintf("Find the process with 'lsof -i :%d' and stop it, or change the port in docker-compose.yml", port)
+}
+
+// elasticsearchServices devuelve los servicios que usan una imagen de Elasticsearch
+func elasticsearchServices(basePath string, services []string) []string {
+	var found []string
+	for _, service := range services {
+		content, err := compose.Read(filepath.Join(basePath, service))
+		if err != nil {
+			continue
+		}
+		for _, image := range compose.Images(content) {
+			if strings.Contains(strings.ToLower(image), "elasticsearch") {
+				found = append(found, service)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// checkMaxMapCount comprueba vm.max_map_count, sin el cual Elasticsearch no arranca
+func checkMaxMapCount(info *engine.SystemInfo, services []string) diagnosis {
+	result := diagnosis{Name: "vm.max_map_count"}
+	needed := strings.Join(services, ", ")
+
+	// Con Docker Desktop el valor que cuenta es el de su VM
+	if runtime.GOOS != "linux" || (info != nil && strings.Contains(info.OperatingSystem, "Docker Desktop")) {
+		result.Status = diagnosisWarn
+		result.Message = fmt.Sprintf("cannot check the Docker VM from here (needed by %s)", needed)
+		result.Hint = fmt.Sprintf("If Elasticsearch exits with 'max virtual memory areas too low', run:\ndocker run --rm --privileged alpine sysctl -w vm.max_map_count=%d", elasticsearchMapCount)
+		return result
+	}
+
+	data, err := os.ReadFile("/proc/sys/vm/max_map_count")
+	if err != nil {
+		result.Status = diagnosisWarn
+		result.Message = fmt.Sprintf("cannot read it: %v", err)
+		return result
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		result.Status = diagnosisWarn
+		result.Message = fmt.Sprintf("unexpected value %q", strings.TrimSpace(string(data)))
+		return result
+	}
+
+	if value < elasticsearchMapCount {
+		result.Status = diagnosisFail
+		result.Message = fmt.Sprintf("%d is below the %d that %s needs", value, elasticsearchMapCount, needed)
+		result.Hint = fmt.Sprintf("sudo sysctl -w vm.max_map_count=%d\n"+
+			"and to keep it after a reboot:\n"+
+			"echo 'vm.max_map_count=%d' | sudo tee /etc/sysctl.d/99-elasticsearch.conf", elasticsearchMapCount, elasticsearchMapCount)
+		return result
+	}
+	result.Status = diagnosisPass
+	result.Message = strconv.Itoa(value)
+	return result
+}
+
+// printDiagnoses muestra los resultados y devuelve cuántas comprobaciones fallaron
+func printDiagnoses(w io.Writer, results []diagnosis) int {
+	failed, warnings := 0, 0
+	for _, result := range results {
+		fmt.Fprintf(w, "[%s] %s: %s\n", result.Status, result.Name, result.Message)
+		if result.Hint != "" {
+			for _, line := range strings.Split(result.Hint, "\n") {
+				fmt.Fprintf(w, "       %s\n", line)
+			}
+		}
+		switch result.Status {
+		case diagnosisFail:
+			failed++
+		case diagnosisWarn:
+			warnings++
+		}
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case failed > 0:
+		fmt.Fprintf(w, "%d checks failed, %d warnings\n", failed, warnings)
+	case warnings > 0:
+		fmt.Fprintf(w, "No problems found, %d warnings\n", warnings)
+	default:
+		fmt.Fprintln(w, "No problems found")
+	}
+	return failed
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func init() {
+	RootCmd.AddCommand(doctorCmd)
+}
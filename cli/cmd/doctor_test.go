@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCheckDaemonPermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can connect to any socket")
+	}
+
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	if err := os.Chmod(socket, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_HOST", "unix://"+socket)
+
+	client, _, result := checkDaemon()
+	if client != nil || result.Status != diagnosisFail {
+		t.Fatalf("expected a failure, got %+v", result)
+	}
+	if result.Message != "permission denied on "+socket || !strings.Contains(result.Hint, "docker group") {
+		t.Errorf("unexpected diagnosis %+v", result)
+	}
+}
+
+func TestCheckDaemonMissingSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	t.Setenv("DOCKER_HOST", "unix://"+socket)
+
+	_, _, result := checkDaemon()
+	if result.Status != diagnosisFail || result.Message != socket+" does not exist" {
+		t.Errorf("unexpected diagnosis %+v", result)
+	}
+}
+
+func TestDaemonFailure(t *testing.T) {
+	// El cliente envuelve el error de conexión como lo hace net/http
+	dialError := func(host, network string, errno syscall.Errno) error {
+		return fmt.Errorf("cannot connect to the docker daemon at %s: %w", host,
+			&net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("connect", errno)})
+	}
+	const (
+		unixHost = "unix:///var/run/docker.sock"
+		tcpHost  = "tcp://127.0.0.1:2375"
+	)
+
+	tests := []struct {
+		name        string
+		host        string
+		err         error
+		wantMessage string
+		wantHint    string
+	}{
+		{"permission denied", unixHost, dialError(unixHost, "unix", syscall.EACCES), "permission denied on /var/run/docker.sock", "sudo usermod -aG docker $USER"},
+		{"connection refused", tcpHost, dialError(tcpHost, "tcp", syscall.ECONNREFUSED), "cannot reach " + tcpHost, "Check that Docker is running"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := daemonFailure(tt.host, tt.err)
+			if result.Status != diagnosisFail || !strings.HasPrefix(result.Message, tt.wantMessage) || !strings.Contains(result.Hint, tt.wantHint) {
+				t.Errorf("unexpected diagnosis %+v", result)
+			}
+		})
+	}
+}
@@ -1,13 +1,12 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
 	"github.com/spf13/cobra"
 )
 
@@ -15,121 +14,140 @@ var downCmd = &cobra.Command{
 	Use:   "down [service1] [service2] ... or 'all'",
 	Short: "Stop one or more infrastructure services",
 	Long: `Stop one or more infrastructure services using docker-compose.
-If 'all' is specified, it stops all available services.
+If 'all' is specified, it stops all available services and removes the shared
+infracli network.
 
 Examples:
   infracli down mysql
   infracli down mongo elasticsearch-kibana
-  infracli down all`,
+  infracli down all
+  infracli down postgres --instance ci
+  infracli down all --ephemeral`,
+	ValidArgsFunction: completeServices(0, true),
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
-			fmt.Println("Error: You must specify at least one service or 'all'")
+			logger.Errorf("Error: You must specify at least one service or 'all'")
 			cmd.Help()
 			return
 		}
 
 		// Verificar si se debe eliminar volúmenes
 		removeVolumes, _ := cmd.Flags().GetBool("volumes")
-		verbose, _ := cmd.Flags().GetBool("verbose")
 
 		// Obtener servicios disponibles
 		availableServices, err := config.GetAvailableServices()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			logger.Errorf("Error: %v", err)
 			return
 		}
 
-		// Obtener la configuración
-		cfg, err := config.LoadConfig()
+		// Obtener la ruta de servicios configurada, ya expandida
+		basePath, err := config.GetServicesPath()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			logger.Errorf("Error loading configuration: %v", err)
 			return
 		}
 
-		// Usar directamente la ruta de servicios configurada
-		basePath := cfg.ServicesPath
-
-		// Expandir la ruta si contiene ~/
-		if len(basePath) >= 2 && basePath[:2] == "~/" {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
-				return
-			}
-			basePath = filepath.Join(homeDir, basePath[2:])
+		logger.Debugf("Services path: %s", basePath)
+		logger.Debugf("Available services: %s", strings.Join(availableServices, ", "))
+		if removeVolumes {
+			logger.Debugf("Volumes will be removed")
 		}
 
-		if verbose {
-			fmt.Printf("Services path: %s\n", basePath)
-			fmt.Printf("Available services: %s\n", strings.Join(availableServices, ", "))
-			if removeVolumes {
-				fmt.Println("Volumes will be removed")
+		opts := stackOptions()
+
+		// Las instancias se eliminan siempre por completo, volúmenes incluidos
+		instanceName, _ := cmd.Flags().GetString("instance")
+		ephemeral, _ := cmd.Flags().GetBool("ephemeral")
+		if instanceName != "" || ephemeral {
+			services := availableServices
+			if len(args) != 1 || args[0] != "all" {
+				services = selectServices(args, availableServices)
 			}
+			stopInstances(services, basePath, instanceName, ephemeral)
+			return
 		}
 
 		// Comprobar si queremos detener todos los servicios
 		if len(args) == 1 && args[0] == "all" {
-			fmt.Println("Stopping all available services...")
-			stopAllServices(availableServices, basePath, removeVolumes, verbose)
+			logger.Infof("Stopping all available services...")
+			stopAllServices(availableServices, basePath, removeVolumes, opts)
 			return
 		}
 
 		// Detener los servicios especificados
-		for _, service := range args {
-			serviceFound := false
-			for _, availService := range availableServices {
-				if service == availService {
-					serviceFound = true
-					break
-				}
-			}
-
-			if !serviceFound {
-				fmt.Printf("Warning: Service '%s' not found in available services\n", service)
-				fmt.Printf("Available services: %s\n", strings.Join(availableServices, ", "))
-				continue
-			}
-
-			stopService(service, basePath, removeVolumes, verbose)
+		for _, service := range selectServices(args, availableServices) {
+			stopService(service, basePath, removeVolumes, opts)
 		}
 	},
 }
 
-func stopService(service, basePath string, removeVolumes, verbose bool) {
+// stopService detiene un servicio con docker-compose down. Los errores se
+// registran aquí y además se devuelven para que el llamador pueda reaccionar.
+func stopService(service, basePath string, removeVolumes bool, opts stack.Options) error {
 	servicePath := filepath.Join(basePath, service)
-	fmt.Printf("Stopping %s...\n", service)
 
-	args := []string{"down"}
-	if removeVolumes {
-		args = append(args, "-v")
+	var composeErr error
+	err := stack.Stop(service, basePath, removeVolumes, opts, func(args ...string) error {
+		logger.Infof("Stopping %s...", service)
+		if composeErr = composeCommand(service, servicePath, args...); composeErr != nil {
+			logComposeError("stopping", service, composeErr)
+			return composeErr
+		}
+		logger.Infof("%s stopped successfully", service)
+		return nil
+	})
+	if err != nil && err != composeErr {
+		logger.Errorf("Error: %v", err)
 	}
+	return err
+}
 
-	cmd := exec.Command("docker-compose", args...)
-	cmd.Dir = servicePath
-
-	if verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		output, err := cmd.CombinedOutput()
+// stopInstances elimina la instancia indicada de cada servicio o, con
+// ephemeral, todas sus instancias efímeras
+func stopInstances(services []string, basePath, name string, ephemeral bool) {
+	stopped := 0
+	for _, service := range services {
+		instances, err := listInstances(service)
 		if err != nil {
-			fmt.Printf("Error stopping %s: %v\n", service, err)
-			fmt.Println(string(output))
-			return
+			logger.Errorf("Error: %v", err)
+			continue
+		}
+		for _, inst := range instances {
+			if (name != "" && inst.Name != name) || (ephemeral && !inst.Ephemeral) {
+				continue
+			}
+			if stopInstance(service, basePath, inst.Name) == nil {
+				stopped++
+			}
 		}
 	}
 
-	fmt.Printf("%s stopped successfully\n", service)
+	if stopped == 0 {
+		if name != "" {
+			logger.Warnf("No instance named '%s' found", name)
+		} else {
+			logger.Infof("No ephemeral instances found")
+		}
+	}
 }
 
-func stopAllServices(services []string, basePath string, removeVolumes, verbose bool) {
+func stopAllServices(services []string, basePath string, removeVolumes bool, opts stack.Options) {
 	for _, service := range services {
-		stopService(service, basePath, removeVolumes, verbose)
+		stopService(service, basePath, removeVolumes, opts)
+	}
+	logger.Infof("All services have been stopped")
+
+	// La red compartida se crea de nuevo con el próximo 'infracli run'
+	if err := removeSharedNetwork(); err != nil {
+		logger.Warnf("Warning: could not remove the %s network: %v", stack.SharedNetwork, err)
 	}
-	fmt.Println("All services have been stopped")
 }
 
 func init() {
 	downCmd.Flags().BoolP("volumes", "d", false, "Remove volumes when stopping services")
+	downCmd.Flags().String("instance", "", "Remove an isolated instance, including its containers and volumes")
+	downCmd.RegisterFlagCompletionFunc("instance", completeInstances)
+	downCmd.Flags().Bool("ephemeral", false, "Remove every ephemeral instance of the services")
 	RootCmd.AddCommand(downCmd)
 }
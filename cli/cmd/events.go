@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/spf13/cobra"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events [service1] [service2] ...",
+	Short: "Stream container events of infrastructure services",
+	Long: `Stream the lifecycle events (start, stop, die, health_status...) of the
+containers of infrastructure services as they happen.
+Without arguments, events of all available services are shown.
+
+Examples:
+  infracli events
+  infracli events postgres`,
+	ValidArgsFunction: completeServices(0, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		services := availableServices
+		if len(args) > 0 {
+			services = selectServices(args, availableServices)
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+
+		filters := engine.Filters{}
+		filters.Add("type", "container")
+		filters.Add("label", engine.LabelProject)
+
+		events, errs := client.Events(cmd.Context(), engine.EventsOptions{Filters: filters})
+		for event := range events {
+			// Los atributos de un evento de contenedor incluyen sus etiquetas
+			container := engine.Container{
+				Names:  []string{event.Actor.Attributes["name"]},
+				Labels: event.Actor.Attributes,
+			}
+
+			for _, service := range services {
+				if engine.BelongsTo(container, filepath.Join(basePath, service)) {
+					timestamp := time.Unix(0, event.TimeNano).Format("2006-01-02 15:04:05")
+					fmt.Printf("%s  %-22s %-16s %s\n", timestamp, service, container.Name(), event.Action)
+					break
+				}
+			}
+		}
+
+		select {
+		case err := <-errs:
+			logger.Errorf("Error reading Docker events: %v", err)
+		default:
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(eventsCmd)
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/registry"
+	"github.com/spf13/cobra"
+)
+
+// serviceImage es una imagen usada por un contenedor de un servicio
+type serviceImage struct {
+	Service   string `json:"service"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	ID        string `json:"id,omitempty"`
+	Digest    string `json:"digest,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+	Pulled    bool   `json:"pulled"`
+}
+
+var imagesCmd = &cobra.Command{
+	Use:   "images [service1] [service2] ...",
+	Short: "List the images used by infrastructure services",
+	Long: `List the image of each container defined in the services' docker-compose.yml,
+with the digest and size of the local copy. Images that have not been pulled
+yet are marked as such.
+Without arguments, all available services are shown.
+
+Examples:
+  infracli images
+  infracli images mongo neo4j
+  infracli images -o json`,
+	ValidArgsFunction: completeServices(0, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		services := availableServices
+		if len(args) > 0 {
+			services = selectServices(args, availableServices)
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+
+		images := collectServiceImages(basePath, services)
+		for i := range images {
+			details, err := client.InspectImage(cmd.Context(), images[i].Image)
+			if err != nil {
+				if !engine.IsNotFound(err) {
+					logger.Errorf("Error inspecting image %s: %v", images[i].Image, err)
+				}
+				continue
+			}
+			images[i].Pulled = true
+			images[i].ID = details.ID
+			images[i].Size = details.Size
+			if ref, err := registry.ParseReference(images[i].Image); err == nil {
+				images[i].Digest = details.RepoDigest(ref.Name())
+			}
+		}
+
+		switch output {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			encoder.Encode(images)
+		case "table":
+			printImagesTable(images)
+		default:
+			logger.Errorf("Error: invalid output format %q (valid formats: table, json)", output)
+		}
+	},
+}
+
+// collectServiceImages lee del docker-compose.yml de cada servicio la imagen
+// de cada uno de sus contenedores
+func collectServiceImages(basePath string, services []string) []serviceImage {
+	images := []serviceImage{}
+	for _, service := range services {
+		composeFile := filepath.Join(basePath, service, "docker-compose.yml")
+		content, err := os.ReadFile(composeFile)
+		if err != nil {
+			logger.Errorf("Error reading docker-compose.yml of %s: %v", service, err)
+			continue
+		}
+
+		containers := compose.Images(string(content))
+		names := make([]string, 0, len(containers))
+		for name := range containers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			images = append(images, serviceImage{
+				Service:   service,
+				Container: name,
+				Image:     strings.Trim(containers[name], `"'`),
+			})
+		}
+	}
+	return images
+}
+
+// shortDigest acorta un digest sha256 a 12 caracteres como hace Docker
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+func printImagesTable(images []serviceImage) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tCONTAINER\tIMAGE\tDIGEST\tSIZE")
+	for _, image := range images {
+		if !image.Pulled {
+			fmt.Fprintf(w, "%s\t%s\t%s\tnot pulled\t-\n", image.Service, image.Container, image.Image)
+			continue
+		}
+		digest := "-"
+		if image.Digest != "" {
+			digest = shortDigest(image.Digest)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", image.Service, image.Container, image.Image, digest, humanBytes(uint64(image.Size)))
+	}
+	w.Flush()
+}
+
+func init() {
+	imagesCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	RootCmd.AddCommand(imagesCmd)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the InfraCLI configuration file",
+	Long: `Create the InfraCLI configuration file.
+The command looks for a services directory (a "services" folder in the current
+directory or any of its parents, and the default location), asks which one to
+use and writes the configuration file.
+
+Examples:
+  infracli init
+  infracli init --yes
+  infracli init --services-path ~/infrastructure/services`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		yes, _ := cmd.Flags().GetBool("yes")
+		force, _ := cmd.Flags().GetBool("force")
+		interactive := !yes && isTerminal(os.Stdin)
+		reader := bufio.NewReader(os.Stdin)
+
+		configPath, err := config.GetConfigFilePath()
+		if err != nil {
+			logger.Errorf("Error getting config path: %v", err)
+			return
+		}
+
+		// No sobrescribir una configuración existente sin confirmación
+		if _, err := os.Stat(configPath); err == nil && !force {
+			if !interactive {
+				logger.Errorf("Error: configuration already exists at %s (use --force to overwrite)", configPath)
+				return
+			}
+			if !askYesNo(reader, fmt.Sprintf("Configuration already exists at %s. Overwrite?", configPath), false) {
+				fmt.Println("Init cancelled")
+				return
+			}
+		}
+
+		cfg := config.GetDefaultConfig()
+
+		servicesPath := ""
+		if cmd.Flags().Changed("services-path") {
+			servicesPath, _ = cmd.Flags().GetString("services-path")
+		} else {
+			workingDir, _ := os.Getwd()
+			candidates := config.DetectServicesPaths(workingDir)
+
+			servicesPath = cfg.ServicesPath
+			if len(candidates) > 0 {
+				servicesPath = candidates[0].Path
+			}
+
+			if interactive {
+				servicesPath = promptServicesPath(reader, candidates, servicesPath)
+			}
+		}
+
+		servicesPath, err = config.ExpandPath(servicesPath)
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		if abs, err := filepath.Abs(servicesPath); err == nil {
+			servicesPath = abs
+		}
+
+		services, err := config.ListServices(servicesPath, cfg.ExcludedDirs)
+		if err != nil || len(services) == 0 {
+			logger.Warnf("Warning: no services found in %s", servicesPath)
+			if interactive && !askYesNo(reader, "Use it anyway?", false) {
+				fmt.Println("Init cancelled")
+				return
+			}
+		}
+
+		cfg.ServicesPath = servicesPath
+
+		if err := config.SaveConfig(cfg); err != nil {
+			logger.Errorf("Error saving configuration: %v", err)
+			return
+		}
+
+		fmt.Printf("Configuration written to %s\n", configPath)
+		fmt.Printf("Services path: %s\n", servicesPath)
+		if len(services) > 0 {
+			fmt.Printf("Services found: %s\n", strings.Join(services, ", "))
+		}
+	},
+}
+
+// promptServicesPath muestra los directorios detectados y pregunta cuál usar.
+// Se puede responder con el número de un candidato o con una ruta.
+func promptServicesPath(reader *bufio.Reader, candidates []config.ServicesCandidate, defaultPath string) string {
+	if len(candidates) > 0 {
+		fmt.Println("Detected services directories:")
+		for i, candidate := range candidates {
+			fmt.Printf("  %d) %s (%s)\n", i+1, candidate.Path, strings.Join(candidate.Services, ", "))
+		}
+	} else {
+		fmt.Println("No services directory was detected automatically.")
+	}
+
+	fmt.Printf("Services directory [%s]: ", defaultPath)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+
+	if answer == "" {
+		return defaultPath
+	}
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(candidates) {
+		return candidates[n-1].Path
+	}
+	return answer
+}
+
+// askYesNo hace una pregunta de sí o no y devuelve defaultYes si no hay respuesta
+func askYesNo(reader *bufio.Reader, question string, defaultYes bool) bool {
+	hint := "[y/N]"
+	if defaultYes {
+		hint = "[Y/n]"
+	}
+	fmt.Printf("%s %s: ", question, hint)
+
+	answer, _ := reader.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	default:
+		return defaultYes
+	}
+}
+
+func init() {
+	initCmd.Flags().BoolP("yes", "y", false, "Accept the detected services directory without asking")
+	initCmd.Flags().BoolP("force", "f", false, "Overwrite an existing configuration file")
+	RootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// instancesDirName es el directorio de estado con una carpeta por instancia
+	instancesDirName = "instances"
+	// instanceFileName guarda los datos de una instancia
+	instanceFileName = "instance.json"
+	// instanceLabelsFileName es el archivo de compose que etiqueta los
+	// contenedores de una instancia
+	instanceLabelsFileName = "labels.yml"
+	// ephemeralPrefix es el prefijo de los nombres generados por --ephemeral
+	ephemeralPrefix = "eph-"
+)
+
+var instanceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// instance es una copia aislada de un servicio con su propio proyecto de
+// compose, nombres de contenedor, puertos y volúmenes
+type instance struct {
+	Service   string    `json:"service"`
+	Name      string    `json:"name"`
+	Project   string    `json:"project"`
+	Ephemeral bool      `json:"ephemeral"`
+	Created   time.Time `json:"created"`
+	// Ports asocia "contenedor:puerto" con el puerto del host asignado
+	Ports map[string]string `json:"ports"`
+
+	dir string
+}
+
+var instancesCmd = &cobra.Command{
+	Use:   "instances [service1] [service2] ...",
+	Short: "List the isolated instances of services",
+	Long: `List the instances created with 'infracli run --instance' or '--ephemeral',
+with their compose project and the host ports they were given.
+
+Examples:
+  infracli instances
+  infracli instances mysql`,
+	ValidArgsFunction: completeServices(0, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		services := args
+		if len(services) == 0 {
+			available, err := config.GetAvailableServices()
+			if err != nil {
+				logger.Errorf("Error: %v", err)
+				return
+			}
+			services = available
+		}
+
+		var instances []*instance
+		for _, service := range services {
+			found, err := listInstances(service)
+			if err != nil {
+				logger.Errorf("Error: %v", err)
+				return
+			}
+			instances = append(instances, found...)
+		}
+
+		if len(instances) == 0 {
+			logger.Infof("No instances; create one with: infracli run <service> --instance <name>")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tINSTANCE\tPROJECT\tPORTS\tEPHEMERAL\tCREATED")
+		for _, inst := range instances {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\n", inst.Service, inst.Name, inst.Project, inst.portsText(), inst.Ephemeral, inst.Created.Format(time.RFC3339))
+		}
+		w.Flush()
+	},
+}
+
+// validateInstanceName comprueba que el nombre sirva como parte del proyecto de compose
+func validateInstanceName(name string) error {
+	if !instanceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid instance name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// newEphemeralName genera un nombre de instancia aleatorio
+func newEphemeralName() (string, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("error generating instance name: %v", err)
+	}
+	return ephemeralPrefix + hex.EncodeToString(suffix), nil
+}
+
+func instancesDir(service string) (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, instancesDirName, service), nil
+}
+
+// loadInstance lee una instancia existente
+func loadInstance(service, name string) (*instance, error) {
+	dir, err := instancesDir(service)
+	if err != nil {
+		return nil, err
+	}
+	dir = filepath.Join(dir, name)
+
+	data, err := os.ReadFile(filepath.Join(dir, instanceFileName))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("instance '%s' of %s not found", name, service)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading instance %s: %v", name, err)
+	}
+
+	inst := &instance{dir: dir}
+	if err := json.Unmarshal(data, inst); err != nil {
+		return nil, fmt.Errorf("error parsing instance %s: %v", name, err)
+	}
+	return inst, nil
+}
+
+// listInstances devuelve las instancias de un servicio ordenadas por nombre
+func listInstances(service string) ([]*instance, error) {
+	dir, err := instancesDir(service)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading instances of %s: %v", service, err)
+	}
+
+	var instances []*instance
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		inst, err := loadInstance(service, entry.Name())
+		if err != nil {
+			logger.Warnf("Warning: %v", err)
+			continue
+		}
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Name < instances[j].Name })
+	return instances, nil
+}
+
+// prepareInstance crea la instancia si no existe: copia el docker-compose.yml
+// del servicio con nombres de contenedor propios y puertos del host libres.
+// Si ya existe se reutiliza para que conserve sus puertos.
+func prepareInstance(service, basePath, name string, ephemeral bool) (*instance, error) {
+	if inst, err := loadInstance(service, name); err == nil {
+		return inst, nil
+	}
+
+	content, err := compose.Read(filepath.Join(basePath, service))
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := instancesDir(service)
+	if err != nil {
+		return nil, err
+	}
+
+	inst := &instance{
+		Service:   service,
+		Name:      name,
+		Project:   engine.InstanceProject(filepath.Join(basePath, service), name),
+		Ephemeral: ephemeral,
+		Created:   time.Now().UTC().Truncate(time.Second),
+		Ports:     make(map[string]string),
+		dir:       filepath.Join(dir, name),
+	}
+
+	var portErr error
+	rewritten, err := compose.Rewrite(content,
+		func(_, containerName string) string {
+			return containerName + "-" + name
+		},
+		func(container, host, target string) string {
+			port, err := freePort()
+			if err != nil {
+				portErr = err
+				return host
+			}
+			inst.Ports[container+":"+target] = port
+			return port
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if portErr != nil {
+		return nil, portErr
+	}
+
+	if err := os.MkdirAll(inst.dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating instance directory: %v", err)
+	}
+	if err := os.WriteFile(inst.composeFile(), []byte(rewritten), 0644); err != nil {
+		return nil, fmt.Errorf("error writing instance compose file: %v", err)
+	}
+	if err := inst.save(); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (i *instance) save() error {
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(i.dir, instanceFileName), data, 0644); err != nil {
+		return fmt.Errorf("error saving instance %s: %v", i.Name, err)
+	}
+	return nil
+}
+
+// composeFile es la copia del docker-compose.yml adaptada a la instancia
+func (i *instance) composeFile() string {
+	return filepath.Join(i.dir, compose.FileName)
+}
+
+// writeLabels escribe el archivo de compose que etiqueta los contenedores de
+// la instancia con su nombre y devuelve su ruta. Se reescribe en cada arranque
+// para que también lo tengan las instancias creadas antes de existir.
+func (i *instance) writeLabels() (string, error) {
+	content, err := compose.Read(i.dir)
+	if err != nil {
+		return "", err
+	}
+
+	containers := make(map[string]bool)
+	for name := range compose.Images(content) {
+		containers[name] = true
+	}
+	for name := range compose.ContainerNames(content) {
+		containers[name] = true
+	}
+
+	var labels strings.Builder
+	labels.WriteString("services:\n")
+	for _, container := range sortedKeys(containers) {
+		fmt.Fprintf(&labels, "  %s:\n    labels:\n      %s: %q\n", container, engine.LabelInstance, i.Name)
+	}
+
+	path := filepath.Join(i.dir, instanceLabelsFileName)
+	if err := os.WriteFile(path, []byte(labels.String()), 0644); err != nil {
+		return "", fmt.Errorf("error writing instance labels: %v", err)
+	}
+	return path, nil
+}
+
+// composeArgs devuelve los argumentos de docker-compose para la instancia.
+// --project-directory mantiene las rutas relativas del servicio, como ./init.
+func (i *instance) composeArgs(basePath string) []string {
+	return []string{"-p", i.Project, "--project-directory", filepath.Join(basePath, i.Service), "-f", i.composeFile()}
+}
+
+// portsText devuelve los puertos como "mysql:3306->49153"
+func (i *instance) portsText() string {
+	keys := make([]string, 0, len(i.Ports))
+	for key := range i.Ports {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var ports []string
+	for _, key := range keys {
+		ports = append(ports, key+"->"+i.Ports[key])
+	}
+	if len(ports) == 0 {
+		return "-"
+	}
+	return strings.Join(ports, ", ")
+}
+
+// runInstance inicia una instancia aislada de un servicio
+func runInstance(service, basePath, name string, ephemeral bool, opts stack.Options) error {
+	inst, err := prepareInstance(service, basePath, name, ephemeral)
+	if err != nil {
+		logger.Errorf("Error preparing instance %s of %s: %v", name, service, err)
+		return err
+	}
+
+	servicePath := filepath.Join(basePath, service)
+	logger.Infof("Starting %s (instance %s)...", service, name)
+
+	// Las instancias comparten las credenciales del servicio
+	if err := stack.EnsureCredentials(service, basePath, opts); err != nil {
+		logger.Errorf("Error generating credentials for %s: %v", service, err)
+		return err
+	}
+
+	labelsPath, err := inst.writeLabels()
+	if err != nil {
+		logger.Errorf("Error starting %s (instance %s): %v", service, name, err)
+		return err
+	}
+
+	overrides, cleanup, err := stack.OverrideArgs(service, basePath, opts)
+	if err != nil {
+		logger.Errorf("Error starting %s (instance %s): %v", service, name, err)
+		return err
+	}
+	defer cleanup()
+
+	args := append(inst.composeArgs(basePath), "-f", labelsPath)
+	args = append(append(args, overrides...), "up", "-d")
+	if err := composeCommand(service, servicePath, args...); err != nil {
+		logComposeError("starting", service, err)
+		inst.reportProblems(servicePath)
+		return err
+	}
+
+	logger.Infof("%s (instance %s) started successfully on ports %s", service, name, inst.portsText())
+	logger.Infof("Connection details: infracli info %s --instance %s", service, name)
+	inst.reportProblems(servicePath)
+	return nil
+}
+
+// reportProblems registra los contenedores de la instancia que no están sanos
+func (i *instance) reportProblems(servicePath string) {
+	reportProblems(i.Service, func(ctx context.Context, client *engine.Client) ([]engine.Container, error) {
+		return client.InstanceContainers(ctx, servicePath, i.Name)
+	})
+}
+
+// stopInstance detiene una instancia y elimina sus contenedores, volúmenes y archivos
+func stopInstance(service, basePath, name string) error {
+	inst, err := loadInstance(service, name)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return err
+	}
+
+	servicePath := filepath.Join(basePath, service)
+	logger.Infof("Stopping %s (instance %s)...", service, name)
+
+	args := append(inst.composeArgs(basePath), "down", "-v", "--remove-orphans")
+	if err := composeCommand(service, servicePath, args...); err != nil {
+		logComposeError("stopping", service, err)
+		return err
+	}
+
+	if err := os.RemoveAll(inst.dir); err != nil {
+		logger.Warnf("Warning: could not remove %s: %v", inst.dir, err)
+	}
+	logger.Infof("%s (instance %s) stopped and removed", service, name)
+	return nil
+}
+
+// freePort pide al sistema un puerto TCP libre en el host
+func freePort() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("error finding a free port: %v", err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+func init() {
+	RootCmd.AddCommand(instancesCmd)
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+)
+
+const mysqlCompose = `services:
+  db:
+    image: mysql:8
+    container_name: mysql
+    ports:
+      - "${MYSQL_PORT:-3306}:3306"
+      - target: 33060
+        published: 33060
+  admin:
+    image: adminer
+    container_name: "mysql-admin"
+    ports:
+      - 127.0.0.1:8080:8080
+`
+
+func TestPrepareInstance(t *testing.T) {
+	basePath := setupServices(t, map[string]string{"mysql": mysqlCompose})
+
+	inst, err := prepareInstance("mysql", basePath, "feature", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(inst.Ports) != 3 {
+		t.Fatalf("ports = %v, want one per published port", inst.Ports)
+	}
+	seen := make(map[string]bool)
+	for key, port := range inst.Ports {
+		if !regexp.MustCompile(`^\d+$`).MatchString(port) || seen[port] {
+			t.Errorf("port %s = %q, want a distinct free port", key, port)
+		}
+		seen[port] = true
+	}
+
+	content, err := compose.Read(inst.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `services:
+  db:
+    image: mysql:8
+    container_name: mysql-feature
+    ports:
+      - "` + inst.Ports["db:3306"] + `:3306"
+      - target: 33060
+        published: ` + inst.Ports["db:33060"] + `
+  admin:
+    image: adminer
+    container_name: mysql-admin-feature
+    ports:
+      - 127.0.0.1:` + inst.Ports["admin:8080"] + `:8080
+`
+	if content != want {
+		t.Errorf("instance compose file:\n%s\nwant:\n%s", content, want)
+	}
+
+	// Una instancia existente conserva sus puertos
+	again, err := prepareInstance("mysql", basePath, "feature", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.portsText() != inst.portsText() {
+		t.Errorf("ports changed from %s to %s", inst.portsText(), again.portsText())
+	}
+}
+
+func TestPrepareInstancePortRange(t *testing.T) {
+	basePath := setupServices(t, map[string]string{"kafka": `services:
+  broker:
+    image: kafka
+    ports:
+      - "9092-9094:9092-9094"
+`})
+
+	if _, err := prepareInstance("kafka", basePath, "feature", false); err == nil || !strings.Contains(err.Error(), "port range 9092-9094") {
+		t.Fatalf("expected a port range error, got %v", err)
+	}
+	// No queda una instancia a medias
+	if _, err := loadInstance("kafka", "feature"); err == nil {
+		t.Error("the instance was saved")
+	}
+}
+
+func TestStopInstanceRemovesVolumes(t *testing.T) {
+	basePath := setupServices(t, map[string]string{"mysql": mysqlCompose})
+	calls := fakeCompose(t)
+
+	inst, err := prepareInstance("mysql", basePath, "feature", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopInstances([]string{"mysql"}, basePath, "feature", false)
+
+	want := "-p " + inst.Project + " --project-directory " + filepath.Join(basePath, "mysql") + " -f " + inst.composeFile() + " down -v --remove-orphans"
+	if got := calls(); len(got) != 1 || got[0] != want {
+		t.Errorf("docker-compose calls = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(inst.dir); !os.IsNotExist(err) {
+		t.Errorf("instance directory still exists: %v", err)
+	}
+}
+
+// fakeCompose pone en el PATH un docker-compose que solo registra sus
+// argumentos y devuelve una función que lee las llamadas
+func fakeCompose(t *testing.T) func() []string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake docker-compose is a shell script")
+	}
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls.log")
+	script := "#!/bin/sh\necho \"$*\" >> '" + log + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return func() []string {
+		data, err := os.ReadFile(log)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
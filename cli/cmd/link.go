@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/infracli"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+	"github.com/spf13/cobra"
+)
+
+var networkNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+var linkCmd = &cobra.Command{
+	Use:   "link [service]",
+	Short: "Attach a service to a shared Docker network",
+	Long: `Attach the containers of a service to an external Docker network so that
+applications running in their own compose projects reach it by name instead of
+through localhost. The network is created if it does not exist.
+
+The link is remembered: 'infracli run' and 'infracli recreate' join the network
+again when the containers are recreated. Inside the network the service answers
+to its name (e.g. postgres), its container_name and its compose service name,
+on the container ports. See them with: infracli info <service> --network
+
+Every service already joins the shared infracli network when it starts; link
+adds networks of your own. Without arguments it lists the current links.
+
+Examples:
+  infracli link postgres --network myapp
+  infracli link redis --network myapp
+  infracli link postgres --network myapp --remove
+  infracli link`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeServices(1, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		network, _ := cmd.Flags().GetString("network")
+		remove, _ := cmd.Flags().GetBool("remove")
+
+		if len(args) == 0 {
+			if err := listLinks(os.Stdout); err != nil {
+				logger.Errorf("Error: %v", err)
+			}
+			return
+		}
+
+		if network == "" {
+			logger.Errorf("Error: --network is required")
+			return
+		}
+		if !networkNamePattern.MatchString(network) {
+			logger.Errorf("Error: invalid network name %q", network)
+			return
+		}
+		if network == stack.SharedNetwork {
+			logger.Errorf("Error: every service already joins the %s network when it starts", stack.SharedNetwork)
+			return
+		}
+		service, err := resolveServiceName(args[0])
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		if remove {
+			if err := unlinkService(service, basePath, network); err != nil {
+				logger.Errorf("Error unlinking %s from %s: %v", service, network, err)
+				return
+			}
+			logger.Infof("%s detached from network %s", service, network)
+			return
+		}
+
+		if err := linkService(service, basePath, network); err != nil {
+			logger.Errorf("Error linking %s to %s: %v", service, network, err)
+			return
+		}
+		logger.Infof("%s attached to network %s", service, network)
+		printNetworkSnippet(os.Stdout, network)
+	},
+}
+
+// linkService recuerda la red del servicio y conecta los contenedores que ya existen
+func linkService(service, basePath, network string) error {
+	links, err := stack.LoadLinks()
+	if err != nil {
+		return err
+	}
+	if !containsString(links[service], network) {
+		links[service] = append(links[service], network)
+		sort.Strings(links[service])
+	}
+
+	client, err := engine.NewFromEnv()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if err := client.EnsureNetwork(ctx, network, map[string]string{stack.LabelManaged: "true"}); err != nil {
+		return fmt.Errorf("error creating network %s: %v", network, err)
+	}
+
+	servicePath := filepath.Join(basePath, service)
+	content, err := compose.Read(servicePath)
+	if err != nil {
+		return err
+	}
+	aliases := infracli.NetworkAliases(service, content)
+
+	containers, err := client.ServiceContainers(ctx, servicePath)
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if err := client.ConnectNetwork(ctx, network, container.ID, aliases[container.ComposeService()]); err != nil {
+			return fmt.Errorf("error connecting %s: %v", container.Name(), err)
+		}
+		logger.Debugf("Connected %s to %s as %s", container.Name(), network, strings.Join(aliases[container.ComposeService()], ", "))
+	}
+	if len(containers) == 0 {
+		logger.Infof("%s is not running; it will join %s when it starts", service, network)
+	}
+
+	return stack.SaveLinks(links)
+}
+
+// unlinkService olvida la red del servicio y desconecta sus contenedores.
+// La red no se elimina porque otros proyectos pueden usarla.
+func unlinkService(service, basePath, network string) error {
+	links, err := stack.LoadLinks()
+	if err != nil {
+		return err
+	}
+	if !containsString(links[service], network) {
+		return fmt.Errorf("%s is not linked to %s", service, network)
+	}
+
+	client, err := engine.NewFromEnv()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	existing, err := client.InspectNetwork(ctx, network)
+	if err != nil && !engine.IsNotFound(err) {
+		return err
+	}
+	if existing != nil {
+		containers, err := client.ServiceContainers(ctx, filepath.Join(basePath, service))
+		if err != nil {
+			return err
+		}
+		for _, container := range containers {
+			if !existing.HasContainer(container.ID) {
+				continue
+			}
+			if err := client.DisconnectNetwork(ctx, network, container.ID); err != nil {
+				return fmt.Errorf("error disconnecting %s: %v", container.Name(), err)
+			}
+		}
+	}
+
+	var remaining []string
+	for _, linked := range links[service] {
+		if linked != network {
+			remaining = append(remaining, linked)
+		}
+	}
+	links[service] = remaining
+	return stack.SaveLinks(links)
+}
+
+// listLinks muestra las redes de cada servicio
+func listLinks(w io.Writer) error {
+	links, err := stack.LoadLinks()
+	if err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		logger.Infof("No links; create one with: infracli link <service> --network <name>")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tNETWORKS")
+	for _, service := range sortedKeys(links) {
+		fmt.Fprintf(tw, "%s\t%s\n", service, strings.Join(links[service], ", "))
+	}
+	return tw.Flush()
+}
+
+// removeSharedNetwork elimina la red compartida de infracli si ya no tiene
+// contenedores conectados
+func removeSharedNetwork() error {
+	client, err := engine.NewFromEnv()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	network, err := client.InspectNetwork(ctx, stack.SharedNetwork)
+	if engine.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if network.Labels[stack.LabelManaged] != "true" {
+		logger.Debugf("Keeping network %s: it was not created by infracli", stack.SharedNetwork)
+		return nil
+	}
+	if len(network.Containers) > 0 {
+		var names []string
+		for _, endpoint := range network.Containers {
+			names = append(names, endpoint.Name)
+		}
+		sort.Strings(names)
+		logger.Warnf("Keeping network %s: still used by %s", stack.SharedNetwork, strings.Join(names, ", "))
+		return nil
+	}
+
+	if err := client.RemoveNetwork(ctx, stack.SharedNetwork); err != nil {
+		return err
+	}
+	logger.Infof("Removed network %s", stack.SharedNetwork)
+	return nil
+}
+
+// printNetworkSnippet muestra cómo unir una aplicación a la red desde su docker-compose.yml
+func printNetworkSnippet(w io.Writer, network string) {
+	fmt.Fprintln(w, "Add the network to your application's docker-compose.yml:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "services:")
+	fmt.Fprintln(w, "  app:")
+	fmt.Fprintln(w, "    networks:")
+	fmt.Fprintln(w, "      - default")
+	fmt.Fprintf(w, "      - %s\n", network)
+	fmt.Fprintln(w, "networks:")
+	fmt.Fprintf(w, "  %s:\n", network)
+	fmt.Fprintln(w, "    external: true")
+}
+
+func init() {
+	linkCmd.Flags().String("network", "", "Name of the external Docker network")
+	linkCmd.Flags().Bool("remove", false, "Detach the service from the network")
+	RootCmd.AddCommand(linkCmd)
+}
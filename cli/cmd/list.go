@@ -2,10 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
 	"github.com/spf13/cobra"
 )
 
@@ -18,12 +18,12 @@ These are the services that can be used with the run and down commands.`,
 		// Obtener servicios disponibles
 		availableServices, err := config.GetAvailableServices()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			logger.Errorf("Error: %v", err)
 			return
 		}
 
 		if len(availableServices) == 0 {
-			fmt.Println("No services found. Check your configuration.")
+			logger.Warnf("No services found. Check your configuration.")
 			return
 		}
 
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/lock"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/registry"
+	"github.com/spf13/cobra"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Pin service images to exact digests",
+	Long: `Show the image digests pinned in infracli.lock.
+
+The lock file lives at the root of the services path and records the digest
+each service's images resolved to, so that everyone sharing the services gets
+identical versions even for tags like "latest". When it exists, 'infracli run',
+'infracli recreate' and 'infracli pull' use the pinned digests.
+
+Examples:
+  infracli lock
+  infracli lock update
+  infracli lock update mongo
+  infracli lock check`,
+	Run: func(cmd *cobra.Command, args []string) {
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		path := lock.Path(basePath)
+		lockFile, err := lock.Load(path)
+		if os.IsNotExist(err) {
+			logger.Infof("No lock file at %s; create it with 'infracli lock update'", path)
+			return
+		}
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		fmt.Printf("Lock file: %s\n\n", path)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tCONTAINER\tIMAGE\tDIGEST")
+		for _, service := range sortedKeys(lockFile.Services) {
+			for _, container := range sortedKeys(lockFile.Services[service]) {
+				entry := lockFile.Services[service][container]
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service, container, entry.Image, shortDigest(entry.Digest))
+			}
+		}
+		w.Flush()
+	},
+}
+
+var lockUpdateCmd = &cobra.Command{
+	Use:   "update [service1] [service2] ...",
+	Short: "Resolve and pin the current image digests",
+	Long: `Resolve the digest every image tag currently points to and record it in
+infracli.lock, creating the file if needed. Without arguments all services are
+updated; otherwise only the given ones, keeping the rest of the lock untouched.
+
+By default digests are resolved against the registry. Use --local to pin the
+images already pulled on this machine instead.
+
+Examples:
+  infracli lock update
+  infracli lock update mongo neo4j
+  infracli lock update --local
+  infracli lock update --registry http://localhost:5000`,
+	ValidArgsFunction: completeServices(0, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		local, _ := cmd.Flags().GetBool("local")
+		mirror, _ := cmd.Flags().GetString("registry")
+
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		services := availableServices
+		if len(args) > 0 {
+			services = selectServices(args, availableServices)
+		}
+
+		path := lock.Path(basePath)
+		lockFile, err := lock.Load(path)
+		if os.IsNotExist(err) {
+			lockFile = lock.New()
+		} else if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+		reg := registry.NewClient(mirror)
+
+		ctx := cmd.Context()
+		failed := false
+		for _, service := range services {
+			// Se descartan las entradas de contenedores que ya no existen
+			delete(lockFile.Services, service)
+
+			for _, image := range collectServiceImages(basePath, []string{service}) {
+				digest, err := resolveDigest(ctx, client, reg, image.Image, local)
+				if err != nil {
+					logger.Errorf("Error resolving %s for %s: %v", image.Image, service, err)
+					failed = true
+					continue
+				}
+
+				lockFile.Set(service, image.Container, lock.Entry{Image: image.Image, Digest: digest})
+				logger.Infof("Locked %s/%s %s to %s", service, image.Container, image.Image, shortDigest(digest))
+			}
+		}
+
+		if failed {
+			logger.Errorf("Lock file not updated because some images could not be resolved")
+			return
+		}
+
+		if err := lockFile.Save(path); err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		logger.Infof("Lock file written to %s", path)
+	},
+}
+
+var lockCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Verify that infracli.lock matches the compose files",
+	Long: `Check that every image in the services' docker-compose.yml files is pinned in
+infracli.lock with the same reference. Exits with a non-zero status when the
+lock file is missing or out of date, so it can be used in CI.
+
+Examples:
+  infracli lock check`,
+	Run: func(cmd *cobra.Command, args []string) {
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			os.Exit(1)
+		}
+
+		path := lock.Path(basePath)
+		lockFile, err := lock.Load(path)
+		if os.IsNotExist(err) {
+			logger.Errorf("No lock file at %s; create it with 'infracli lock update'", path)
+			os.Exit(1)
+		}
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+
+		problems := checkLock(lockFile, collectServiceImages(basePath, availableServices))
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				logger.Errorf("%s", problem)
+			}
+			logger.Errorf("%s is out of date; run 'infracli lock update' to refresh it", path)
+			os.Exit(1)
+		}
+
+		logger.Infof("%s is up to date", path)
+	},
+}
+
+// checkLock compara las imágenes de los docker-compose.yml con el lockfile
+func checkLock(lockFile *lock.File, images []serviceImage) []string {
+	var problems []string
+	inCompose := make(map[string]map[string]bool)
+
+	for _, image := range images {
+		if inCompose[image.Service] == nil {
+			inCompose[image.Service] = make(map[string]bool)
+		}
+		inCompose[image.Service][image.Container] = true
+
+		entry, ok := lockFile.Lookup(image.Service, image.Container)
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s/%s: %s is not locked", image.Service, image.Container, image.Image))
+		case entry.Image != image.Image:
+			problems = append(problems, fmt.Sprintf("%s/%s: docker-compose.yml uses %s but the lock has %s", image.Service, image.Container, image.Image, entry.Image))
+		}
+	}
+
+	for _, service := range sortedKeys(lockFile.Services) {
+		for _, container := range sortedKeys(lockFile.Services[service]) {
+			if !inCompose[service][container] {
+				problems = append(problems, fmt.Sprintf("%s/%s: locked but no longer defined", service, container))
+			}
+		}
+	}
+
+	return problems
+}
+
+// resolveDigest obtiene el digest al que apunta una imagen, en el registro o
+// en la copia local
+func resolveDigest(ctx context.Context, client *engine.Client, reg *registry.Client, image string, local bool) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Pinned() {
+		return ref.Digest, nil
+	}
+
+	if !local {
+		return reg.Digest(ctx, ref)
+	}
+
+	details, err := client.InspectImage(ctx, image)
+	if engine.IsNotFound(err) {
+		return "", fmt.Errorf("image not pulled; run 'infracli pull' first")
+	}
+	if err != nil {
+		return "", err
+	}
+	digest := details.RepoDigest(ref.Name())
+	if digest == "" {
+		return "", fmt.Errorf("local image has no registry digest")
+	}
+	return digest, nil
+}
+
+// lockedImages sustituye cada imagen por su referencia fijada en el lockfile.
+// Las imágenes que no están en el lockfile o cuya referencia cambió en el
+// docker-compose.yml se dejan como están, con un aviso en el segundo caso.
+func lockedImages(basePath string, images []serviceImage) []serviceImage {
+	lockFile := loadLockFile(basePath)
+	if lockFile == nil {
+		return images
+	}
+
+	byService := make(map[string]map[string]string)
+	for _, image := range images {
+		if byService[image.Service] == nil {
+			byService[image.Service] = make(map[string]string)
+		}
+		byService[image.Service][image.Container] = image.Image
+	}
+	pins := make(map[string]map[string]string, len(byService))
+	for service, containers := range byService {
+		pins[service] = servicePins(lockFile, service, containers)
+	}
+
+	locked := make([]serviceImage, 0, len(images))
+	for _, image := range images {
+		if pinned, ok := pins[image.Service][image.Container]; ok {
+			image.Image = pinned
+		} else {
+			logger.Debugf("%s/%s is not pinned", image.Service, image.Container)
+		}
+		locked = append(locked, image)
+	}
+	return locked
+}
+
+// loadLockFile lee el lockfile de la ruta de servicios. Devuelve nil si no
+// existe o, con un aviso, si no se puede leer.
+func loadLockFile(basePath string) *lock.File {
+	lockFile, err := lock.Load(lock.Path(basePath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		logger.Warnf("Warning: ignoring lock file: %v", err)
+		return nil
+	}
+	return lockFile
+}
+
+// servicePins devuelve las imágenes fijadas de los contenedores de un servicio
+// y avisa de los que usan una imagen distinta de la del lockfile
+func servicePins(lockFile *lock.File, service string, containers map[string]string) map[string]string {
+	pins, stale := lockFile.Pins(service, containers)
+	for _, container := range stale {
+		entry, _ := lockFile.Lookup(service, container)
+		logger.Warnf("Warning: %s/%s uses %s but the lock has %s; run 'infracli lock update %s'", service, container, containers[container], entry.Image, service)
+	}
+	return pins
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	lockUpdateCmd.Flags().Bool("local", false, "Pin the digests of the images pulled on this machine instead of querying the registry")
+	lockUpdateCmd.Flags().String("registry", "", "Registry URL to query instead of each image's registry, e.g. http://localhost:5000")
+	lockCmd.AddCommand(lockUpdateCmd)
+	lockCmd.AddCommand(lockCheckCmd)
+	RootCmd.AddCommand(lockCmd)
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [service]",
+	Short: "Show the logs of a service's containers",
+	Long: `Show the logs of the containers of an infrastructure service.
+When a service has several containers each line is prefixed with the
+container name; use --container to show only one of them.
+
+Examples:
+  infracli logs mysql
+  infracli logs elasticsearch-kibana --container kibana -f
+  infracli logs postgres --tail 50`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeServices(1, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, _ := cmd.Flags().GetBool("follow")
+		tail, _ := cmd.Flags().GetInt("tail")
+		timestamps, _ := cmd.Flags().GetBool("timestamps")
+		containerName, _ := cmd.Flags().GetString("container")
+
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		services := selectServices(args, availableServices)
+		if len(services) == 0 {
+			return
+		}
+		service := services[0]
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+
+		ctx := cmd.Context()
+		containers, err := client.ServiceContainers(ctx, filepath.Join(basePath, service))
+		if err != nil {
+			logger.Errorf("Error listing containers: %v", err)
+			return
+		}
+
+		containers = filterContainers(containers, containerName)
+		if len(containers) == 0 {
+			logger.Warnf("No containers found for %s; start it with: infracli run %s", service, service)
+			return
+		}
+
+		opts := engine.LogsOptions{Follow: follow, Tail: tail, Timestamps: timestamps}
+		prefix := len(containers) > 1
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		for _, container := range containers {
+			wg.Add(1)
+			go func(container engine.Container) {
+				defer wg.Done()
+
+				stdout, stderr := io.Writer(os.Stdout), io.Writer(os.Stderr)
+				if prefix {
+					stdout = &prefixWriter{mu: &mu, out: os.Stdout, prefix: container.Name() + " | "}
+					stderr = &prefixWriter{mu: &mu, out: os.Stderr, prefix: container.Name() + " | "}
+				}
+
+				if err := copyContainerLogs(ctx, client, container, opts, stdout, stderr); err != nil {
+					logger.Errorf("Error reading logs of %s: %v", container.Name(), err)
+				}
+			}(container)
+		}
+		wg.Wait()
+	},
+}
+
+// filterContainers devuelve los contenedores cuyo nombre o servicio de compose
+// coincide con name; si name está vacío los devuelve todos
+func filterContainers(containers []engine.Container, name string) []engine.Container {
+	if name == "" {
+		return containers
+	}
+
+	var result []engine.Container
+	for _, container := range containers {
+		if container.Name() == name || container.ComposeService() == name {
+			result = append(result, container)
+		}
+	}
+	return result
+}
+
+// copyContainerLogs escribe los logs de un contenedor, separando stdout y
+// stderr si el contenedor no usa TTY
+func copyContainerLogs(ctx context.Context, client *engine.Client, container engine.Container, opts engine.LogsOptions, stdout, stderr io.Writer) error {
+	details, err := client.InspectContainer(ctx, container.ID)
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.ContainerLogs(ctx, container.ID, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if details.Config.Tty {
+		_, err = io.Copy(stdout, stream)
+	} else {
+		err = engine.StdCopy(stdout, stderr, stream)
+	}
+	flushPrefixWriter(stdout)
+	flushPrefixWriter(stderr)
+	return err
+}
+
+// prefixWriter antepone un prefijo a cada línea escrita
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.mu.Lock()
+		fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf[:i])
+		w.mu.Unlock()
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flushPrefixWriter escribe la última línea pendiente si no terminaba en salto de línea
+func flushPrefixWriter(w io.Writer) {
+	pw, ok := w.(*prefixWriter)
+	if !ok || len(pw.buf) == 0 {
+		return
+	}
+	pw.mu.Lock()
+	fmt.Fprintf(pw.out, "%s%s\n", pw.prefix, pw.buf)
+	pw.mu.Unlock()
+	pw.buf = nil
+}
+
+func init() {
+	logsCmd.Flags().BoolP("follow", "f", false, "Follow the log output")
+	logsCmd.Flags().Int("tail", 0, "Number of lines to show from the end of the logs (0 shows all)")
+	logsCmd.Flags().BoolP("timestamps", "t", false, "Show timestamps")
+	logsCmd.Flags().StringP("container", "c", "", "Only show the logs of this container or compose service")
+	logsCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	RootCmd.AddCommand(logsCmd)
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/registry"
+	"github.com/spf13/cobra"
+)
+
+// imageUpdate es el resultado de comparar una imagen local con el registro
+type imageUpdate struct {
+	Local  string
+	Remote string
+	Status string
+}
+
+var pullCmd = &cobra.Command{
+	Use:   "pull [service1] [service2] ... or 'all'",
+	Short: "Pull the images of infrastructure services",
+	Long: `Pull the images used by one or more infrastructure services, several at a
+time, so that starting them later does not have to wait for downloads.
+
+With --check-updates nothing is downloaded: the digest of each local image is
+compared with the one its tag points to in the registry, to show which services
+have newer images available. Use --registry to query a mirror or a local
+registry instead of each image's own registry.
+
+Examples:
+  infracli pull all
+  infracli pull mongo neo4j --parallel 2
+  infracli pull all --check-updates
+  infracli pull redis --check-updates --registry http://localhost:5000`,
+	ValidArgsFunction: completeServices(0, true),
+	Run: func(cmd *cobra.Command, args []string) {
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		checkUpdates, _ := cmd.Flags().GetBool("check-updates")
+		mirror, _ := cmd.Flags().GetString("registry")
+
+		if len(args) == 0 {
+			logger.Errorf("Error: You must specify at least one service or 'all'")
+			cmd.Help()
+			return
+		}
+		if parallel < 1 {
+			parallel = 1
+		}
+
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		images := collectServiceImages(basePath, selectServices(args, availableServices))
+		if len(images) == 0 {
+			return
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+
+		// Se descargan las imágenes fijadas en el lockfile, que son las que usará run
+		if !checkUpdates {
+			images = lockedImages(basePath, images)
+		}
+
+		// Varios servicios pueden usar la misma imagen; se procesa una sola vez
+		var unique []string
+		seen := make(map[string]bool)
+		for _, image := range images {
+			if !seen[image.Image] {
+				seen[image.Image] = true
+				unique = append(unique, image.Image)
+			}
+		}
+
+		ctx := cmd.Context()
+		if checkUpdates {
+			updates := checkImageUpdates(ctx, client, registry.NewClient(mirror), unique, parallel)
+			printUpdatesTable(images, updates)
+			return
+		}
+
+		failed := pullImages(ctx, client, unique, parallel)
+		if failed > 0 {
+			logger.Errorf("%d of %d images could not be pulled", failed, len(unique))
+			return
+		}
+		logger.Infof("Pulled %d images", len(unique))
+	},
+}
+
+// forEachImage ejecuta fn para cada imagen con como mucho parallel a la vez
+func forEachImage(images []string, parallel int, fn func(image string)) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallel)
+	for _, image := range images {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(image string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			fn(image)
+		}(image)
+	}
+	wg.Wait()
+}
+
+// pullImages descarga las imágenes en paralelo y devuelve cuántas fallaron
+func pullImages(ctx context.Context, client *engine.Client, images []string, parallel int) int {
+	var mu sync.Mutex
+	failed := 0
+
+	forEachImage(images, parallel, func(image string) {
+		ref, err := registry.ParseReference(image)
+		tag := ref.Tag
+		if ref.Pinned() {
+			tag = ref.Digest
+		}
+		if err == nil {
+			logger.Infof("Pulling %s...", image)
+			err = client.PullImage(ctx, ref.Name(), tag)
+		}
+
+		if err != nil {
+			logger.Errorf("Error pulling %s: %v", image, err)
+			mu.Lock()
+			failed++
+			mu.Unlock()
+			return
+		}
+		logger.Infof("Pulled %s", image)
+	})
+
+	return failed
+}
+
+// checkImageUpdates compara en paralelo el digest de cada imagen local con el
+// de su etiqueta en el registro
+func checkImageUpdates(ctx context.Context, client *engine.Client, reg *registry.Client, images []string, parallel int) map[string]imageUpdate {
+	var mu sync.Mutex
+	updates := make(map[string]imageUpdate)
+
+	forEachImage(images, parallel, func(image string) {
+		update := checkImageUpdate(ctx, client, reg, image)
+		mu.Lock()
+		updates[image] = update
+		mu.Unlock()
+	})
+
+	return updates
+}
+
+func checkImageUpdate(ctx context.Context, client *engine.Client, reg *registry.Client, image string) imageUpdate {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		logger.Warnf("Warning: %v", err)
+		return imageUpdate{Status: "unknown"}
+	}
+
+	var update imageUpdate
+	details, err := client.InspectImage(ctx, image)
+	switch {
+	case engine.IsNotFound(err):
+		// Sin copia local no hay nada que comparar
+		return imageUpdate{Status: "not pulled"}
+	case err != nil:
+		logger.Warnf("Warning: cannot inspect %s: %v", image, err)
+		return imageUpdate{Status: "unknown"}
+	default:
+		update.Local = details.RepoDigest(ref.Name())
+	}
+
+	// Una imagen fijada por digest no cambia aunque cambie la etiqueta
+	if ref.Pinned() {
+		update.Remote = ref.Digest
+		update.Status = "pinned"
+		return update
+	}
+
+	update.Remote, err = reg.Digest(ctx, ref)
+	if err != nil {
+		logger.Warnf("Warning: cannot check %s: %v", image, err)
+		update.Status = "unknown"
+		return update
+	}
+
+	switch {
+	case update.Local == "":
+		// Imágenes construidas localmente no tienen digest de repositorio
+		update.Status = "unknown"
+	case update.Local == update.Remote:
+		update.Status = "up to date"
+	default:
+		update.Status = "update available"
+	}
+	return update
+}
+
+func printUpdatesTable(images []serviceImage, updates map[string]imageUpdate) {
+	outdated := make(map[string]bool)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tCONTAINER\tIMAGE\tLOCAL\tREMOTE\tSTATUS")
+	for _, image := range images {
+		update := updates[image.Image]
+		local, remote := "-", "-"
+		if update.Local != "" {
+			local = shortDigest(update.Local)
+		}
+		if update.Remote != "" {
+			remote = shortDigest(update.Remote)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", image.Service, image.Container, image.Image, local, remote, update.Status)
+
+		if update.Status == "update available" {
+			outdated[image.Service] = true
+		}
+	}
+	w.Flush()
+
+	if len(outdated) > 0 {
+		var services []string
+		for service := range outdated {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		logger.Infof("Newer images are available for: %s", strings.Join(services, ", "))
+		logger.Infof("Run 'infracli recreate <service> --pull' to update them")
+	}
+}
+
+func init() {
+	pullCmd.Flags().Int("parallel", 4, "Number of images to pull at the same time")
+	pullCmd.Flags().Bool("check-updates", false, "Only report which services have newer images in the registry")
+	pullCmd.Flags().String("registry", "", "Registry URL to query instead of each image's registry, e.g. http://localhost:5000")
+	RootCmd.AddCommand(pullCmd)
+}
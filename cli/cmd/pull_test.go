@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/engine/enginetest"
+	"github.com/solrac97gr/infrastructure/infracli/registry"
+)
+
+const (
+	currentDigest = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	newerDigest   = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func TestCheckImageUpdate(t *testing.T) {
+	// Imágenes locales por referencia, como las devuelve /images/{name}/json
+	local := map[string]engine.ImageDetails{
+		"mongo:7.0":                    {RepoDigests: []string{"mongo@" + currentDigest}},
+		"redis:7":                      {RepoDigests: []string{"redis@" + currentDigest}},
+		"bitnami/kafka:3.6":            {RepoDigests: []string{"bitnami/kafka@" + currentDigest}},
+		"custom:dev":                   {},
+		"postgres:16@" + currentDigest: {RepoDigests: []string{"postgres@" + currentDigest}},
+	}
+	docker := newFakeDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"+engine.APIVersion+"/images/"), "/json")
+		details, ok := local[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message":"No such image: `+name+`"}`)
+			return
+		}
+		json.NewEncoder(w).Encode(details)
+	}))
+
+	// Digests de las etiquetas en el registro
+	remote := map[string]string{
+		"/v2/library/mongo/manifests/7.0":    currentDigest,
+		"/v2/library/redis/manifests/7":      newerDigest,
+		"/v2/bitnami/kafka/manifests/3.6":    newerDigest,
+		"/v2/library/custom/manifests/dev":   currentDigest,
+		"/v2/library/postgres/manifests/16":  newerDigest,
+		"/v2/library/mariadb/manifests/11.2": currentDigest,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		digest, ok := remote[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+	}))
+	defer server.Close()
+	reg := registry.NewClient(server.URL)
+
+	tests := []struct {
+		image string
+		want  imageUpdate
+	}{
+		{"mongo:7.0", imageUpdate{Local: currentDigest, Remote: currentDigest, Status: "up to date"}},
+		{"redis:7", imageUpdate{Local: currentDigest, Remote: newerDigest, Status: "update available"}},
+		{"bitnami/kafka:3.6", imageUpdate{Local: currentDigest, Remote: newerDigest, Status: "update available"}},
+		// Construida localmente: sin digest de repositorio no se puede comparar
+		{"custom:dev", imageUpdate{Remote: currentDigest, Status: "unknown"}},
+		// Fijada por digest: no se consulta el registro
+		{"postgres:16@" + currentDigest, imageUpdate{Local: currentDigest, Remote: currentDigest, Status: "pinned"}},
+		{"mariadb:11.2", imageUpdate{Status: "not pulled"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got := checkImageUpdate(context.Background(), docker, reg, tt.image)
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	updates := checkImageUpdates(context.Background(), docker, reg, []string{"mongo:7.0", "redis:7"}, 2)
+	if updates["mongo:7.0"].Status != "up to date" || updates["redis:7"].Status != "update available" {
+		t.Errorf("unexpected updates %+v", updates)
+	}
+}
+
+func TestCheckImageUpdateRegistryError(t *testing.T) {
+	docker := newFakeDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(engine.ImageDetails{RepoDigests: []string{"mongo@" + currentDigest}})
+	}))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	got := checkImageUpdate(context.Background(), docker, registry.NewClient(server.URL), "mongo:7.0")
+	if want := (imageUpdate{Local: currentDigest, Status: "unknown"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// newFakeDocker devuelve un cliente conectado a un demonio falso
+func newFakeDocker(t *testing.T, handler http.Handler) *engine.Client {
+	t.Helper()
+
+	client, err := engine.NewClient(enginetest.NewDaemon(t, handler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
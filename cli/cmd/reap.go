@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// activityFileName guarda los contadores de la última ejecución de reap
+	activityFileName = "activity.json"
+	// reapLogFileName registra los servicios detenidos por reap
+	reapLogFileName = "reap.log"
+)
+
+// containerCounters son los contadores acumulados de un contenedor
+type containerCounters struct {
+	CPUUsage uint64 `json:"cpuUsage"`
+	RxBytes  uint64 `json:"rxBytes"`
+}
+
+// serviceActivity es lo que reap recuerda de un servicio entre ejecuciones
+type serviceActivity struct {
+	LastActive time.Time                    `json:"lastActive"`
+	LastCheck  time.Time                    `json:"lastCheck"`
+	Containers map[string]containerCounters `json:"containers"`
+}
+
+// activityState es el contenido del archivo de actividad
+type activityState struct {
+	Services map[string]*serviceActivity `json:"services"`
+}
+
+var reapCmd = &cobra.Command{
+	Use:   "reap [service1] [service2] ...",
+	Short: "Stop services that have been idle for too long",
+	Long: `Stop services whose containers have had no inbound network traffic and
+no CPU activity for the configured time (autoStop.after).
+
+Activity is measured by comparing the containers' counters with the ones
+recorded on the previous run, so reap is meant to be run periodically, for
+example every 15 minutes from cron or a systemd timer. A service is
+considered active the first time reap sees it.
+
+Stopped services are appended to reap.log in the infracli state directory.
+
+Examples:
+  infracli config set autoStop.after 4h
+  infracli config set autoStop.services neo4j elasticsearch-kibana
+  infracli reap --dry-run
+  */15 * * * * infracli reap --quiet`,
+	ValidArgsFunction: completeServices(0, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		cpuThreshold, _ := cmd.Flags().GetFloat64("cpu-threshold")
+		rxThreshold, _ := cmd.Flags().GetUint64("rx-threshold")
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		afterText := cfg.AutoStop.After
+		if cmd.Flags().Changed("after") {
+			afterText, _ = cmd.Flags().GetString("after")
+		}
+		if afterText == "" {
+			logger.Infof("Auto-stop is disabled; enable it with: infracli config set autoStop.after 4h")
+			return
+		}
+		after, err := time.ParseDuration(afterText)
+		if err != nil || after <= 0 {
+			logger.Errorf("Error: invalid idle time %q", afterText)
+			return
+		}
+
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		// Los argumentos tienen prioridad sobre autoStop.services
+		targets := availableServices
+		if len(args) > 0 {
+			targets = selectServices(args, availableServices)
+		} else if len(cfg.AutoStop.Services) > 0 {
+			targets = selectServices(cfg.AutoStop.Services, availableServices)
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+
+		stateDir, err := config.GetStateDir()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		state, err := loadActivityState(filepath.Join(stateDir, activityFileName))
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		ctx := cmd.Context()
+		now := time.Now()
+		thresholds := activityThresholds{cpuPercent: cpuThreshold, rxBytes: rxThreshold}
+
+		opts := stackOptions()
+		for _, service := range targets {
+			containers, err := client.ServiceContainers(ctx, filepath.Join(basePath, service))
+			if err != nil {
+				logger.Errorf("Error listing containers of %s: %v", service, err)
+				continue
+			}
+
+			running := runningContainers(containers)
+			if len(running) == 0 {
+				delete(state.Services, service)
+				continue
+			}
+
+			activity := state.Services[service]
+			if activity == nil {
+				activity = &serviceActivity{LastActive: now}
+				state.Services[service] = activity
+			}
+
+			active := observeActivity(ctx, client, activity, running, now, thresholds)
+			if active {
+				activity.LastActive = now
+			}
+
+			idle := now.Sub(activity.LastActive)
+			logger.Debugf("%s: idle for %s (active this run: %t)", service, idle.Round(time.Second), active)
+
+			if idle < after {
+				continue
+			}
+
+			if dryRun {
+				logger.Infof("Would stop %s (idle for %s)", service, idle.Round(time.Second))
+				continue
+			}
+
+			logger.Infof("%s has been idle for %s", service, idle.Round(time.Second))
+			if err := stopService(service, basePath, false, opts); err != nil {
+				continue
+			}
+			delete(state.Services, service)
+
+			entry := fmt.Sprintf("%s stopped %s (idle for %s)\n", now.Format(time.RFC3339), service, idle.Round(time.Second))
+			if err := appendReapLog(filepath.Join(stateDir, reapLogFileName), entry); err != nil {
+				logger.Warnf("Warning: could not write reap log: %v", err)
+			}
+		}
+
+		if err := saveActivityState(filepath.Join(stateDir, activityFileName), state); err != nil {
+			logger.Errorf("Error saving activity state: %v", err)
+		}
+	},
+}
+
+// activityThresholds definen a partir de qué consumo un contenedor se considera activo
+type activityThresholds struct {
+	// cpuPercent es el uso medio de CPU, en porcentaje de un núcleo, desde la última ejecución
+	cpuPercent float64
+	// rxBytes son los bytes recibidos desde la última ejecución
+	rxBytes uint64
+}
+
+// observeActivity compara los contadores actuales de los contenedores con los
+// de la ejecución anterior, los actualiza e indica si hubo actividad
+func observeActivity(ctx context.Context, client *engine.Client, activity *serviceActivity, running []engine.Container, now time.Time, thresholds activityThresholds) bool {
+	elapsed := now.Sub(activity.LastCheck)
+	previous := activity.Containers
+	current := make(map[string]containerCounters)
+	active := false
+
+	for _, container := range running {
+		stats, err := client.ContainerStats(ctx, container.ID)
+		if err != nil {
+			logger.Debugf("Error getting stats of %s: %v", container.Name(), err)
+			// Sin datos no se puede afirmar que esté inactivo
+			active = true
+			continue
+		}
+
+		rx, _ := stats.NetworkIO()
+		counters := containerCounters{CPUUsage: stats.CPUStats.CPUUsage.TotalUsage, RxBytes: rx}
+		current[container.ID] = counters
+
+		// Un contenedor nuevo o reiniciado cuenta como actividad
+		prev, ok := previous[container.ID]
+		if !ok || counters.CPUUsage < prev.CPUUsage || counters.RxBytes < prev.RxBytes || elapsed <= 0 {
+			active = true
+			continue
+		}
+
+		cpuPercent := float64(counters.CPUUsage-prev.CPUUsage) / float64(elapsed.Nanoseconds()) * 100
+		rxDelta := counters.RxBytes - prev.RxBytes
+		logger.Debugf("%s: %.2f%% CPU, %d bytes received since last check", container.Name(), cpuPercent, rxDelta)
+
+		if cpuPercent >= thresholds.cpuPercent || rxDelta >= thresholds.rxBytes {
+			active = true
+		}
+	}
+
+	activity.Containers = current
+	activity.LastCheck = now
+	return active
+}
+
+func runningContainers(containers []engine.Container) []engine.Container {
+	var running []engine.Container
+	for _, container := range containers {
+		if container.State == "running" {
+			running = append(running, container)
+		}
+	}
+	return running
+}
+
+func loadActivityState(path string) (*activityState, error) {
+	state := &activityState{Services: make(map[string]*serviceActivity)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading activity state: %v", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing activity state %s: %v", path, err)
+	}
+	if state.Services == nil {
+		state.Services = make(map[string]*serviceActivity)
+	}
+	return state, nil
+}
+
+func saveActivityState(path string, state *activityState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func appendReapLog(path, entry string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(entry)
+	return err
+}
+
+func init() {
+	reapCmd.Flags().Bool("dry-run", false, "Only report which services would be stopped")
+	reapCmd.Flags().String("after", "", "Idle time before stopping a service (overrides autoStop.after)")
+	reapCmd.Flags().Float64("cpu-threshold", 5, "Average CPU usage, in percent of one core, that counts as activity")
+	reapCmd.Flags().Uint64("rx-threshold", 64*1024, "Bytes received since the previous run that count as activity")
+	RootCmd.AddCommand(reapCmd)
+}
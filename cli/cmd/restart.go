@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+	"github.com/spf13/cobra"
+)
+
+var restartCmd = &cobra.Command{
+	Use:   "restart [service1] [service2] ... or 'all'",
+	Short: "Restart one or more infrastructure services",
+	Long: `Restart the containers of one or more infrastructure services without
+recreating them. Use --container to restart only one container of a service,
+given by its compose service name or its container name.
+
+Examples:
+  infracli restart mysql
+  infracli restart elasticsearch-kibana --container kibana
+  infracli restart all`,
+	ValidArgsFunction: completeServices(0, true),
+	Run: func(cmd *cobra.Command, args []string) {
+		container, _ := cmd.Flags().GetString("container")
+		forEachSelectedService(cmd, args, "Restarting", func(service, basePath string) {
+			restartService(service, basePath, container)
+		})
+	},
+}
+
+var recreateCmd = &cobra.Command{
+	Use:   "recreate [service1] [service2] ... or 'all'",
+	Short: "Recreate the containers of one or more infrastructure services",
+	Long: `Recreate the containers of one or more infrastructure services, even if
+their configuration has not changed. Volumes are kept, so data survives.
+Use --pull to fetch fresh images before recreating the containers.
+
+Examples:
+  infracli recreate redis
+  infracli recreate mongo neo4j --pull
+  infracli recreate elasticsearch-kibana --container kibana
+  infracli recreate all --pull`,
+	ValidArgsFunction: completeServices(0, true),
+	Run: func(cmd *cobra.Command, args []string) {
+		container, _ := cmd.Flags().GetString("container")
+		pull, _ := cmd.Flags().GetBool("pull")
+		opts := stackOptions()
+		forEachSelectedService(cmd, args, "Recreating", func(service, basePath string) {
+			recreateService(service, basePath, container, pull, opts)
+		})
+	},
+}
+
+// forEachSelectedService resuelve los servicios de los argumentos con la misma
+// semántica que run ("all" o una lista de servicios) y aplica action a cada uno
+func forEachSelectedService(cmd *cobra.Command, args []string, verb string, action func(service, basePath string)) {
+	if len(args) == 0 {
+		logger.Errorf("Error: You must specify at least one service or 'all'")
+		cmd.Help()
+		return
+	}
+
+	// Obtener servicios disponibles
+	availableServices, err := config.GetAvailableServices()
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return
+	}
+
+	// Obtener la ruta de servicios configurada, ya expandida
+	basePath, err := config.GetServicesPath()
+	if err != nil {
+		logger.Errorf("Error loading configuration: %v", err)
+		return
+	}
+
+	logger.Debugf("Services path: %s", basePath)
+	logger.Debugf("Available services: %s", strings.Join(availableServices, ", "))
+
+	if len(args) == 1 && args[0] == "all" {
+		logger.Infof("%s all available services...", verb)
+	}
+
+	for _, service := range selectServices(args, availableServices) {
+		action(service, basePath)
+	}
+}
+
+// restartService reinicia los contenedores de un servicio, o solo el indicado
+func restartService(service, basePath, container string) error {
+	servicePath := filepath.Join(basePath, service)
+
+	args := []string{"restart"}
+	target, err := composeTarget(servicePath, container)
+	if err != nil {
+		logger.Warnf("Warning: %s: %v", service, err)
+		return err
+	}
+	if target != "" {
+		args = append(args, target)
+		logger.Infof("Restarting %s (%s)...", service, target)
+	} else {
+		logger.Infof("Restarting %s...", service)
+	}
+
+	if err := composeCommand(service, servicePath, args...); err != nil {
+		logComposeError("restarting", service, err)
+		reportContainerProblems(service, servicePath)
+		return err
+	}
+
+	logger.Infof("%s restarted successfully", service)
+	reportContainerProblems(service, servicePath)
+	return nil
+}
+
+// recreateService vuelve a crear los contenedores de un servicio conservando sus volúmenes
+func recreateService(service, basePath, container string, pull bool, opts stack.Options) error {
+	servicePath := filepath.Join(basePath, service)
+
+	target, err := composeTarget(servicePath, container)
+	if err != nil {
+		logger.Warnf("Warning: %s: %v", service, err)
+		return err
+	}
+
+	composeArgs, cleanup, err := stack.ComposeArgs(service, basePath, opts)
+	if err != nil {
+		logger.Errorf("Error recreating %s: %v", service, err)
+		return err
+	}
+	defer cleanup()
+
+	if pull {
+		logger.Infof("Pulling images for %s...", service)
+		pullArgs := append(append([]string{}, composeArgs...), "pull")
+		if target != "" {
+			pullArgs = append(pullArgs, target)
+		}
+		if err := composeCommand(service, servicePath, pullArgs...); err != nil {
+			logComposeError("pulling images for", service, err)
+			return err
+		}
+	}
+
+	args := append(append([]string{}, composeArgs...), "up", "-d", "--force-recreate")
+	if target != "" {
+		// --no-deps evita recrear también los servicios de los que depende
+		args = append(args, "--no-deps", target)
+		logger.Infof("Recreating %s (%s)...", service, target)
+	} else {
+		logger.Infof("Recreating %s...", service)
+	}
+
+	if err := composeCommand(service, servicePath, args...); err != nil {
+		logComposeError("recreating", service, err)
+		reportContainerProblems(service, servicePath)
+		return err
+	}
+
+	logger.Infof("%s recreated successfully", service)
+	reportContainerProblems(service, servicePath)
+	return nil
+}
+
+// composeTarget convierte el nombre de un contenedor en el nombre del servicio
+// de compose que lo define. Acepta tanto el nombre del servicio de compose
+// como su container_name; si container está vacío devuelve "".
+func composeTarget(servicePath, container string) (string, error) {
+	if container == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(servicePath, "docker-compose.yml"))
+	if err != nil {
+		return "", fmt.Errorf("error reading docker-compose.yml: %v", err)
+	}
+	content := string(data)
+
+	composeServices := compose.Images(content)
+	if _, ok := composeServices[container]; ok {
+		return container, nil
+	}
+
+	for composeService, containerName := range compose.ContainerNames(content) {
+		if containerName == container {
+			return composeService, nil
+		}
+	}
+
+	return "", fmt.Errorf("container '%s' not found", container)
+}
+
+func init() {
+	restartCmd.Flags().StringP("container", "c", "", "Only restart this container (compose service or container name)")
+	restartCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	recreateCmd.Flags().StringP("container", "c", "", "Only recreate this container (compose service or container name)")
+	recreateCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	recreateCmd.Flags().Bool("pull", false, "Pull fresh images before recreating the containers")
+	RootCmd.AddCommand(restartCmd)
+	RootCmd.AddCommand(recreateCmd)
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
 	"github.com/spf13/cobra"
 )
 
@@ -14,17 +17,95 @@ var RootCmd = &cobra.Command{
 infrastructure services defined in Docker Compose files.
 
 It allows running and stopping multiple services at once from a centralized CLI.
-The tool automatically detects available services based on the directory structure.`,
+The tool automatically detects available services based on the directory structure.
+
+Configuration values are resolved with the following precedence:
+  flag > environment variable > project file (.infracli.json) > user file > defaults`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogger(cmd); err != nil {
+			return err
+		}
+		applyConfigOverrides(cmd)
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		// El banner solo se muestra al usar el comando sin argumentos en una terminal
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		if !quiet && isTerminal(os.Stdout) {
+			printBanner()
+		}
+
 		// Si no se proporciona ningún subcomando, mostrar la ayuda
 		cmd.Help()
 	},
 }
 
 func init() {
-	// Aquí se pueden agregar flags globales si se necesitan
-	RootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose output")
+	// Flags globales de logging; los logs siempre se escriben en stderr
+	RootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose output (same as --log-level debug)")
+	RootCmd.PersistentFlags().String("log-level", "info", "Minimum log level: debug, info, warn or error")
+	RootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only log errors")
+	RootCmd.PersistentFlags().String("log-format", "text", "Log format: text or json")
+	RootCmd.PersistentFlags().String("config", "", fmt.Sprintf("Path to an alternate configuration file (env: %s)", config.ConfigFileEnv))
+
+	// Un flag global por cada clave de configuración, por ejemplo --services-path.
+	// Las listas se indican repitiendo el flag, sin separar por comas, porque
+	// valores como los hooks o las plantillas pueden contenerlas.
+	for _, field := range config.Fields() {
+		usage := field.Description
+		if !field.UserOnly {
+			usage = fmt.Sprintf("%s (env: %s)", field.Description, field.EnvVar())
+		}
+		if field.Kind == config.StringListField {
+			RootCmd.PersistentFlags().StringArray(field.FlagName(), nil, usage)
+		} else {
+			RootCmd.PersistentFlags().String(field.FlagName(), "", usage)
+		}
+	}
+
+}
+
+// applyConfigOverrides pasa al paquete config los flags globales que se hayan indicado
+func applyConfigOverrides(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	opts := config.Options{Flags: make(map[string][]string)}
+
+	opts.ConfigFile, _ = flags.GetString("config")
+
+	for _, field := range config.Fields() {
+		if !flags.Changed(field.FlagName()) {
+			continue
+		}
+		if field.Kind == config.StringListField {
+			values, _ := flags.GetStringArray(field.FlagName())
+			opts.Flags[field.Key] = values
+		} else {
+			value, _ := flags.GetString(field.FlagName())
+			opts.Flags[field.Key] = []string{value}
+		}
+	}
+
+	config.SetOptions(opts)
+}
+
+// setupLogger configura el logger global a partir de los flags
+func setupLogger(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	opts := logger.Options{}
+
+	opts.Level, _ = flags.GetString("log-level")
+	opts.Quiet, _ = flags.GetBool("quiet")
+	opts.Format, _ = flags.GetString("log-format")
+
+	if verbose, _ := flags.GetBool("verbose"); verbose && !flags.Changed("log-level") {
+		opts.Level = "debug"
+	}
+
+	return logger.Setup(opts)
+}
 
+// printBanner muestra el logo de InfraCLI
+func printBanner() {
 	fmt.Println(`
 ██╗███╗   ██╗███████╗██████╗  █████╗  ██████╗██╗     ██╗
 ██║████╗  ██║██╔════╝██╔══██╗██╔══██╗██╔════╝██║     ██║
@@ -3,11 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/probe"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
 	"github.com/spf13/cobra"
 )
 
@@ -20,105 +22,187 @@ If 'all' is specified, it starts all available services.
 Examples:
   infracli run mysql
   infracli run mongo elasticsearch-kibana
-  infracli run all`,
+  infracli run all
+  infracli run postgres --instance ci
+  infracli run mysql --ephemeral
+  infracli run postgres redis --ready
+
+--ready blocks until the services accept clients, checked as 'infracli check'
+does, and exits with a non-zero status if they are not ready in time.`,
+	ValidArgsFunction: completeServices(0, true),
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
-			fmt.Println("Error: You must specify at least one service or 'all'")
+			logger.Errorf("Error: You must specify at least one service or 'all'")
 			cmd.Help()
 			return
 		}
 
-		verbose, _ := cmd.Flags().GetBool("verbose")
-
 		// Obtener servicios disponibles
 		availableServices, err := config.GetAvailableServices()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			logger.Errorf("Error: %v", err)
 			return
 		}
 
-		// Obtener la configuración
-		cfg, err := config.LoadConfig()
+		// Obtener la ruta de servicios configurada, ya expandida
+		basePath, err := config.GetServicesPath()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+			logger.Errorf("Error loading configuration: %v", err)
 			return
 		}
 
-		// Usar directamente la ruta de servicios configurada
-		basePath := cfg.ServicesPath
-
-		// Expandir la ruta si contiene ~/
-		if len(basePath) >= 2 && basePath[:2] == "~/" {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error getting home directory: %v\n", err)
-				return
+		logger.Debugf("Services path: %s", basePath)
+		logger.Debugf("Available services: %s", strings.Join(availableServices, ", "))
+
+		var started []string
+		opts := stackOptions()
+
+		// Con --instance o --ephemeral se inicia una copia aislada de cada servicio
+		instanceName, _ := cmd.Flags().GetString("instance")
+		ephemeral, _ := cmd.Flags().GetBool("ephemeral")
+		if instanceName != "" || ephemeral {
+			instanceName, started = runInstances(args, availableServices, basePath, instanceName, ephemeral, opts)
+		} else if len(args) == 1 && args[0] == "all" {
+			// Comprobar si queremos iniciar todos los servicios
+			logger.Infof("Starting all available services...")
+			started = runAllServices(availableServices, basePath, opts)
+		} else {
+			// Iniciar los servicios especificados
+			for _, service := range selectServices(args, availableServices) {
+				if runService(service, basePath, opts) == nil {
+					started = append(started, service)
+				}
 			}
-			basePath = filepath.Join(homeDir, basePath[2:])
 		}
 
-		if verbose {
-			fmt.Printf("Services path: %s\n", basePath)
-			fmt.Printf("Available services: %s\n", strings.Join(availableServices, ", "))
+		// Los hooks onHealthy se ejecutan cuando el servicio acepta clientes;
+		// las instancias no ejecutan hooks
+		var healthy []string
+		if instanceName == "" {
+			for _, service := range started {
+				if stack.HasHooks(service, basePath, stack.HookOnHealthy) {
+					healthy = append(healthy, service)
+				}
+			}
 		}
 
-		// Comprobar si queremos iniciar todos los servicios
-		if len(args) == 1 && args[0] == "all" {
-			fmt.Println("Starting all available services...")
-			runAllServices(availableServices, basePath, verbose)
-			return
+		// Con --ready se espera a todos los servicios y si no solo a los que
+		// tienen hooks onHealthy
+		ready, _ := cmd.Flags().GetBool("ready")
+		waitFor := healthy
+		if ready {
+			waitFor = started
 		}
-
-		// Iniciar los servicios especificados
-		for _, service := range args {
-			serviceFound := false
-			for _, availService := range availableServices {
-				if service == availService {
-					serviceFound = true
-					break
+		if len(waitFor) > 0 {
+			timeout, _ := cmd.Flags().GetDuration("ready-timeout")
+			var targets []probe.Target
+			for _, service := range waitFor {
+				found, err := serviceProbeTargets(service, basePath, instanceName, opts)
+				if err != nil {
+					logger.Errorf("Error: %v", err)
+					os.Exit(1)
 				}
+				targets = append(targets, found...)
 			}
-
-			if !serviceFound {
-				fmt.Printf("Warning: Service '%s' not found in available services\n", service)
-				fmt.Printf("Available services: %s\n", strings.Join(availableServices, ", "))
-				continue
+			if err := waitReady(targets, timeout); err != nil {
+				if len(healthy) > 0 {
+					logger.Errorf("Error: onHealthy hooks of %s were not run", strings.Join(healthy, ", "))
+				}
+				os.Exit(1)
 			}
+		}
 
-			runService(service, basePath, verbose)
+		failed := false
+		for _, service := range healthy {
+			if err := stack.RunHooks(service, basePath, stack.HookOnHealthy, opts); err != nil {
+				logger.Errorf("Error: %v", err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
 		}
 	},
 }
 
-func runService(service, basePath string, verbose bool) {
+// runService inicia un servicio con docker-compose up. Los errores se
+// registran aquí y además se devuelven para que el llamador pueda reaccionar.
+func runService(service, basePath string, opts stack.Options) error {
 	servicePath := filepath.Join(basePath, service)
-	fmt.Printf("Starting %s...\n", service)
-
-	cmd := exec.Command("docker-compose", "up", "-d")
-	cmd.Dir = servicePath
+	logger.Infof("Starting %s...", service)
+
+	// La salida de docker-compose se registra aquí; el resto de errores, al final
+	var composeErr error
+	err := stack.Start(service, basePath, opts, func(args ...string) error {
+		if composeErr = composeCommand(service, servicePath, args...); composeErr != nil {
+			logComposeError("starting", service, composeErr)
+			reportContainerProblems(service, servicePath)
+			return composeErr
+		}
+		logger.Infof("%s started successfully", service)
+		reportContainerProblems(service, servicePath)
+		return nil
+	})
+	if err != nil && err != composeErr {
+		logger.Errorf("Error: %v", err)
+	}
+	return err
+}
 
-	if verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		output, err := cmd.CombinedOutput()
+// runInstances inicia la instancia indicada de cada servicio seleccionado.
+// Con ephemeral y sin nombre se genera uno, que se imprime en la salida
+// estándar para poder detenerla después desde un script. Devuelve el nombre
+// de la instancia y los servicios que se iniciaron.
+func runInstances(args, availableServices []string, basePath, name string, ephemeral bool, opts stack.Options) (string, []string) {
+	if name == "" {
+		generated, err := newEphemeralName()
 		if err != nil {
-			fmt.Printf("Error starting %s: %v\n", service, err)
-			fmt.Println(string(output))
-			return
+			logger.Errorf("Error: %v", err)
+			return "", nil
 		}
+		name = generated
+	}
+	if err := validateInstanceName(name); err != nil {
+		logger.Errorf("Error: %v", err)
+		return "", nil
+	}
+
+	services := availableServices
+	if len(args) != 1 || args[0] != "all" {
+		services = selectServices(args, availableServices)
 	}
 
-	fmt.Printf("%s started successfully\n", service)
+	var started []string
+	for _, service := range services {
+		if runInstance(service, basePath, name, ephemeral, opts) == nil {
+			started = append(started, service)
+		}
+	}
+
+	if len(started) > 0 && ephemeral {
+		fmt.Println(name)
+		logger.Infof("Remove it with: infracli down %s --instance %s", strings.Join(args, " "), name)
+	}
+	return name, started
 }
 
-func runAllServices(services []string, basePath string, verbose bool) {
+// runAllServices inicia todos los servicios y devuelve los que se iniciaron
+func runAllServices(services []string, basePath string, opts stack.Options) []string {
+	var started []string
 	for _, service := range services {
-		runService(service, basePath, verbose)
+		if runService(service, basePath, opts) == nil {
+			started = append(started, service)
+		}
 	}
-	fmt.Println("All services have been started")
+	logger.Infof("All services have been started")
+	return started
 }
 
 func init() {
+	runCmd.Flags().String("instance", "", "Start an isolated instance with its own project, container names, ports and volumes")
+	runCmd.RegisterFlagCompletionFunc("instance", completeInstances)
+	runCmd.Flags().Bool("ephemeral", false, "Start an isolated instance with a generated name, meant to be removed with 'down --instance'")
+	runCmd.Flags().Bool("ready", false, "Wait until the services accept clients, checked by speaking their protocol")
+	runCmd.Flags().Duration("ready-timeout", defaultReadyTimeout, "Maximum time to wait with --ready")
 	RootCmd.AddCommand(runCmd)
 }
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/secrets"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage the encrypted passwords of services",
+	Long: `Store passwords and other sensitive values of services encrypted, instead of
+in plain text in their docker-compose.yml.
+
+Each secret is an environment variable of a service. When the service is started
+with 'infracli run', the variable is set in every container whose compose file
+declares it, overriding the value written there.
+
+The encryption key is kept in the OS keyring (macOS Keychain or the Secret Service
+through secret-tool). Without a keyring, or when ` + stack.PassphraseEnv + ` is set,
+it is derived from a passphrase.
+
+Examples:
+  infracli secret set mysql MYSQL_ROOT_PASSWORD
+  infracli secret get mysql MYSQL_ROOT_PASSWORD
+  infracli secret list
+  infracli secret rm mysql MYSQL_ROOT_PASSWORD`,
+}
+
+var secretSetCmd = &cobra.Command{
+	Use:   "set [service] [name] [value]",
+	Short: "Store a secret of a service",
+	Long: `Store a secret of a service. Without a value it is read from the terminal
+without echoing it, or from standard input when it is not a terminal.
+
+Examples:
+  infracli secret set postgres POSTGRES_PASSWORD
+  echo -n "s3cret" | infracli secret set postgres POSTGRES_PASSWORD`,
+	Args:              cobra.RangeArgs(2, 3),
+	ValidArgsFunction: completeServices(1, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		service, err := resolveServiceName(args[0])
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		name := args[1]
+		if err := secrets.ValidateName(name); err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		var value string
+		if len(args) == 3 {
+			value = args[2]
+		} else {
+			read, err := readSecretValue(fmt.Sprintf("Value for %s of %s: ", name, service))
+			if err != nil {
+				logger.Errorf("Error: %v", err)
+				return
+			}
+			value = read
+		}
+
+		store, path, err := stack.LoadSecrets()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		if store == nil {
+			if store, err = stack.CreateSecrets(stackOptions()); err != nil {
+				logger.Errorf("Error: %v", err)
+				return
+			}
+		} else if err := store.Unlock(secretsPassphrase); err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		if err := store.Set(service, name, value); err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		if err := store.Save(path); err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		logger.Infof("Secret %s of %s saved; it will be used the next time %s starts", name, service, service)
+	},
+}
+
+var secretGetCmd = &cobra.Command{
+	Use:               "get [service] [name]",
+	Short:             "Print the value of a secret",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeServices(1, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, _, err := stack.LoadSecrets()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		service, err := secretServiceName(store, args[0])
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		name := args[1]
+		if store == nil || !store.Has(service, name) {
+			logger.Errorf("Error: secret %s of %s not found", name, service)
+			return
+		}
+		if err := store.Unlock(secretsPassphrase); err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		value, err := store.Get(service, name)
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		fmt.Println(value)
+	},
+}
+
+var secretListCmd = &cobra.Command{
+	Use:               "list [service]",
+	Short:             "List the names of the stored secrets",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeServices(1, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, _, err := stack.LoadSecrets()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		var services []string
+		if store != nil {
+			services = store.Services()
+			if len(args) == 1 {
+				service, err := secretServiceName(store, args[0])
+				if err != nil {
+					logger.Errorf("Error: %v", err)
+					return
+				}
+				services = nil
+				if len(store.Names(service)) > 0 {
+					services = []string{service}
+				}
+			}
+		}
+		if len(services) == 0 {
+			logger.Infof("No secrets stored; add one with: infracli secret set <service> <name>")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tNAME")
+		for _, service := range services {
+			for _, name := range store.Names(service) {
+				fmt.Fprintf(w, "%s\t%s\n", service, name)
+			}
+		}
+		w.Flush()
+	},
+}
+
+var secretRmCmd = &cobra.Command{
+	Use:   "rm [service] [name]",
+	Short: "Remove a secret",
+	Long: `Remove a secret. The service goes back to the value in its docker-compose.yml
+the next time it starts.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeServices(1, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, path, err := stack.LoadSecrets()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		service, err := secretServiceName(store, args[0])
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		name := args[1]
+		if store == nil || !store.Remove(service, name) {
+			logger.Errorf("Error: secret %s of %s not found", name, service)
+			return
+		}
+		if err := store.Save(path); err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		logger.Infof("Secret %s of %s removed", name, service)
+	},
+}
+
+// secretServiceName devuelve el servicio de un nombre o alias. Los secretos de
+// un servicio que ya no existe se siguen pudiendo consultar y borrar por su nombre.
+func secretServiceName(store *secrets.Store, name string) (string, error) {
+	if store != nil && len(store.Names(name)) > 0 {
+		return name, nil
+	}
+	return resolveServiceName(name)
+}
+
+// newSecretsPassphrase pide dos veces en la terminal la frase de paso con la
+// que se crea el almacén
+func newSecretsPassphrase() (string, error) {
+	passphrase, err := promptPassword("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirmation, err := promptPassword("Repeat the passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirmation {
+		return "", fmt.Errorf("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// secretsPassphrase obtiene la frase de paso del entorno o de la terminal
+func secretsPassphrase() (string, error) {
+	if passphrase, ok := os.LookupEnv(stack.PassphraseEnv); ok {
+		return passphrase, nil
+	}
+	return promptPassword("Secrets passphrase: ")
+}
+
+// promptPassword pide un valor en la terminal sin mostrarlo
+func promptPassword(prompt string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("cannot ask for the passphrase without a terminal; set %s", stack.PassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	value, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading from the terminal: %v", err)
+	}
+	return string(value), nil
+}
+
+// readSecretValue lee un secreto de la terminal o, si no es una terminal,
+// de la entrada estándar sin el salto de línea final
+func readSecretValue(prompt string) (string, error) {
+	if isTerminal(os.Stdin) {
+		return promptPassword(prompt)
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("error reading standard input: %v", err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+}
+
+func init() {
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretGetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretRmCmd)
+	RootCmd.AddCommand(secretCmd)
+}
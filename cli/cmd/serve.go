@@ -0,0 +1,542 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/infracli"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// serveSocketName es el socket por defecto de 'infracli serve' en el directorio de estado
+	serveSocketName = "serve.sock"
+	// serveTokenName guarda el token generado para los listeners TCP
+	serveTokenName = "serve.token"
+	// serveTokenEnv permite fijar el token sin pasarlo por la línea de comandos
+	serveTokenEnv = "INFRACLI_SERVE_TOKEN"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local HTTP/JSON API to control the services",
+	Long: `Serve a local HTTP/JSON API that lets other tools, such as IDE plugins or a
+developer portal, list, start and stop services, read their status and
+connection information and stream their logs. The API is described by the
+OpenAPI document served at /openapi.json.
+
+By default the API listens on a unix socket in the infracli state directory,
+only accessible to the current user. TCP listeners require a bearer token,
+taken from --token, $INFRACLI_SERVE_TOKEN or generated and saved to
+serve.token in the state directory.
+
+The API never asks for the secrets passphrase in the terminal. With a
+passphrase-protected secrets store, set $INFRACLI_SECRETS_PASSPHRASE before
+starting it; otherwise run, down and info answer 503.
+
+Examples:
+  infracli serve
+  infracli serve --listen unix:///tmp/infracli.sock
+  infracli serve --listen 127.0.0.1:7070
+  curl -H "Authorization: Bearer $(cat ~/.local/state/infracli/serve.token)" http://127.0.0.1:7070/v1/services`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, _ := cmd.Flags().GetString("listen")
+		token, _ := cmd.Flags().GetString("token")
+
+		stateDir, err := config.GetStateDir()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		if listen == "" {
+			listen = "unix://" + filepath.Join(stateDir, serveSocketName)
+		}
+
+		listener, network, err := serveListen(listen)
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+		defer listener.Close()
+
+		if network == "tcp" {
+			if token == "" {
+				token = os.Getenv(serveTokenEnv)
+			}
+			if token == "" {
+				tokenFile := filepath.Join(stateDir, serveTokenName)
+				if token, err = loadOrCreateToken(tokenFile); err != nil {
+					logger.Errorf("Error: %v", err)
+					return
+				}
+				logger.Infof("Token for TCP clients saved in %s", tokenFile)
+			}
+		} else {
+			// El socket ya está protegido por sus permisos
+			token = ""
+		}
+
+		server := &http.Server{
+			Handler:           newAPIServer(token).routes(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(shutdownCtx)
+		}()
+
+		logger.Infof("Serving the infracli API on %s", listen)
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("Error serving the API: %v", err)
+		}
+	},
+}
+
+// serveListen abre el listener indicado: unix:///ruta, tcp://host:puerto o host:puerto
+func serveListen(address string) (net.Listener, string, error) {
+	if path, ok := strings.CutPrefix(address, "unix://"); ok {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, "", fmt.Errorf("error creating socket directory: %v", err)
+		}
+		// Un socket que quedó de una ejecución anterior impide escuchar
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, "", fmt.Errorf("error listening on %s: %v", address, err)
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			listener.Close()
+			return nil, "", fmt.Errorf("error setting socket permissions: %v", err)
+		}
+		return listener, "unix", nil
+	}
+
+	listener, err := net.Listen("tcp", strings.TrimPrefix(address, "tcp://"))
+	if err != nil {
+		return nil, "", fmt.Errorf("error listening on %s: %v", address, err)
+	}
+	return listener, "tcp", nil
+}
+
+// loadOrCreateToken lee el token guardado o genera uno nuevo
+func loadOrCreateToken(path string) (string, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("error generating token: %v", err)
+	}
+	token := hex.EncodeToString(secret)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("error creating state directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("error saving token: %v", err)
+	}
+	return token, nil
+}
+
+// apiServer atiende la API HTTP usando las mismas funciones que los comandos
+type apiServer struct {
+	token string
+
+	// locks evita que dos peticiones ejecuten docker-compose sobre el mismo servicio a la vez
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// apiError es el cuerpo de las respuestas de error
+type apiError struct {
+	Error  string `json:"error"`
+	Output string `json:"output,omitempty"`
+}
+
+// serviceInfo es la respuesta de /v1/services/{service}/info
+type serviceInfo struct {
+	Service string `json:"service"`
+	Text    string `json:"text"`
+}
+
+// actionResult es la respuesta de run y down
+type actionResult struct {
+	Service string `json:"service"`
+	Action  string `json:"action"`
+}
+
+// logLine es un evento del flujo de logs
+type logLine struct {
+	Container string `json:"container"`
+	Stream    string `json:"stream"`
+	Line      string `json:"line"`
+}
+
+func newAPIServer(token string) *apiServer {
+	return &apiServer{token: token, locks: make(map[string]*sync.Mutex)}
+}
+
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/v1/services", s.handleServices)
+	mux.HandleFunc("/v1/services/", s.handleService)
+	mux.HandleFunc("/v1/status", s.handleStatus)
+	return s.authenticate(mux)
+}
+
+// authenticate exige el token como "Authorization: Bearer <token>" o, para
+// clientes como EventSource que no pueden enviar cabeceras, como ?access_token=
+func (s *apiServer) authenticate(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("access_token")
+		if header, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = header
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="infracli"`)
+			writeJSON(w, http.StatusUnauthorized, apiError{Error: "missing or invalid token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *apiServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// GET /v1/services
+func (s *apiServer) handleServices(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	services, err := config.GetAvailableServices()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, services)
+}
+
+// GET /v1/status
+func (s *apiServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	services, err := config.GetAvailableServices()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+	s.writeStatus(w, r, services)
+}
+
+// /v1/services/{service}[/status|/info|/run|/down|/logs]
+func (s *apiServer) handleService(w http.ResponseWriter, r *http.Request) {
+	service, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/services/"), "/")
+
+	services, err := config.GetAvailableServices()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+	if !containsString(services, service) {
+		writeJSON(w, http.StatusNotFound, apiError{Error: fmt.Sprintf("service '%s' not found", service)})
+		return
+	}
+
+	switch action {
+	case "", "status":
+		if allowMethod(w, r, http.MethodGet) {
+			s.writeStatus(w, r, []string{service})
+		}
+	case "info":
+		if allowMethod(w, r, http.MethodGet) {
+			s.handleInfo(w, service)
+		}
+	case "run":
+		if allowMethod(w, r, http.MethodPost) {
+			s.handleAction(w, service, "run", func(basePath string) error {
+				return runService(service, basePath, serveOptions())
+			})
+		}
+	case "down":
+		if allowMethod(w, r, http.MethodPost) {
+			removeVolumes, _ := strconv.ParseBool(r.URL.Query().Get("volumes"))
+			s.handleAction(w, service, "down", func(basePath string) error {
+				return stopService(service, basePath, removeVolumes, serveOptions())
+			})
+		}
+	case "logs":
+		if allowMethod(w, r, http.MethodGet) {
+			s.handleLogs(w, r, service)
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, apiError{Error: fmt.Sprintf("unknown endpoint %s", r.URL.Path)})
+	}
+}
+
+func (s *apiServer) writeStatus(w http.ResponseWriter, r *http.Request, services []string) {
+	basePath, err := config.GetServicesPath()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+
+	client, err := engine.NewFromEnv()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+
+	statuses, err := collectStatus(r.Context(), client, basePath, services)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, apiError{Error: err.Error()})
+		return
+	}
+	if len(services) == 1 && len(statuses) == 1 {
+		writeJSON(w, http.StatusOK, statuses[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (s *apiServer) handleInfo(w http.ResponseWriter, service string) {
+	basePath, err := config.GetServicesPath()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+
+	content, err := os.ReadFile(filepath.Join(basePath, service, "docker-compose.yml"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: fmt.Sprintf("error reading docker-compose.yml: %v", err)})
+		return
+	}
+
+	values, err := stack.ServiceSecrets(service, serveOptions())
+	if err != nil {
+		writeJSON(w, errorStatus(err), apiError{Error: fmt.Sprintf("error reading the secrets of %s: %v", service, err)})
+		return
+	}
+
+	var buf bytes.Buffer
+	displayServiceInfo(&buf, service, string(content), values, false)
+	writeJSON(w, http.StatusOK, serviceInfo{Service: service, Text: buf.String()})
+}
+
+// handleAction ejecuta run o down sin permitir dos operaciones simultáneas
+// sobre el mismo servicio
+func (s *apiServer) handleAction(w http.ResponseWriter, service, action string, fn func(basePath string) error) {
+	basePath, err := config.GetServicesPath()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+
+	lock := s.serviceLock(service)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := fn(basePath); err != nil {
+		body := apiError{Error: err.Error()}
+		var composeErr *composeError
+		if errors.As(err, &composeErr) {
+			body.Output = composeErr.output
+		}
+		writeJSON(w, errorStatus(err), body)
+		return
+	}
+	writeJSON(w, http.StatusOK, actionResult{Service: service, Action: action})
+}
+
+// serveOptions prepara los servicios sin preguntar en la terminal: la API no
+// puede esperar a que alguien escriba la frase de paso en la terminal del
+// servidor, así que se toma de INFRACLI_SECRETS_PASSPHRASE. Cada petición
+// desbloquea el almacén una vez y ve los secretos guardados desde que arrancó.
+func serveOptions() stack.Options {
+	return stack.Options{HookEnv: infracli.HookEnv, Secrets: &stack.SecretsCache{}}
+}
+
+// errorStatus devuelve el código de respuesta de un error de run, down o info.
+// Sin frase de paso el servidor no puede atender la petición hasta que se
+// reinicie con INFRACLI_SECRETS_PASSPHRASE.
+func errorStatus(err error) int {
+	if errors.Is(err, stack.ErrPassphraseRequired) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// handleLogs envía los logs de los contenedores del servicio como Server-Sent
+// Events, un evento "log" por línea. Con follow=true la conexión sigue abierta.
+func (s *apiServer) handleLogs(w http.ResponseWriter, r *http.Request, service string) {
+	query := r.URL.Query()
+	follow, _ := strconv.ParseBool(query.Get("follow"))
+	tail, _ := strconv.Atoi(query.Get("tail"))
+	containerName := query.Get("container")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: "streaming not supported"})
+		return
+	}
+
+	basePath, err := config.GetServicesPath()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+
+	client, err := engine.NewFromEnv()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: err.Error()})
+		return
+	}
+
+	ctx := r.Context()
+	containers, err := client.ServiceContainers(ctx, filepath.Join(basePath, service))
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, apiError{Error: err.Error()})
+		return
+	}
+	containers = filterContainers(containers, containerName)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var mu sync.Mutex
+	send := func(event string, data interface{}) {
+		payload, _ := json.Marshal(data)
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+	}
+
+	opts := engine.LogsOptions{Follow: follow, Tail: tail}
+	var wg sync.WaitGroup
+	for _, container := range containers {
+		wg.Add(1)
+		go func(container engine.Container) {
+			defer wg.Done()
+
+			stdout := &eventWriter{send: send, container: container.Name(), stream: "stdout"}
+			stderr := &eventWriter{send: send, container: container.Name(), stream: "stderr"}
+			if err := copyContainerLogs(ctx, client, container, opts, stdout, stderr); err != nil && ctx.Err() == nil {
+				send("error", apiError{Error: fmt.Sprintf("error reading logs of %s: %v", container.Name(), err)})
+			}
+			stdout.flush()
+			stderr.flush()
+		}(container)
+	}
+	wg.Wait()
+
+	send("end", struct{}{})
+}
+
+func (s *apiServer) serviceLock(service string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[service] == nil {
+		s.locks[service] = &sync.Mutex{}
+	}
+	return s.locks[service]
+}
+
+// eventWriter convierte cada línea escrita en un evento "log"
+type eventWriter struct {
+	send      func(event string, data interface{})
+	container string
+	stream    string
+	buf       []byte
+}
+
+func (e *eventWriter) Write(p []byte) (int, error) {
+	e.buf = append(e.buf, p...)
+	for {
+		i := bytes.IndexByte(e.buf, '\n')
+		if i < 0 {
+			break
+		}
+		e.send("log", logLine{Container: e.container, Stream: e.stream, Line: strings.TrimSuffix(string(e.buf[:i]), "\r")})
+		e.buf = e.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (e *eventWriter) flush() {
+	if len(e.buf) > 0 {
+		e.send("log", logLine{Container: e.container, Stream: e.stream, Line: string(e.buf)})
+		e.buf = nil
+	}
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: fmt.Sprintf("method %s not allowed", r.Method)})
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(body)
+}
+
+func init() {
+	serveCmd.Flags().String("listen", "", "Address to listen on: unix:///path/to.sock or host:port (default: serve.sock in the state directory)")
+	serveCmd.Flags().String("token", "", "Bearer token required from TCP clients (env: "+serveTokenEnv+")")
+	RootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/engine/enginetest"
+	"github.com/solrac97gr/infrastructure/infracli/secrets"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+)
+
+const postgresCompose = `services:
+  db:
+    image: postgres:16
+    container_name: postgres
+    environment:
+      POSTGRES_PASSWORD: postgres
+    ports:
+      - "5432:5432"
+`
+
+// setupServices crea un directorio de servicios con el docker-compose.yml de
+// cada uno y apunta a él la configuración, en directorios temporales
+func setupServices(t *testing.T, services map[string]string) string {
+	t.Helper()
+
+	basePath := t.TempDir()
+	for service, content := range services {
+		dir := filepath.Join(basePath, service)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("INFRACLI_SERVICES_PATH", basePath)
+	t.Setenv(config.ConfigFileEnv, "")
+	t.Setenv(stack.PassphraseEnv, "")
+	os.Unsetenv(stack.PassphraseEnv)
+	return basePath
+}
+
+func TestServeAuthentication(t *testing.T) {
+	setupServices(t, map[string]string{"postgres": postgresCompose})
+	server := httptest.NewServer(newAPIServer("secret-token").routes())
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"no token", "/v1/services", "", http.StatusUnauthorized},
+		{"wrong bearer", "/v1/services", "Bearer other", http.StatusUnauthorized},
+		{"not bearer", "/v1/services", "Basic c2VjcmV0LXRva2Vu", http.StatusUnauthorized},
+		{"bearer", "/v1/services", "Bearer secret-token", http.StatusOK},
+		{"access_token", "/v1/services?access_token=secret-token", "", http.StatusOK},
+		{"wrong access_token", "/v1/services?access_token=other", "", http.StatusUnauthorized},
+		// La cabecera tiene prioridad sobre el parámetro
+		{"bearer over access_token", "/v1/services?access_token=secret-token", "Bearer other", http.StatusUnauthorized},
+		{"openapi needs a token", "/openapi.json", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != `Bearer realm="infracli"` {
+				t.Errorf("WWW-Authenticate = %q", resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestServeRoutes(t *testing.T) {
+	setupServices(t, map[string]string{"postgres": postgresCompose, "mysql": postgresCompose})
+	// Sin token, como en el socket unix
+	server := httptest.NewServer(newAPIServer("").routes())
+	defer server.Close()
+
+	tests := []struct {
+		method    string
+		path      string
+		want      int
+		allow     string
+		wantError string
+	}{
+		{http.MethodPost, "/v1/services", http.StatusMethodNotAllowed, "GET", "method POST not allowed"},
+		{http.MethodDelete, "/v1/status", http.StatusMethodNotAllowed, "GET", "method DELETE not allowed"},
+		{http.MethodGet, "/v1/services/postgres/run", http.StatusMethodNotAllowed, "POST", "method GET not allowed"},
+		{http.MethodGet, "/v1/services/postgres/down", http.StatusMethodNotAllowed, "POST", "method GET not allowed"},
+		{http.MethodPost, "/v1/services/postgres/logs", http.StatusMethodNotAllowed, "GET", "method POST not allowed"},
+		{http.MethodGet, "/v1/services/redis/status", http.StatusNotFound, "", "service 'redis' not found"},
+		{http.MethodPost, "/v1/services/redis/run", http.StatusNotFound, "", "service 'redis' not found"},
+		{http.MethodGet, "/v1/services/postgres/restart", http.StatusNotFound, "", "unknown endpoint /v1/services/postgres/restart"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if resp.Header.Get("Allow") != tt.allow {
+				t.Errorf("Allow = %q, want %q", resp.Header.Get("Allow"), tt.allow)
+			}
+			var body apiError
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error != tt.wantError {
+				t.Errorf("error = %q (%v), want %q", body.Error, err, tt.wantError)
+			}
+		})
+	}
+
+	var services []string
+	getJSON(t, server.URL+"/v1/services", &services)
+	if want := []string{"mysql", "postgres"}; !reflect.DeepEqual(services, want) {
+		t.Errorf("services = %v, want %v", services, want)
+	}
+
+	var spec map[string]interface{}
+	getJSON(t, server.URL+"/openapi.json", &spec)
+	if spec["openapi"] == nil {
+		t.Errorf("unexpected OpenAPI document %v", spec)
+	}
+}
+
+// TestServeWithoutPassphrase comprueba que la API no pregunta la frase de paso
+// en la terminal del servidor y responde 503 si no está en el entorno
+func TestServeWithoutPassphrase(t *testing.T) {
+	setupServices(t, map[string]string{"postgres": postgresCompose})
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := secrets.NewWithPassphrase("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("postgres", "POSTGRES_PASSWORD", "stored"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(secrets.Path(configDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(newAPIServer("").routes())
+	defer server.Close()
+
+	for _, request := range []struct{ method, path string }{
+		{http.MethodPost, "/v1/services/postgres/run"},
+		{http.MethodGet, "/v1/services/postgres/info"},
+	} {
+		req, _ := http.NewRequest(request.method, server.URL+request.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body apiError
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable || !strings.Contains(body.Error, stack.PassphraseEnv) {
+			t.Errorf("%s %s: got %d %q, want 503 asking for %s", request.method, request.path, resp.StatusCode, body.Error, stack.PassphraseEnv)
+		}
+	}
+}
+
+func TestServeLogs(t *testing.T) {
+	basePath := setupServices(t, map[string]string{"postgres": postgresCompose})
+	servicePath := filepath.Join(basePath, "postgres")
+
+	docker := newFakeDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := "/" + engine.APIVersion
+		switch r.URL.Path {
+		case prefix + "/containers/json":
+			json.NewEncoder(w).Encode([]engine.Container{
+				{ID: "db", Names: []string{"/postgres"}, Labels: map[string]string{engine.LabelProject: "postgres", engine.LabelWorkingDir: servicePath}},
+				{ID: "other", Names: []string{"/mysql"}, Labels: map[string]string{engine.LabelProject: "mysql", engine.LabelWorkingDir: filepath.Join(basePath, "mysql")}},
+			})
+		case prefix + "/containers/db/json":
+			json.NewEncoder(w).Encode(engine.ContainerDetails{ID: "db", Name: "/postgres"})
+		case prefix + "/containers/db/logs":
+			if r.URL.Query().Get("tail") != "2" {
+				t.Errorf("tail = %q, want 2", r.URL.Query().Get("tail"))
+			}
+			w.Write(enginetest.LogFrame(1, "ready to accept connections\r\n"))
+			w.Write(enginetest.LogFrame(2, "WARNING: no password"))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Setenv("DOCKER_HOST", docker.Host())
+
+	server := httptest.NewServer(newAPIServer("secret-token").routes())
+	defer server.Close()
+
+	// EventSource no puede enviar cabeceras y usa access_token
+	resp, err := http.Get(server.URL + "/v1/services/postgres/logs?tail=2&access_token=secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	type event struct {
+		name string
+		data string
+	}
+	var events []event
+	var current event
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			events = append(events, current)
+			current = event{}
+		}
+	}
+
+	want := []event{
+		{"log", `{"container":"postgres","stream":"stdout","line":"ready to accept connections"}`},
+		{"log", `{"container":"postgres","stream":"stderr","line":"WARNING: no password"}`},
+		{"end", `{}`},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func getJSON(t *testing.T, url string, out interface{}) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		t.Fatalf("GET %s: %d %s", url, resp.StatusCode, data)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatal(err)
+	}
+}
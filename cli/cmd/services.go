@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+)
+
+// selectServices convierte los argumentos de un comando en servicios disponibles.
+// "all" selecciona todos los servicios y los alias se sustituyen por su servicio.
+// Los nombres desconocidos se informan con sugerencias y se omiten, salvo que en
+// una terminal se elija uno de los servicios parecidos.
+func selectServices(args []string, availableServices []string) []string {
+	if len(args) == 1 && args[0] == "all" {
+		return availableServices
+	}
+
+	aliases := serviceAliases(availableServices)
+
+	var selected []string
+	for _, arg := range args {
+		service, ok := matchService(arg, availableServices, aliases)
+		if !ok {
+			continue
+		}
+		// Un servicio y su alias en la misma orden solo se usan una vez
+		if !containsString(selected, service) {
+			selected = append(selected, service)
+		}
+	}
+
+	return selected
+}
+
+// resolveServiceName devuelve el servicio que corresponde a un nombre o alias
+func resolveServiceName(name string) (string, error) {
+	available, err := config.GetAvailableServices()
+	if err != nil {
+		return "", err
+	}
+	selected := selectServices([]string{name}, available)
+	if len(selected) == 0 {
+		return "", fmt.Errorf("service '%s' not found in available services", name)
+	}
+	return selected[0], nil
+}
+
+// matchService busca el servicio de un argumento: por su nombre, por un alias
+// o, si no coincide ninguno, entre los servicios de nombre parecido
+func matchService(name string, availableServices []string, aliases map[string]string) (string, bool) {
+	if service, ok := resolveService(name, availableServices, aliases); ok {
+		if service != name {
+			logger.Debugf("Using service %s for '%s'", service, name)
+		}
+		return service, true
+	}
+
+	suggestions := suggestServices(name, availableServices, aliases)
+	switch {
+	case len(suggestions) == 1:
+		logger.Warnf("Warning: Service '%s' not found. Did you mean '%s'?", name, suggestions[0])
+	case len(suggestions) > 1 && isTerminal(os.Stdin) && isTerminal(os.Stderr):
+		return pickService(bufio.NewReader(os.Stdin), name, suggestions)
+	case len(suggestions) > 1:
+		logger.Warnf("Warning: Service '%s' is ambiguous. Did you mean one of: %s?", name, strings.Join(suggestions, ", "))
+	default:
+		logger.Warnf("Warning: Service '%s' not found in available services", name)
+		logger.Warnf("Available services: %s", strings.Join(availableServices, ", "))
+	}
+	return "", false
+}
+
+// resolveService busca el servicio por su nombre exacto o por un alias, sin
+// distinguir mayúsculas. Los alias están en minúsculas, como los devuelve
+// serviceAliases.
+func resolveService(name string, availableServices []string, aliases map[string]string) (string, bool) {
+	if containsString(availableServices, name) {
+		return name, true
+	}
+	lower := strings.ToLower(name)
+	for _, service := range availableServices {
+		if strings.ToLower(service) == lower {
+			return service, true
+		}
+	}
+	if service, ok := aliases[lower]; ok {
+		return service, true
+	}
+	return "", false
+}
+
+// suggestServices devuelve los servicios cuyo nombre o alias empieza por name
+// o está a poca distancia de edición, del más parecido al menos
+func suggestServices(name string, availableServices []string, aliases map[string]string) []string {
+	lower := strings.ToLower(name)
+	if lower == "" {
+		return nil
+	}
+	// Se toleran más errores cuanto más largo es el nombre, pero nunca tantos
+	// como letras tiene, o cualquier nombre corto se parecería a todo
+	maxDistance := len(lower) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	if maxDistance >= len(lower) {
+		maxDistance = len(lower) - 1
+	}
+
+	best := make(map[string]int)
+	consider := func(candidate, service string) {
+		candidate = strings.ToLower(candidate)
+		distance := editDistance(lower, candidate)
+		if strings.HasPrefix(candidate, lower) {
+			// Un prefijo es la sugerencia más probable aunque falten muchas letras
+			distance = 0
+		}
+		if distance > maxDistance {
+			return
+		}
+		if current, ok := best[service]; !ok || distance < current {
+			best[service] = distance
+		}
+	}
+	for _, service := range availableServices {
+		consider(service, service)
+	}
+	for alias, service := range aliases {
+		consider(alias, service)
+	}
+
+	suggestions := make([]string, 0, len(best))
+	for service := range best {
+		suggestions = append(suggestions, service)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if best[suggestions[i]] != best[suggestions[j]] {
+			return best[suggestions[i]] < best[suggestions[j]]
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	return suggestions
+}
+
+// editDistance calcula la distancia de Levenshtein entre dos cadenas
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// pickService pregunta cuál de los servicios parecidos se quería usar. Se
+// escribe en stderr para no mezclar la pregunta con la salida del comando.
+func pickService(reader *bufio.Reader, name string, suggestions []string) (string, bool) {
+	fmt.Fprintf(os.Stderr, "Service '%s' is ambiguous:\n", name)
+	for i, service := range suggestions {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, service)
+	}
+	fmt.Fprintf(os.Stderr, "Choose a service [1-%d, empty to skip]: ", len(suggestions))
+
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "", false
+	}
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(suggestions) {
+		return suggestions[n-1], true
+	}
+	if containsString(suggestions, answer) {
+		return answer, true
+	}
+	logger.Warnf("Warning: '%s' is not one of the options; skipping '%s'", answer, name)
+	return "", false
+}
+
+// serviceAliases devuelve el servicio al que apunta cada alias, en minúsculas:
+// los declarados en la sección x-infracli del docker-compose.yml de cada
+// servicio y los de la configuración, que tienen prioridad. Si dos alias solo
+// se distinguen en mayúsculas y apuntan a servicios distintos se avisa y se
+// usa el primero, en el orden de los servicios o de los alias.
+func serviceAliases(availableServices []string) map[string]string {
+	aliases := make(map[string]string)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.Debugf("Cannot load aliases: %v", err)
+		return aliases
+	}
+	basePath, err := config.ExpandPath(cfg.ServicesPath)
+	if err != nil {
+		logger.Debugf("Cannot load aliases: %v", err)
+		return aliases
+	}
+
+	for _, service := range availableServices {
+		content, err := compose.Read(filepath.Join(basePath, service))
+		if err != nil {
+			continue
+		}
+		for _, alias := range compose.Aliases(content) {
+			key := strings.ToLower(alias)
+			if other, ok := aliases[key]; ok && other != service {
+				logger.Warnf("Warning: alias '%s' of %s is already an alias of %s; using %s", alias, service, other, other)
+				continue
+			}
+			aliases[key] = service
+		}
+	}
+
+	configAliases := cfg.ServiceAliases()
+	seen := make(map[string]string)
+	for _, alias := range sortedKeys(configAliases) {
+		service := configAliases[alias]
+		if !containsString(availableServices, service) {
+			logger.Warnf("Warning: alias '%s' points to unknown service '%s'", alias, service)
+			continue
+		}
+		key := strings.ToLower(alias)
+		if other, ok := seen[key]; ok && configAliases[other] != service {
+			logger.Warnf("Warning: aliases '%s' and '%s' only differ in case; using '%s' for %s", other, alias, other, configAliases[other])
+			continue
+		}
+		seen[key] = alias
+		aliases[key] = service
+	}
+
+	return aliases
+}
+
+// containsString indica si la lista contiene el valor
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+)
+
+var testServices = []string{"elasticsearch-kibana", "mongo", "mysql", "postgres", "rabbitmq", "redis"}
+
+var testAliases = map[string]string{"pg": "postgres", "es": "elasticsearch-kibana", "postgresql": "postgres"}
+
+func TestResolveService(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []string
+		want      string
+		wantOK    bool
+	}{
+		{"postgres", testServices, "postgres", true},
+		{"Postgres", testServices, "postgres", true},
+		{"pg", testServices, "postgres", true},
+		{"PG", testServices, "postgres", true},
+		{"PostgreSQL", testServices, "postgres", true},
+		{"postgrs", testServices, "", false},
+		{"", testServices, "", false},
+		// El nombre exacto tiene prioridad sobre uno que solo difiere en mayúsculas
+		{"redis", []string{"Redis", "redis"}, "redis", true},
+		{"REDIS", []string{"Redis", "redis"}, "Redis", true},
+		// Un servicio tiene prioridad sobre un alias con el mismo nombre
+		{"es", []string{"elasticsearch-kibana", "es"}, "es", true},
+	}
+
+	for _, tt := range tests {
+		got, ok := resolveService(tt.name, tt.available, testAliases)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("resolveService(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSuggestServices(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"postgrs", []string{"postgres"}},
+		{"pstgres", []string{"postgres"}},
+		// Con nombres cortos solo cuentan los prefijos
+		{"m", []string{"mongo", "mysql"}},
+		{"mo", []string{"mongo"}},
+		{"my", []string{"mysql"}},
+		{"rd", nil},
+		// Del más parecido al menos, por distancia y después por nombre
+		{"reds", []string{"redis", "elasticsearch-kibana"}},
+		{"rabbit", []string{"rabbitmq"}},
+		// A tres ediciones de un nombre de cinco letras ya no se parece
+		{"rabit", nil},
+		{"elastic", []string{"elasticsearch-kibana"}},
+		// Los alias también se comparan, pero se sugiere su servicio
+		{"postgresq", []string{"postgres"}},
+		{"kafka", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := suggestServices(tt.name, testServices, testAliases)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("suggestServices(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"redis", "", 5},
+		{"", "mongo", 5},
+		{"mysql", "mysql", 0},
+		{"kitten", "sitting", 3},
+		{"postgres", "postgers", 2},
+		{"reds", "redis", 1},
+		// Se cuentan runas, no bytes
+		{"ñu", "nu", 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestPickService(t *testing.T) {
+	suggestions := []string{"mongo", "mysql"}
+	tests := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"1\n", "mongo", true},
+		{" 2 \n", "mysql", true},
+		{"mysql\n", "mysql", true},
+		{"2", "mysql", true},
+		{"\n", "", false},
+		{"", "", false},
+		{"0\n", "", false},
+		{"3\n", "", false},
+		{"redis\n", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := pickService(bufio.NewReader(strings.NewReader(tt.input)), "m", suggestions)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("pickService(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestServiceAliases comprueba que los alias que solo se distinguen en
+// mayúsculas se resuelven siempre igual
+func TestServiceAliases(t *testing.T) {
+	setupServices(t, map[string]string{
+		"mysql":    "services:\n  mysql:\n    image: mysql:8\n",
+		"pgvector": "services:\n  db:\n    image: pgvector/pgvector:pg16\nx-infracli:\n  aliases: [PG, vector]\n",
+		"postgres": "services:\n  db:\n    image: postgres:16\nx-infracli:\n  aliases: [pg, PostgreSQL]\n",
+	})
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	userConfig := `{"version": 1, "aliases": ["db=postgres", "DB=mysql", "sql=mysql", "Vector=postgres"]}`
+	if err := os.WriteFile(filepath.Join(configDir, config.ConfigFileName), []byte(userConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	available, err := config.GetAvailableServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		// pgvector va antes que postgres, así que PG es suyo
+		"pg":         "pgvector",
+		"postgresql": "postgres",
+		// DB va antes que db en orden
+		"db":  "mysql",
+		"sql": "mysql",
+		// La configuración tiene prioridad sobre el docker-compose.yml
+		"vector": "postgres",
+	}
+	for i := 0; i < 10; i++ {
+		if got := serviceAliases(available); !reflect.DeepEqual(got, want) {
+			t.Fatalf("aliases = %v, want %v", got, want)
+		}
+	}
+
+	if got := selectServices([]string{"Pg", "DB", "postgresql"}, available); !reflect.DeepEqual(got, []string{"pgvector", "mysql", "postgres"}) {
+		t.Errorf("selected %v", got)
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/spf13/cobra"
+)
+
+// containerStatus es el estado de un contenedor de un servicio
+type containerStatus struct {
+	Name    string   `json:"name"`
+	Service string   `json:"service"`
+	Image   string   `json:"image"`
+	State   string   `json:"state"`
+	Health  string   `json:"health"`
+	Ports   []string `json:"ports"`
+}
+
+// serviceStatus es el estado de un servicio de infracli y sus contenedores
+type serviceStatus struct {
+	Service    string            `json:"service"`
+	Running    bool              `json:"running"`
+	Containers []containerStatus `json:"containers"`
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status [service1] [service2] ...",
+	Short: "Show the state of infrastructure services",
+	Long: `Show the state and health of the containers of each infrastructure service.
+The information is read directly from the Docker Engine API, using the socket
+at /var/run/docker.sock or the address in $DOCKER_HOST.
+Without arguments, all available services are shown.
+
+Examples:
+  infracli status
+  infracli status mysql redis
+  infracli status -o json`,
+	ValidArgsFunction: completeServices(0, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		services := availableServices
+		if len(args) > 0 {
+			services = selectServices(args, availableServices)
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+
+		statuses, err := collectStatus(cmd.Context(), client, basePath, services)
+		if err != nil {
+			logger.Errorf("Error getting service status: %v", err)
+			return
+		}
+
+		switch output {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			encoder.Encode(statuses)
+		case "table":
+			printStatusTable(statuses)
+		default:
+			logger.Errorf("Error: invalid output format %q (valid formats: table, json)", output)
+		}
+	},
+}
+
+// collectStatus consulta al demonio de Docker el estado de los servicios indicados
+func collectStatus(ctx context.Context, client *engine.Client, basePath string, services []string) ([]serviceStatus, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	containers, err := client.ComposeContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := engine.GroupByService(containers, basePath, services)
+
+	var statuses []serviceStatus
+	for _, service := range services {
+		status := serviceStatus{Service: service, Containers: []containerStatus{}}
+
+		for _, container := range groups[service] {
+			health := "none"
+			if details, err := client.InspectContainer(ctx, container.ID); err == nil {
+				health = details.State.HealthStatus()
+			} else {
+				logger.Debugf("Error inspecting container %s: %v", container.Name(), err)
+			}
+
+			status.Containers = append(status.Containers, containerStatus{
+				Name:    container.Name(),
+				Service: container.ComposeService(),
+				Image:   container.Image,
+				State:   container.State,
+				Health:  health,
+				Ports:   formatPorts(container.Ports),
+			})
+
+			if container.State == "running" {
+				status.Running = true
+			}
+		}
+
+		sort.Slice(status.Containers, func(i, j int) bool {
+			return status.Containers[i].Name < status.Containers[j].Name
+		})
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// formatPorts convierte los puertos publicados en texto como "3306->3306/tcp"
+func formatPorts(ports []engine.Port) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, port := range ports {
+		var text string
+		if port.PublicPort > 0 {
+			text = fmt.Sprintf("%d->%d/%s", port.PublicPort, port.PrivatePort, port.Type)
+		} else {
+			text = fmt.Sprintf("%d/%s", port.PrivatePort, port.Type)
+		}
+		// Docker lista los puertos una vez por IPv4 y otra por IPv6
+		if !seen[text] {
+			seen[text] = true
+			result = append(result, text)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// reportContainerProblems consulta al demonio de Docker los contenedores de
+// un servicio y registra los que no están en ejecución o no están sanos,
+// con el código de salida y la última salida del healthcheck
+func reportContainerProblems(service, servicePath string) {
+	reportProblems(service, func(ctx context.Context, client *engine.Client) ([]engine.Container, error) {
+		return client.ServiceContainers(ctx, servicePath)
+	})
+}
+
+// reportProblems registra los problemas de los contenedores que devuelve list
+func reportProblems(service string, list func(context.Context, *engine.Client) ([]engine.Container, error)) {
+	client, err := engine.NewFromEnv()
+	if err != nil {
+		logger.Debugf("Cannot inspect containers of %s: %v", service, err)
+		return
+	}
+
+	ctx := context.Background()
+	containers, err := list(ctx, client)
+	if err != nil {
+		logger.Debugf("Cannot inspect containers of %s: %v", service, err)
+		return
+	}
+
+	for _, container := range containers {
+		details, err := client.InspectContainer(ctx, container.ID)
+		if err != nil {
+			logger.Debugf("Error inspecting container %s: %v", container.Name(), err)
+			continue
+		}
+
+		state := details.State
+		switch {
+		case !state.Running:
+			logger.Warnf("Container %s is %s (exit code %d)", container.Name(), state.Status, state.ExitCode)
+			if state.Error != "" {
+				logger.Warnf("  %s", state.Error)
+			}
+		case state.HealthStatus() == "unhealthy":
+			logger.Warnf("Container %s is unhealthy", container.Name())
+			if n := len(state.Health.Log); n > 0 {
+				logger.Warnf("  last health check: %s", strings.TrimSpace(state.Health.Log[n-1].Output))
+			}
+		}
+	}
+}
+
+func printStatusTable(statuses []serviceStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tCONTAINER\tSTATE\tHEALTH\tPORTS")
+	for _, status := range statuses {
+		if len(status.Containers) == 0 {
+			fmt.Fprintf(w, "%s\t-\tnot created\t-\t-\n", status.Service)
+			continue
+		}
+		for _, container := range status.Containers {
+			ports := strings.Join(container.Ports, ", ")
+			if ports == "" {
+				ports = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", status.Service, container.Name, container.State, container.Health, ports)
+		}
+	}
+	w.Flush()
+}
+
+func init() {
+	statusCmd.Flags().StringP("output", "o", "table", "Output format: table or json")
+	RootCmd.AddCommand(statusCmd)
+}
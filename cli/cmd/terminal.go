@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// isTerminal indica si el archivo está conectado a una terminal interactiva
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Teclas especiales que devuelve readKeys además de los caracteres normales
+const (
+	keyUp    = "up"
+	keyDown  = "down"
+	keyTab   = "tab"
+	keyEnter = "enter"
+	keyEsc   = "esc"
+	keyCtrlC = "ctrl+c"
+)
+
+// readKeys lee las pulsaciones de una terminal en modo raw y las envía al
+// canal, traduciendo las secuencias de escape de las flechas
+func readKeys(in io.Reader, keys chan<- string) {
+	buf := make([]byte, 64)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+
+		input := buf[:n]
+		for len(input) > 0 {
+			switch {
+			case bytes.HasPrefix(input, []byte("\x1b[A")), bytes.HasPrefix(input, []byte("\x1bOA")):
+				keys <- keyUp
+				input = input[3:]
+			case bytes.HasPrefix(input, []byte("\x1b[B")), bytes.HasPrefix(input, []byte("\x1bOB")):
+				keys <- keyDown
+				input = input[3:]
+			case input[0] == 0x1b:
+				// Otras secuencias de escape no se usan; se descartan enteras
+				keys <- keyEsc
+				input = nil
+			case input[0] == '\t':
+				keys <- keyTab
+				input = input[1:]
+			case input[0] == '\r' || input[0] == '\n':
+				keys <- keyEnter
+				input = input[1:]
+			case input[0] == 0x03:
+				keys <- keyCtrlC
+				input = input[1:]
+			default:
+				r, size := utf8.DecodeRune(input)
+				keys <- string(r)
+				input = input[size:]
+			}
+		}
+	}
+}
+
+// copyToClipboard copia text al portapapeles con la primera herramienta del
+// sistema disponible. Si no hay ninguna usa la secuencia OSC 52, que la
+// mayoría de terminales entienden incluso a través de SSH.
+func copyToClipboard(text string) error {
+	tools := [][]string{
+		{"pbcopy"},
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+		{"clip.exe"},
+	}
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("error running %s: %v", tool[0], err)
+		}
+		return nil
+	}
+
+	_, err := fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
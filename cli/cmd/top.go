@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/spf13/cobra"
+)
+
+// serviceUsage es el consumo de recursos de un servicio, sumando todos sus contenedores
+type serviceUsage struct {
+	Service         string  `json:"service"`
+	Containers      int     `json:"containers"`
+	CPUPercent      float64 `json:"cpuPercent"`
+	MemoryBytes     uint64  `json:"memoryBytes"`
+	MemoryLimit     uint64  `json:"memoryLimitBytes"`
+	NetRxBytes      uint64  `json:"netRxBytes"`
+	NetTxBytes      uint64  `json:"netTxBytes"`
+	BlockReadBytes  uint64  `json:"blockReadBytes"`
+	BlockWriteBytes uint64  `json:"blockWriteBytes"`
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top [service1] [service2] ...",
+	Short: "Show resource usage of running services",
+	Long: `Show a live, refreshing table with the CPU, memory, network and block I/O
+used by each infrastructure service, adding up all of its containers.
+Only services with running containers are shown. Press Ctrl+C to exit.
+
+Use --once to print a single snapshot; JSON output always prints a single snapshot.
+
+Examples:
+  infracli top
+  infracli top elasticsearch-kibana neo4j --interval 5s
+  infracli top --once -o json`,
+	ValidArgsFunction: completeServices(0, false),
+	Run: func(cmd *cobra.Command, args []string) {
+		once, _ := cmd.Flags().GetBool("once")
+		output, _ := cmd.Flags().GetString("output")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		sortBy, _ := cmd.Flags().GetString("sort")
+
+		if output != "table" && output != "json" {
+			logger.Errorf("Error: invalid output format %q (valid formats: table, json)", output)
+			return
+		}
+		if sortBy != "name" && sortBy != "cpu" && sortBy != "mem" {
+			logger.Errorf("Error: invalid sort column %q (valid columns: name, cpu, mem)", sortBy)
+			return
+		}
+
+		availableServices, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		services := availableServices
+		if len(args) > 0 {
+			services = selectServices(args, availableServices)
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		for {
+			usage, err := collectUsage(ctx, client, basePath, services)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Errorf("Error getting resource usage: %v", err)
+				}
+				return
+			}
+			sortUsage(usage, sortBy)
+
+			if output == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				encoder.Encode(usage)
+				return
+			}
+
+			if once {
+				printUsageTable(os.Stdout, usage)
+				return
+			}
+
+			// Limpiar la pantalla y volver a dibujar la tabla
+			fmt.Print("\033[H\033[2J")
+			fmt.Printf("infracli top - %s (every %s, Ctrl+C to exit)\n\n", time.Now().Format("15:04:05"), interval)
+			printUsageTable(os.Stdout, usage)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+// collectUsage obtiene una muestra de estadísticas de todos los contenedores en
+// ejecución de los servicios indicados y las suma por servicio. Los servicios
+// sin contenedores en ejecución no se incluyen.
+func collectUsage(ctx context.Context, client *engine.Client, basePath string, services []string) ([]serviceUsage, error) {
+	containers, err := client.ComposeContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := engine.GroupByService(containers, basePath, services)
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		totals = make(map[string]*serviceUsage)
+	)
+
+	for _, service := range services {
+		for _, container := range groups[service] {
+			if container.State != "running" {
+				continue
+			}
+
+			wg.Add(1)
+			go func(service string, container engine.Container) {
+				defer wg.Done()
+
+				// Cada muestra tarda alrededor de un segundo, por eso se piden en paralelo
+				stats, err := client.ContainerStats(ctx, container.ID)
+				if err != nil {
+					logger.Debugf("Error getting stats of %s: %v", container.Name(), err)
+					return
+				}
+
+				rx, tx := stats.NetworkIO()
+				read, write := stats.BlockIO()
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				total, ok := totals[service]
+				if !ok {
+					total = &serviceUsage{Service: service}
+					totals[service] = total
+				}
+				total.Containers++
+				total.CPUPercent += stats.CPUPercent()
+				total.MemoryBytes += stats.MemoryUsage()
+				total.MemoryLimit += stats.MemoryStats.Limit
+				total.NetRxBytes += rx
+				total.NetTxBytes += tx
+				total.BlockReadBytes += read
+				total.BlockWriteBytes += write
+			}(service, container)
+		}
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	usage := []serviceUsage{}
+	for _, service := range services {
+		if total, ok := totals[service]; ok {
+			usage = append(usage, *total)
+		}
+	}
+	return usage, nil
+}
+
+// sortUsage ordena por nombre, o de mayor a menor consumo de CPU o memoria
+func sortUsage(usage []serviceUsage, by string) {
+	sort.SliceStable(usage, func(i, j int) bool {
+		switch by {
+		case "cpu":
+			return usage[i].CPUPercent > usage[j].CPUPercent
+		case "mem":
+			return usage[i].MemoryBytes > usage[j].MemoryBytes
+		default:
+			return usage[i].Service < usage[j].Service
+		}
+	})
+}
+
+func printUsageTable(out io.Writer, usage []serviceUsage) {
+	if len(usage) == 0 {
+		fmt.Fprintln(out, "No running services")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tCONTAINERS\tCPU %\tMEM USAGE / LIMIT\tNET I/O\tBLOCK I/O")
+	for _, u := range usage {
+		fmt.Fprintf(w, "%s\t%d\t%.2f%%\t%s / %s\t%s / %s\t%s / %s\n",
+			u.Service,
+			u.Containers,
+			u.CPUPercent,
+			humanBytes(u.MemoryBytes), humanBytes(u.MemoryLimit),
+			humanBytes(u.NetRxBytes), humanBytes(u.NetTxBytes),
+			humanBytes(u.BlockReadBytes), humanBytes(u.BlockWriteBytes),
+		)
+	}
+	w.Flush()
+}
+
+// humanBytes convierte un número de bytes en texto legible, por ejemplo "512MiB"
+func humanBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	topCmd.Flags().Bool("once", false, "Print a single snapshot and exit")
+	topCmd.Flags().StringP("output", "o", "table", "Output format: table or json (json implies --once)")
+	topCmd.Flags().Duration("interval", 2*time.Second, "Refresh interval")
+	topCmd.Flags().String("sort", "name", "Sort by: name, cpu or mem")
+	RootCmd.AddCommand(topCmd)
+}
@@ -0,0 +1,675 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	// uiRefreshInterval es cada cuánto se vuelve a consultar el estado
+	uiRefreshInterval = 2 * time.Second
+	// uiLogLines es el número de líneas de log que conserva el panel de logs
+	uiLogLines = 500
+	// uiHelp son los atajos de teclado que se muestran en la última línea
+	uiHelp = "↑↓ select  s start  d stop  r restart  l logs  i info  tab focus  c copy  q quit"
+)
+
+// Secuencias ANSI usadas por el panel
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiDim     = "\x1b[2m"
+)
+
+// uiPane es el contenido del panel derecho
+type uiPane int
+
+const (
+	infoPane uiPane = iota
+	logsPane
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Open an interactive dashboard of the infrastructure services",
+	Long: `Open a full-screen dashboard that lists the available services with their
+live status and health. From it you can start, stop and restart services, tail
+their logs and see and copy their connection information.
+
+Keys:
+  ↑/↓ or k/j   select a service (or a line of the info pane when focused)
+  s            start the selected service
+  d            stop the selected service
+  r            restart the selected service
+  l            tail the logs of the selected service
+  i            show the connection information of the selected service
+  tab          move the focus between the service list and the info pane
+  c            copy the selected info line, or the whole info pane, to the clipboard
+  q            quit
+
+Examples:
+  infracli ui`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			logger.Errorf("Error: infracli ui needs an interactive terminal")
+			return
+		}
+
+		services, err := config.GetAvailableServices()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return
+		}
+
+		basePath, err := config.GetServicesPath()
+		if err != nil {
+			logger.Errorf("Error loading configuration: %v", err)
+			return
+		}
+
+		client, err := engine.NewFromEnv()
+		if err != nil {
+			logger.Errorf("Error connecting to Docker: %v", err)
+			return
+		}
+
+		d := newDashboard(client, basePath, services)
+		if err := d.run(cmd.Context()); err != nil {
+			logger.Errorf("Error: %v", err)
+		}
+	},
+}
+
+// dashboard es el estado del panel interactivo
+type dashboard struct {
+	client   *engine.Client
+	basePath string
+	services []string
+
+	// redraw pide volver a dibujar la pantalla desde otras goroutines
+	redraw chan struct{}
+	// messages recibe los logs de infracli mientras el panel está abierto
+	messages *lineBuffer
+	// logs contiene los logs del servicio seleccionado
+	logs       *lineBuffer
+	stopLogs   context.CancelFunc
+	logService string
+
+	mu          sync.Mutex
+	statuses    map[string]serviceStatus
+	statusError string
+	busy        map[string]string
+	selected    int
+	pane        uiPane
+	focusInfo   bool
+	infoLines   []string
+	infoCursor  int
+	infoService string
+}
+
+func newDashboard(client *engine.Client, basePath string, services []string) *dashboard {
+	d := &dashboard{
+		client:   client,
+		basePath: basePath,
+		services: services,
+		redraw:   make(chan struct{}, 1),
+		statuses: make(map[string]serviceStatus),
+		busy:     make(map[string]string),
+	}
+	d.messages = newLineBuffer(1, d.requestRedraw)
+	d.logs = newLineBuffer(uiLogLines, d.requestRedraw)
+	return d
+}
+
+// run abre el panel y procesa teclas y actualizaciones hasta que se cierra
+func (d *dashboard) run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("error preparing the terminal: %v", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	// Pantalla alternativa y cursor oculto; se restauran al salir
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	// Los logs de infracli se muestran en la línea de mensajes en lugar de stderr
+	restoreLogger := logger.Redirect(d.messages)
+	defer restoreLogger()
+	defer d.closeLogs()
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+
+	refresh := time.NewTicker(uiRefreshInterval)
+	defer refresh.Stop()
+	// La terminal no avisa de los cambios de tamaño sin señales de Unix; se consulta
+	resize := time.NewTicker(250 * time.Millisecond)
+	defer resize.Stop()
+
+	go d.refreshStatus(ctx)
+	d.loadInfo()
+
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+	d.render(width, height)
+
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || !d.handleKey(ctx, key) {
+				return nil
+			}
+		case <-refresh.C:
+			go d.refreshStatus(ctx)
+			continue
+		case <-resize.C:
+			w, h, _ := term.GetSize(int(os.Stdout.Fd()))
+			if w == width && h == height {
+				continue
+			}
+		case <-d.redraw:
+		case <-ctx.Done():
+			return nil
+		}
+
+		width, height, _ = term.GetSize(int(os.Stdout.Fd()))
+		d.render(width, height)
+	}
+}
+
+// handleKey aplica una pulsación; devuelve false para cerrar el panel
+func (d *dashboard) handleKey(ctx context.Context, key string) bool {
+	switch key {
+	case "q", keyCtrlC:
+		return false
+	case keyUp, "k":
+		d.move(ctx, -1)
+	case keyDown, "j":
+		d.move(ctx, 1)
+	case keyTab:
+		d.mu.Lock()
+		d.focusInfo = !d.focusInfo && d.pane == infoPane
+		d.mu.Unlock()
+	case "i", keyEsc:
+		d.closeLogs()
+		d.mu.Lock()
+		d.pane = infoPane
+		d.mu.Unlock()
+	case "l":
+		d.mu.Lock()
+		d.pane = logsPane
+		d.focusInfo = false
+		d.mu.Unlock()
+		d.followLogs(ctx)
+	case "s":
+		opts := stackOptions()
+		d.runAction("starting", func(service string) { runService(service, d.basePath, opts) })
+	case "d":
+		opts := stackOptions()
+		d.runAction("stopping", func(service string) { stopService(service, d.basePath, false, opts) })
+	case "r":
+		d.runAction("restarting", func(service string) { restartService(service, d.basePath, "") })
+	case "c":
+		d.copyInfo()
+	}
+	return true
+}
+
+// move cambia el servicio seleccionado o, con el foco en el panel de
+// información, la línea seleccionada
+func (d *dashboard) move(ctx context.Context, delta int) {
+	d.mu.Lock()
+	if d.focusInfo {
+		d.infoCursor = clamp(d.infoCursor+delta, 0, len(d.infoLines)-1)
+		d.mu.Unlock()
+		return
+	}
+	d.selected = clamp(d.selected+delta, 0, len(d.services)-1)
+	pane := d.pane
+	d.mu.Unlock()
+
+	d.loadInfo()
+	if pane == logsPane {
+		d.followLogs(ctx)
+	}
+}
+
+func (d *dashboard) selectedService() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.services) == 0 {
+		return ""
+	}
+	return d.services[d.selected]
+}
+
+// runAction ejecuta una operación de docker-compose sobre el servicio
+// seleccionado sin bloquear el panel
+func (d *dashboard) runAction(action string, fn func(service string)) {
+	service := d.selectedService()
+	if service == "" {
+		return
+	}
+
+	d.mu.Lock()
+	if d.busy[service] != "" {
+		d.mu.Unlock()
+		return
+	}
+	d.busy[service] = action
+	d.mu.Unlock()
+
+	go func() {
+		fn(service)
+
+		d.mu.Lock()
+		delete(d.busy, service)
+		d.mu.Unlock()
+		d.refreshStatus(context.Background())
+	}()
+	d.requestRedraw()
+}
+
+// refreshStatus consulta el estado de todos los servicios
+func (d *dashboard) refreshStatus(ctx context.Context) {
+	statuses, err := collectStatus(ctx, d.client, d.basePath, d.services)
+
+	d.mu.Lock()
+	if err != nil {
+		d.statusError = err.Error()
+	} else {
+		d.statusError = ""
+		for _, status := range statuses {
+			d.statuses[status.Service] = status
+		}
+	}
+	d.mu.Unlock()
+	d.requestRedraw()
+}
+
+// loadInfo genera la información de conexión del servicio seleccionado con
+// las mismas funciones que 'infracli info'
+func (d *dashboard) loadInfo() {
+	service := d.selectedService()
+
+	d.mu.Lock()
+	loaded := service == d.infoService
+	d.mu.Unlock()
+	if loaded || service == "" {
+		return
+	}
+
+	var buf bytes.Buffer
+	content, err := os.ReadFile(filepath.Join(d.basePath, service, "docker-compose.yml"))
+	if err != nil {
+		fmt.Fprintf(&buf, "Error reading docker-compose.yml: %v\n", err)
+	} else {
+		// El panel muestra las contraseñas reales para poder copiarlas
+		values, err := stack.ServiceSecrets(service, stack.Options{})
+		if err != nil {
+			logger.Warnf("Warning: showing the passwords of the docker-compose.yml of %s: %v", service, err)
+		}
+		displayServiceInfo(&buf, service, string(content), values, false)
+	}
+
+	d.mu.Lock()
+	d.infoService = service
+	d.infoLines = strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	d.infoCursor = 0
+	d.mu.Unlock()
+}
+
+// copyInfo copia al portapapeles el valor de la línea seleccionada del panel
+// de información o, sin foco en el panel, toda la información
+func (d *dashboard) copyInfo() {
+	d.mu.Lock()
+	if len(d.infoLines) == 0 {
+		d.mu.Unlock()
+		return
+	}
+	text := strings.Join(d.infoLines, "\n") + "\n"
+	what := "connection information of " + d.infoService
+	if d.focusInfo {
+		text = infoLineValue(d.infoLines[d.infoCursor])
+		what = text
+	}
+	d.mu.Unlock()
+
+	if text == "" {
+		return
+	}
+	if err := copyToClipboard(text); err != nil {
+		logger.Errorf("Error copying to the clipboard: %v", err)
+		return
+	}
+	logger.Infof("Copied %s to the clipboard", what)
+}
+
+// infoLineValue devuelve el valor de una línea "Etiqueta: valor" de la
+// información de conexión, o la línea entera si no tiene etiqueta
+func infoLineValue(line string) string {
+	line = strings.TrimPrefix(strings.TrimSpace(line), "- ")
+	if _, value, found := strings.Cut(line, ": "); found {
+		return strings.TrimSpace(value)
+	}
+	return line
+}
+
+// followLogs sigue los logs de los contenedores del servicio seleccionado
+func (d *dashboard) followLogs(ctx context.Context) {
+	service := d.selectedService()
+	if service == "" || service == d.logService {
+		return
+	}
+	d.closeLogs()
+	d.logs.Reset()
+	d.logService = service
+
+	ctx, cancel := context.WithCancel(ctx)
+	d.stopLogs = cancel
+
+	go func() {
+		containers, err := d.client.ServiceContainers(ctx, filepath.Join(d.basePath, service))
+		if err != nil {
+			fmt.Fprintf(d.logs, "Error listing containers: %v\n", err)
+			return
+		}
+		if len(containers) == 0 {
+			fmt.Fprintf(d.logs, "No containers found for %s; press s to start it\n", service)
+			return
+		}
+
+		var mu sync.Mutex
+		opts := engine.LogsOptions{Follow: true, Tail: 100}
+		for _, container := range containers {
+			go func(container engine.Container) {
+				out := &prefixWriter{mu: &mu, out: d.logs}
+				if len(containers) > 1 {
+					out.prefix = container.Name() + " | "
+				}
+				if err := copyContainerLogs(ctx, d.client, container, opts, out, out); err != nil && ctx.Err() == nil {
+					fmt.Fprintf(d.logs, "Error reading logs of %s: %v\n", container.Name(), err)
+				}
+			}(container)
+		}
+	}()
+}
+
+func (d *dashboard) closeLogs() {
+	if d.stopLogs != nil {
+		d.stopLogs()
+		d.stopLogs = nil
+	}
+	d.logService = ""
+}
+
+func (d *dashboard) requestRedraw() {
+	select {
+	case d.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// render dibuja la pantalla completa: la lista de servicios a la izquierda,
+// la información o los logs a la derecha, y mensajes y ayuda abajo
+func (d *dashboard) render(width, height int) {
+	if width < 40 || height < 8 {
+		fmt.Print("\x1b[H\x1b[2JTerminal too small")
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Marcador, nombre, estado y salud: 2 + nombre + 1 + 14 + 1 + 9
+	nameWidth := 12
+	for _, service := range d.services {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(service))
+	}
+	listWidth := min(nameWidth+27, width/2)
+	paneWidth := width - listWidth - 3
+	bodyHeight := height - 4
+
+	left := make([]string, bodyHeight)
+	left[0] = ansiBold + "  " + pad("SERVICE", max(4, listWidth-27)) + " " + pad("STATE", 14) + " " + pad("HEALTH", 9) + ansiReset
+	for i, service := range d.services {
+		if i+1 >= bodyHeight {
+			break
+		}
+		left[i+1] = d.serviceLine(i, service, listWidth)
+	}
+
+	right := make([]string, bodyHeight)
+	service := ""
+	if len(d.services) > 0 {
+		service = d.services[d.selected]
+	}
+	switch d.pane {
+	case infoPane:
+		right[0] = ansiBold + pad("Info: "+service, paneWidth) + ansiReset
+		for i, line := range d.infoLines {
+			if i+1 >= bodyHeight {
+				break
+			}
+			text := pad(line, paneWidth)
+			if d.focusInfo && i == d.infoCursor {
+				text = ansiReverse + text + ansiReset
+			}
+			right[i+1] = text
+		}
+	case logsPane:
+		right[0] = ansiBold + pad("Logs: "+service, paneWidth) + ansiReset
+		lines := d.logs.Lines()
+		if len(lines) > bodyHeight-1 {
+			lines = lines[len(lines)-(bodyHeight-1):]
+		}
+		for i, line := range lines {
+			right[i+1] = pad(line, paneWidth)
+		}
+	}
+
+	var frame strings.Builder
+	frame.WriteString("\x1b[H")
+	frame.WriteString(ansiReverse + pad(" infracli  "+d.basePath, width) + ansiReset + "\x1b[K\r\n")
+	frame.WriteString("\x1b[K\r\n")
+	for i := 0; i < bodyHeight; i++ {
+		l := left[i]
+		if l == "" {
+			l = strings.Repeat(" ", listWidth)
+		}
+		frame.WriteString(l + " " + ansiDim + "│" + ansiReset + " " + right[i] + "\x1b[K\r\n")
+	}
+
+	message := d.messages.Last()
+	if d.statusError != "" {
+		message = "Error getting service status: " + d.statusError
+	}
+	frame.WriteString(pad(message, width) + "\x1b[K\r\n")
+	frame.WriteString(ansiDim + pad(uiHelp, width) + ansiReset + "\x1b[K")
+	fmt.Print(frame.String())
+}
+
+// serviceLine devuelve la línea de la lista de servicios para el servicio i
+func (d *dashboard) serviceLine(i int, service string, width int) string {
+	state, health := "stopped", "-"
+	color := ansiDim
+
+	if action := d.busy[service]; action != "" {
+		state, color = action+"...", ansiYellow
+	} else if status, ok := d.statuses[service]; ok && len(status.Containers) > 0 {
+		running := 0
+		health = "none"
+		for _, container := range status.Containers {
+			if container.State == "running" {
+				running++
+			}
+			health = worseHealth(health, container.Health)
+		}
+		state = fmt.Sprintf("running %d/%d", running, len(status.Containers))
+		switch {
+		case running == 0:
+			state, color = "exited", ansiRed
+		case running < len(status.Containers) || health == "unhealthy":
+			color = ansiYellow
+		default:
+			color = ansiGreen
+		}
+	}
+
+	marker := "  "
+	if i == d.selected {
+		marker = "› "
+	}
+	line := marker + pad(service, max(4, width-27)) + " " + color + pad(state, 14) + ansiReset + " " + pad(health, 9)
+	if i == d.selected && !d.focusInfo {
+		return ansiBold + line + ansiReset
+	}
+	return line
+}
+
+// worseHealth devuelve el peor de dos estados de healthcheck
+func worseHealth(a, b string) string {
+	rank := map[string]int{"none": 0, "healthy": 1, "starting": 2, "unhealthy": 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// pad ajusta text a exactamente width caracteres, recortando o rellenando
+func pad(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(text)
+	if n > width {
+		runes := []rune(text)
+		return string(runes[:width-1]) + "…"
+	}
+	return text + strings.Repeat(" ", width-n)
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
+
+// lineBuffer guarda las últimas líneas escritas en él, sin caracteres de
+// control para que no alteren la pantalla
+type lineBuffer struct {
+	mu       sync.Mutex
+	lines    []string
+	partial  []byte
+	limit    int
+	onChange func()
+}
+
+func newLineBuffer(limit int, onChange func()) *lineBuffer {
+	return &lineBuffer{limit: limit, onChange: onChange}
+}
+
+func (b *lineBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	b.partial = append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			break
+		}
+		b.lines = append(b.lines, sanitizeLine(string(b.partial[:i])))
+		b.partial = b.partial[i+1:]
+	}
+	if len(b.lines) > b.limit {
+		b.lines = append([]string(nil), b.lines[len(b.lines)-b.limit:]...)
+	}
+	b.mu.Unlock()
+
+	if b.onChange != nil {
+		b.onChange()
+	}
+	return len(p), nil
+}
+
+// Lines devuelve una copia de las líneas guardadas
+func (b *lineBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.lines...)
+}
+
+// Last devuelve la última línea guardada
+func (b *lineBuffer) Last() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.lines) == 0 {
+		return ""
+	}
+	return b.lines[len(b.lines)-1]
+}
+
+// Reset descarta las líneas guardadas
+func (b *lineBuffer) Reset() {
+	b.mu.Lock()
+	b.lines = nil
+	b.partial = nil
+	b.mu.Unlock()
+}
+
+// sanitizeLine cambia los tabuladores por espacios y elimina los demás
+// caracteres de control y las secuencias de escape ANSI
+func sanitizeLine(line string) string {
+	var out strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\t':
+			out.WriteString("    ")
+		case c == 0x1b:
+			// Saltar la secuencia CSI completa, por ejemplo \x1b[31m
+			if i+1 < len(line) && line[i+1] == '[' {
+				i += 2
+				for i < len(line) && (line[i] < 0x40 || line[i] > 0x7e) {
+					i++
+				}
+			}
+		case c < 0x20 || c == 0x7f:
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+func init() {
+	RootCmd.AddCommand(uiCmd)
+}
@@ -0,0 +1,783 @@
+// Package compose contains simple helpers to read the docker-compose.yml files
+// of the services without using a YAML parser.
+package compose
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FileName is the compose file every service directory contains
+const FileName = "docker-compose.yml"
+
+// Read returns the content of the compose file in servicePath
+func Read(servicePath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(servicePath, FileName))
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", FileName, err)
+	}
+	return string(data), nil
+}
+
+// Ports returns the "host:container" port mappings of the service that starts
+// with servicePrefix, e.g. "  mysql:"
+func Ports(content string, servicePrefix string) []string {
+	var ports []string
+	inService := false
+	inPorts := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	re := regexp.MustCompile(`^\s*-\s*"?([^:]+):([^"]+)"?`)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Check if we're entering a service section
+		if strings.HasPrefix(line, servicePrefix) {
+			inService = true
+			continue
+		}
+
+		// If we've moved to a different section, stop processing
+		if inService && len(line) > 0 && !strings.HasPrefix(line, " ") {
+			inService = false
+			inPorts = false
+			continue
+		}
+
+		// Check if we're in the ports section of the current service
+		if inService && strings.TrimSpace(line) == "ports:" {
+			inPorts = true
+			continue
+		}
+
+		// If we're in the ports section, extract port mappings
+		if inService && inPorts {
+			// If we've moved to a different subsection, stop processing ports
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "-") {
+				inPorts = false
+				continue
+			}
+
+			// Extract port mappings
+			matches := re.FindStringSubmatch(line)
+			if len(matches) >= 3 {
+				hostPort := strings.TrimSpace(matches[1])
+				containerPort := strings.TrimSpace(matches[2])
+				ports = append(ports, fmt.Sprintf("%s:%s", hostPort, containerPort))
+			}
+		}
+	}
+
+	return ports
+}
+
+// Networks returns the networks the service that starts with servicePrefix
+// joins, in either map or list form. It is empty when the service only uses
+// the default network of the project.
+func Networks(content string, servicePrefix string) []string {
+	var networks []string
+	inService := false
+	networksIndent := -1
+	itemIndent := -1
+
+	reItem := regexp.MustCompile(`^\s*(?:-\s*)?["']?([^"':\s]+)["']?\s*:?`)
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		// Check if we're entering a service section
+		if strings.HasPrefix(line, servicePrefix) {
+			inService = true
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// The service ends at the next service or top-level key
+		if inService && indent <= 2 {
+			inService = false
+			networksIndent = -1
+			continue
+		}
+		if !inService {
+			continue
+		}
+
+		if trimmed == "networks:" {
+			networksIndent = indent
+			itemIndent = -1
+			continue
+		}
+		if networksIndent < 0 {
+			continue
+		}
+
+		// Another key of the service ends the networks section
+		if indent <= networksIndent {
+			networksIndent = -1
+			continue
+		}
+		// Only the first level holds network names; deeper lines are their options
+		if itemIndent < 0 {
+			itemIndent = indent
+		}
+		if indent != itemIndent {
+			continue
+		}
+		if matches := reItem.FindStringSubmatch(line); matches != nil {
+			networks = append(networks, matches[1])
+		}
+	}
+
+	return networks
+}
+
+// MetadataKey is the top-level extension field that holds infracli metadata
+// about a service. Docker Compose ignores keys that start with "x-".
+const MetadataKey = "x-infracli"
+
+// Aliases returns the alternative names of the service declared in the
+// metadata section, in either list or inline form:
+//
+//	x-infracli:
+//	  aliases: [postgresql, pg]
+func Aliases(content string) []string {
+	var aliases []string
+	inMetadata := false
+	inAliases := false
+
+	reInline := regexp.MustCompile(`^\s*aliases:\s*\[(.*)\]\s*$`)
+	reItem := regexp.MustCompile(`^\s*-\s*["']?([^"'#]+?)["']?\s*$`)
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// The metadata section ends at the next top-level key
+		if !strings.HasPrefix(line, " ") {
+			inMetadata = trimmed == MetadataKey+":"
+			inAliases = false
+			continue
+		}
+		if !inMetadata {
+			continue
+		}
+
+		if matches := reInline.FindStringSubmatch(line); matches != nil {
+			for _, alias := range strings.Split(matches[1], ",") {
+				if alias = strings.Trim(strings.TrimSpace(alias), `"'`); alias != "" {
+					aliases = append(aliases, alias)
+				}
+			}
+			inAliases = false
+			continue
+		}
+		if trimmed == "aliases:" {
+			inAliases = true
+			continue
+		}
+
+		if inAliases {
+			if matches := reItem.FindStringSubmatch(line); matches != nil {
+				aliases = append(aliases, matches[1])
+				continue
+			}
+			// Another key of the metadata ends the list
+			inAliases = false
+		}
+	}
+
+	return aliases
+}
+
+// Hook is a command declared in the metadata section to run around a
+// lifecycle event of the service. Fields hold the raw values of the file.
+type Hook struct {
+	Event string
+	// Command runs on the host; Exec runs inside Container instead
+	Command   string
+	Exec      string
+	Container string
+	Timeout   string
+	OnFailure string
+}
+
+// Hooks returns the hooks declared in the metadata section, in file order.
+// An item is either a command or a map with its options:
+//
+//	x-infracli:
+//	  hooks:
+//	    postRun:
+//	      - ./scripts/migrate.sh
+//	    preDown:
+//	      - exec: pg_dump -U postgres postgres > /tmp/backup.sql
+//	        container: db
+//	        timeout: 5m
+//	        onFailure: continue
+func Hooks(content string) []Hook {
+	var hooks []Hook
+	inMetadata := false
+	hooksIndent := -1
+	eventIndent := -1
+	event := ""
+	var current *Hook
+
+	reKey := regexp.MustCompile(`^\s*(-\s+)?([A-Za-z]+):\s*(.*)$`)
+	reItem := regexp.MustCompile(`^\s*-\s+(.+)$`)
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// The metadata section ends at the next top-level key
+		if indent == 0 {
+			inMetadata = trimmed == MetadataKey+":"
+			hooksIndent = -1
+			continue
+		}
+		if !inMetadata {
+			continue
+		}
+
+		if hooksIndent < 0 {
+			if trimmed == "hooks:" {
+				hooksIndent = indent
+				eventIndent = -1
+				event = ""
+			}
+			continue
+		}
+		// Another key of the metadata ends the hooks
+		if indent <= hooksIndent {
+			hooksIndent = -1
+			continue
+		}
+
+		// The first level holds the events
+		if eventIndent < 0 {
+			eventIndent = indent
+		}
+		if indent == eventIndent {
+			event = strings.TrimSuffix(trimmed, ":")
+			current = nil
+			continue
+		}
+		if event == "" {
+			continue
+		}
+
+		matches := reKey.FindStringSubmatch(line)
+		if matches == nil {
+			// A plain item is a command to run on the host
+			if item := reItem.FindStringSubmatch(line); item != nil {
+				hooks = append(hooks, Hook{Event: event, Command: unquote(item[1])})
+				current = nil
+			}
+			continue
+		}
+		if matches[1] != "" {
+			hooks = append(hooks, Hook{Event: event})
+			current = &hooks[len(hooks)-1]
+		}
+		if current == nil {
+			continue
+		}
+
+		value := unquote(matches[3])
+		switch matches[2] {
+		case "command":
+			current.Command = value
+		case "exec":
+			current.Exec = value
+		case "container":
+			current.Container = value
+		case "timeout":
+			current.Timeout = value
+		case "onFailure":
+			current.OnFailure = value
+		}
+	}
+
+	return hooks
+}
+
+// unquote removes the quotes around a YAML scalar
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Environment returns the environment variables of the service that starts
+// with servicePrefix, in either map or list form
+func Environment(content string, servicePrefix string) map[string]string {
+	env := make(map[string]string)
+	inService := false
+	inEnvironment := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	reKeyValue := regexp.MustCompile(`^\s*([^:]+):\s*(.+)$`)
+	reEnvVar := regexp.MustCompile(`^\s*-\s*([^=]+)=(.+)$`)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Check if we're entering a service section
+		if strings.HasPrefix(line, servicePrefix) {
+			inService = true
+			continue
+		}
+
+		// If we've moved to a different section, stop processing
+		if inService && len(line) > 0 && !strings.HasPrefix(line, " ") {
+			inService = false
+			inEnvironment = false
+			continue
+		}
+
+		// Check if we're in the environment section of the current service
+		if inService && strings.TrimSpace(line) == "environment:" {
+			inEnvironment = true
+			continue
+		}
+
+		// If we're in the environment section, extract variables
+		if inService && inEnvironment {
+			// If we've moved to a different subsection, stop processing environment
+			if len(line) > 0 && strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "    ") && !strings.Contains(line, ":") && !strings.Contains(line, "-") {
+				inEnvironment = false
+				continue
+			}
+
+			// Try to match key-value format
+			matches := reKeyValue.FindStringSubmatch(line)
+			if len(matches) >= 3 {
+				key := strings.TrimSpace(matches[1])
+				value := strings.TrimSpace(matches[2])
+				env[key] = value
+				continue
+			}
+
+			// Try to match array format with environment variables
+			matches = reEnvVar.FindStringSubmatch(line)
+			if len(matches) >= 3 {
+				key := strings.TrimSpace(matches[1])
+				value := strings.TrimSpace(matches[2])
+				// Remove quotes if present
+				value = strings.Trim(value, "\"'")
+				parts := strings.SplitN(key, "=", 2)
+				if len(parts) == 2 {
+					env[parts[0]] = parts[1]
+				} else {
+					env[key] = value
+				}
+			}
+		}
+	}
+
+	return env
+}
+
+// Images returns the image of each compose service
+func Images(content string) map[string]string {
+	services := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	inServices := false
+	currentService := ""
+
+	reService := regexp.MustCompile(`^(\s*)([^:]+):$`)
+	reImage := regexp.MustCompile(`^\s*image:\s*(.+)$`)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Check if we're in the services section
+		if strings.TrimSpace(line) == "services:" {
+			inServices = true
+			continue
+		}
+
+		// If we've exited the services section
+		if inServices && len(line) > 0 && !strings.HasPrefix(line, " ") && line != "services:" {
+			inServices = false
+			continue
+		}
+
+		if inServices {
+			// Try to match service name
+			serviceMatches := reService.FindStringSubmatch(line)
+			if len(serviceMatches) >= 3 && len(serviceMatches[1]) == 2 { // Check indent level to ensure it's a service
+				currentService = strings.TrimSpace(serviceMatches[2])
+				continue
+			}
+
+			// Try to match image definition
+			if currentService != "" {
+				imageMatches := reImage.FindStringSubmatch(line)
+				if len(imageMatches) >= 2 {
+					services[currentService] = strings.TrimSpace(imageMatches[1])
+				}
+			}
+		}
+	}
+
+	return services
+}
+
+// ContainerNames returns the container_name of each compose service that defines one
+func ContainerNames(content string) map[string]string {
+	names := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	inServices := false
+	currentService := ""
+
+	reService := regexp.MustCompile(`^(\s*)([^:]+):$`)
+	reContainerName := regexp.MustCompile(`^\s*container_name:\s*["']?([^"']+)["']?\s*$`)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Check if we're in the services section
+		if strings.TrimSpace(line) == "services:" {
+			inServices = true
+			continue
+		}
+
+		// If we've exited the services section
+		if inServices && len(line) > 0 && !strings.HasPrefix(line, " ") {
+			inServices = false
+			continue
+		}
+
+		if inServices {
+			// Try to match service name
+			serviceMatches := reService.FindStringSubmatch(line)
+			if len(serviceMatches) >= 3 && len(serviceMatches[1]) == 2 {
+				currentService = strings.TrimSpace(serviceMatches[2])
+				continue
+			}
+
+			// Try to match the container name
+			if currentService != "" {
+				nameMatches := reContainerName.FindStringSubmatch(line)
+				if len(nameMatches) >= 2 {
+					names[currentService] = strings.TrimSpace(nameMatches[1])
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// Rewrite returns content with the container_name and the published host
+// ports of every compose service replaced. containerName receives the compose
+// service and its current container_name; hostPort receives the compose
+// service, the published port as written (a number or a variable such as
+// ${PORT:-3306}) and the container port of each mapping, in both the short
+// and the long syntax. Lines that are not container names or port mappings
+// are kept as they are. A published port range cannot be replaced by a single
+// port and is an error, since keeping it would clash with the service.
+func Rewrite(content string, containerName func(service, name string) string, hostPort func(service, host, target string) string) (string, error) {
+	reService := regexp.MustCompile(`^  ([^\s:#][^:]*):\s*$`)
+	reContainerName := regexp.MustCompile(`^(\s+container_name:\s*)["']?([^"']+?)["']?\s*$`)
+	// - "3306:3306", - 127.0.0.1:8080:80/tcp, - ${PORT:-3306}:3306
+	reShortPort := regexp.MustCompile(`^(\s*-\s*)(["']?)([^"'\s#]+)(["']?)(\s*(?:#.*)?)$`)
+	// - target: 80, published: "8080"
+	rePortKey := regexp.MustCompile(`^(\s*(?:-\s+)?)([a-z_]+):(\s*)(["']?)([^"'\s#]*)(["']?)(\s*(?:#.*)?)$`)
+
+	lines := strings.Split(content, "\n")
+	inServices := false
+	portsIndent := -1
+	currentService := ""
+
+	// A long syntax mapping is rewritten once all its keys are known, since
+	// target may come after published
+	var long *longPort
+	flush := func() error {
+		mapping := long
+		long = nil
+		if mapping == nil || mapping.line < 0 {
+			return nil
+		}
+		host, err := publishedPort(currentService, mapping.published, mapping.target, hostPort)
+		if err != nil {
+			return err
+		}
+		m := mapping.matches
+		lines[mapping.line] = m[1] + m[2] + ":" + m[3] + m[4] + host + m[6] + m[7]
+		return nil
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// The ports list ends at the next key of the service
+		if portsIndent >= 0 && indent <= portsIndent {
+			if err := flush(); err != nil {
+				return "", err
+			}
+			portsIndent = -1
+		}
+
+		if trimmed == "services:" && indent == 0 {
+			inServices = true
+			continue
+		}
+		if indent == 0 {
+			inServices = false
+			continue
+		}
+		if !inServices {
+			continue
+		}
+
+		if matches := reService.FindStringSubmatch(line); matches != nil {
+			currentService = strings.TrimSpace(matches[1])
+			continue
+		}
+		if currentService == "" {
+			continue
+		}
+
+		if trimmed == "ports:" {
+			portsIndent = indent
+			continue
+		}
+
+		if portsIndent < 0 {
+			if matches := reContainerName.FindStringSubmatch(line); matches != nil {
+				lines[i] = matches[1] + containerName(currentService, matches[2])
+			}
+			continue
+		}
+
+		if matches := reShortPort.FindStringSubmatch(line); matches != nil {
+			if err := flush(); err != nil {
+				return "", err
+			}
+			parts := splitPort(matches[3])
+			// Without a published port Docker picks a free one
+			if len(parts) < 2 || parts[len(parts)-2] == "" {
+				continue
+			}
+			target := parts[len(parts)-1]
+			host, err := publishedPort(currentService, parts[len(parts)-2], strings.Split(target, "/")[0], hostPort)
+			if err != nil {
+				return "", err
+			}
+			parts[len(parts)-2] = host
+			lines[i] = matches[1] + matches[2] + strings.Join(parts, ":") + matches[4] + matches[5]
+			continue
+		}
+
+		if matches := rePortKey.FindStringSubmatch(line); matches != nil {
+			if strings.Contains(matches[1], "-") {
+				if err := flush(); err != nil {
+					return "", err
+				}
+			}
+			if long == nil {
+				long = &longPort{line: -1}
+			}
+			switch matches[2] {
+			case "published":
+				long.line = i
+				long.published = matches[5]
+				long.matches = matches
+			case "target":
+				long.target = matches[5]
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// longPort is a port mapping in the long syntax while it is being read
+type longPort struct {
+	// line is the index of the published key, or -1 if there is none yet
+	line      int
+	published string
+	target    string
+	matches   []string
+}
+
+var (
+	rePortNumber   = regexp.MustCompile(`^\d+$`)
+	rePortVariable = regexp.MustCompile(`^\$(\{[^}]+\}|[A-Za-z_][A-Za-z0-9_]*)$`)
+	rePortRange    = regexp.MustCompile(`^\d+-\d+$`)
+)
+
+// publishedPort returns the host port that replaces a published port
+func publishedPort(service, host, target string, hostPort func(service, host, target string) string) (string, error) {
+	switch {
+	case rePortNumber.MatchString(host) || rePortVariable.MatchString(host):
+		return hostPort(service, host, target), nil
+	case rePortRange.MatchString(host):
+		return "", fmt.Errorf("the port range %s of %s cannot be moved to free ports; publish single ports instead", host, service)
+	default:
+		return "", fmt.Errorf("unsupported published port %q in %s", host, service)
+	}
+}
+
+// splitPort splits a short syntax port mapping at the colons that are not
+// part of a variable such as ${PORT:-3306} or of an IPv6 address in brackets
+func splitPort(mapping string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, c := range mapping {
+		switch c {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				parts = append(parts, mapping[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, mapping[start:])
+}
+
+// SetEnvironment returns content with the value of every environment variable
+// named in values replaced, in all the services that declare it. Values are
+// written as they are, the same way Environment reads them back, so the result
+// is meant to be inspected with this package rather than passed to docker-compose.
+func SetEnvironment(content string, values map[string]string) string {
+	reKeyValue := regexp.MustCompile(`^(\s+)([A-Za-z_][A-Za-z0-9_]*):(\s*).*$`)
+	reEnvVar := regexp.MustCompile(`^(\s*-\s*["']?)([A-Za-z_][A-Za-z0-9_]*)=[^"']*(["']?)\s*$`)
+
+	lines := strings.Split(content, "\n")
+	envIndent := -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if trimmed == "environment:" {
+			envIndent = indent
+			continue
+		}
+		// The environment section ends at the next key of the service
+		if envIndent < 0 || trimmed == "" {
+			continue
+		}
+		if indent <= envIndent {
+			envIndent = -1
+			continue
+		}
+
+		if matches := reEnvVar.FindStringSubmatch(line); matches != nil {
+			if value, ok := values[matches[2]]; ok {
+				lines[i] = matches[1] + matches[2] + "=" + value + matches[3]
+			}
+			continue
+		}
+		if matches := reKeyValue.FindStringSubmatch(line); matches != nil {
+			if value, ok := values[matches[2]]; ok {
+				lines[i] = matches[1] + matches[2] + ": " + value
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// knownContainers maps each service infracli knows to the text that identifies
+// its main compose service by name or image
+var knownContainers = map[string]string{
+	"mysql":                "mysql",
+	"postgres":             "postgres",
+	"mongo":                "mongo",
+	"redis":                "redis",
+	"elasticsearch-kibana": "elastic",
+	"neo4j":                "neo4j",
+}
+
+// FindContainer returns the first compose service, in name order, whose name
+// or image contains match
+func FindContainer(content, match string) string {
+	images := Images(content)
+	names := make([]string, 0, len(images))
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if strings.Contains(strings.ToLower(images[name]), match) || strings.Contains(strings.ToLower(name), match) {
+			return name
+		}
+	}
+	return ""
+}
+
+// PrimaryContainer returns the main compose service of an infracli service.
+// Unknown services only have one when they define a single compose service or
+// one named after them.
+func PrimaryContainer(service, content string) string {
+	if match, ok := knownContainers[service]; ok {
+		return FindContainer(content, match)
+	}
+	images := Images(content)
+	if len(images) == 1 {
+		for container := range images {
+			return container
+		}
+	}
+	return FindContainer(content, service)
+}
+
+// NetworkAliases returns the DNS names of each compose service of an infracli
+// service in a shared network: the infracli service name for its primary
+// container, the container_name and the compose service name
+func NetworkAliases(service, content string) map[string][]string {
+	names := ContainerNames(content)
+	primary := PrimaryContainer(service, content)
+
+	aliases := make(map[string][]string)
+	for container := range Images(content) {
+		var candidates []string
+		if container == primary {
+			candidates = append(candidates, service)
+		}
+		candidates = append(candidates, names[container], container)
+
+		seen := make(map[string]bool)
+		for _, alias := range candidates {
+			if alias != "" && !seen[alias] {
+				seen[alias] = true
+				aliases[container] = append(aliases[container], alias)
+			}
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,162 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"short syntax", `services:
+  db:
+    image: mysql:8
+    container_name: mysql
+    ports:
+      - "3306:3306"
+      - 127.0.0.1:33060:33060/tcp
+      - '8080:80' # admin
+`, `services:
+  db:
+    image: mysql:8
+    container_name: mysql-test
+    ports:
+      - "db-3306-3306:3306"
+      - 127.0.0.1:db-33060-33060:33060/tcp
+      - 'db-8080-80:80' # admin
+`},
+		{"variables", `services:
+  db:
+    ports:
+      - "${MYSQL_PORT:-3306}:3306"
+      - $ADMIN_PORT:80
+      - "[::1]:${DEBUG_PORT}:9229"
+`, `services:
+  db:
+    ports:
+      - "db-${MYSQL_PORT:-3306}-3306:3306"
+      - db-$ADMIN_PORT-80:80
+      - "[::1]:db-${DEBUG_PORT}-9229:9229"
+`},
+		{"without a published port", `services:
+  db:
+    ports:
+      - "3306"
+      - 8000-8010
+      - "127.0.0.1::5432"
+`, `services:
+  db:
+    ports:
+      - "3306"
+      - 8000-8010
+      - "127.0.0.1::5432"
+`},
+		{"long syntax", `services:
+  web:
+    ports:
+      - target: 80
+        published: 8080
+        protocol: tcp
+      - published: "${HTTPS_PORT:-8443}"
+        host_ip: 127.0.0.1
+        target: 443
+      - target: 9000
+    environment:
+      PORT: "8080"
+`, `services:
+  web:
+    ports:
+      - target: 80
+        published: web-8080-80
+        protocol: tcp
+      - published: "web-${HTTPS_PORT:-8443}-443"
+        host_ip: 127.0.0.1
+        target: 443
+      - target: 9000
+    environment:
+      PORT: "8080"
+`},
+		{"several services", `services:
+  api:
+    container_name: api
+    ports:
+      - "8080:8080"
+  worker:
+    container_name: "worker"
+    expose:
+      - "9000"
+volumes:
+  data:
+`, `services:
+  api:
+    container_name: api-test
+    ports:
+      - "api-8080-8080:8080"
+  worker:
+    container_name: worker-test
+    expose:
+      - "9000"
+volumes:
+  data:
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Rewrite(tt.content,
+				func(service, name string) string { return name + "-test" },
+				func(service, host, target string) string { return service + "-" + host + "-" + target },
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   string
+		wantErr string
+	}{
+		{"short range", `      - "8000-8010:8000-8010"`, "port range 8000-8010 of web"},
+		{"long range", "      - target: 8000-8010\n        published: 8000-8010", "port range 8000-8010 of web"},
+		{"unsupported", `      - "${PORT}0:80"`, `unsupported published port "${PORT}0" in web`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "services:\n  web:\n    ports:\n" + tt.ports + "\n"
+			_, err := Rewrite(content,
+				func(service, name string) string { return name },
+				func(service, host, target string) string { return "1" },
+			)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSplitPort(t *testing.T) {
+	tests := map[string][]string{
+		"3306":                     {"3306"},
+		"3306:3306":                {"3306", "3306"},
+		"127.0.0.1:8080:80/udp":    {"127.0.0.1", "8080", "80/udp"},
+		"${PORT:-3306}:3306":       {"${PORT:-3306}", "3306"},
+		"[::1]:${PORT:-5432}:5432": {"[::1]", "${PORT:-5432}", "5432"},
+	}
+	for mapping, want := range tests {
+		got := splitPort(mapping)
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("splitPort(%q) = %q, want %q", mapping, got, want)
+		}
+	}
+}
@@ -5,24 +5,166 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
 	// ConfigFileName es el nombre del archivo de configuración
 	ConfigFileName = "infracli.json"
-	// ConfigDirName es el nombre del directorio de configuración dentro de ~/.config
+	// ConfigDirName es el nombre del directorio de configuración dentro de $XDG_CONFIG_HOME o ~/.config
 	ConfigDirName = "infracli"
+	// ProjectConfigFileName es el archivo de configuración de proyecto que se busca
+	// desde el directorio actual hacia arriba
+	ProjectConfigFileName = ".infracli.json"
+	// ConfigFileEnv es la variable de entorno con la ruta de un archivo de configuración alternativo
+	ConfigFileEnv = "INFRACLI_CONFIG"
 )
 
 // Config contiene la configuración para la herramienta InfraCLI
 type Config struct {
-	ServicesPath string   `json:"servicesPath"`
-	ExcludedDirs []string `json:"excludedDirs"`
+	Version      int               `json:"version"`
+	ServicesPath string            `json:"servicesPath"`
+	ExcludedDirs []string          `json:"excludedDirs"`
+	AutoStop     AutoStopConfig    `json:"autoStop"`
+	Credentials  CredentialsConfig `json:"credentials"`
+	// ConnectionTemplates son cadenas de conexión propias de la forma
+	// "nombre=plantilla", que se muestran con "infracli info --format nombre"
+	ConnectionTemplates []string `json:"connectionTemplates,omitempty"`
+	// Aliases son nombres alternativos de servicios de la forma "alias=servicio",
+	// que se suman a los declarados en el docker-compose.yml de cada servicio
+	Aliases []string    `json:"aliases,omitempty"`
+	Hooks   HooksConfig `json:"hooks"`
+}
+
+// AutoStopConfig es la política para detener servicios inactivos con "infracli reap"
+type AutoStopConfig struct {
+	// After es el tiempo sin actividad tras el cual se detiene un servicio, por
+	// ejemplo "4h"; vacío desactiva la política
+	After string `json:"after,omitempty"`
+	// Services limita la política a estos servicios; vacío la aplica a todos
+	Services []string `json:"services,omitempty"`
+}
+
+// CredentialsConfig controla las credenciales aleatorias de los servicios
+type CredentialsConfig struct {
+	// Generate son los servicios cuyas contraseñas se generan al azar en su
+	// primer arranque; "all" lo activa para todos y vacío lo desactiva
+	Generate []string `json:"generate,omitempty"`
+}
+
+// HooksConfig son los comandos que se ejecutan alrededor del ciclo de vida de
+// los servicios, además de los declarados en su docker-compose.yml
+type HooksConfig struct {
+	// Commands son entradas "servicio.evento=comando", que se ejecutan en el
+	// host, o "servicio.evento@contenedor=comando", dentro del contenedor
+	Commands []string `json:"commands,omitempty"`
+	// Timeout es el tiempo máximo de cada comando que no indica otro
+	Timeout string `json:"timeout,omitempty"`
+	// OnFailure es "abort" o "continue" para los comandos que no indican otro
+	OnFailure string `json:"onFailure,omitempty"`
+	// TrustedPaths son directorios de servicios cuyos hooks x-infracli se
+	// ejecutan aunque servicesPath venga de un archivo de proyecto o del entorno
+	TrustedPaths []string `json:"trustedPaths,omitempty"`
+}
+
+// HookEvents son los eventos del ciclo de vida en los que se ejecutan hooks
+var HookEvents = []string{"preRun", "postRun", "onHealthy", "preDown", "postDown"}
+
+// HookEntry es una entrada de hooks.commands ya separada en sus partes
+type HookEntry struct {
+	Service   string
+	Event     string
+	Container string
+	Command   string
+}
+
+// ParseHookEntry separa una entrada "servicio.evento[@contenedor]=comando"
+func ParseHookEntry(entry string) (HookEntry, error) {
+	target, command, found := strings.Cut(entry, "=")
+	if !found || strings.TrimSpace(command) == "" {
+		return HookEntry{}, fmt.Errorf("hooks.commands entry %q must have the form service.event=command", entry)
+	}
+	target, container, _ := strings.Cut(strings.TrimSpace(target), "@")
+	service, event, found := strings.Cut(target, ".")
+	if !found || service == "" {
+		return HookEntry{}, fmt.Errorf("hooks.commands entry %q must have the form service.event=command", entry)
+	}
+	if !containsString(HookEvents, event) {
+		return HookEntry{}, fmt.Errorf("hooks.commands entry %q has unknown event %q (expected one of %s)", entry, event, strings.Join(HookEvents, ", "))
+	}
+	return HookEntry{Service: service, Event: event, Container: container, Command: strings.TrimSpace(command)}, nil
+}
+
+// GeneratesCredentials indica si las contraseñas del servicio se generan al azar
+func (c *Config) GeneratesCredentials(service string) bool {
+	return containsString(c.Credentials.Generate, service) || containsString(c.Credentials.Generate, "all")
+}
+
+// ConnectionTemplate devuelve la plantilla de conexión propia con ese nombre
+func (c *Config) ConnectionTemplate(name string) (string, bool) {
+	for _, entry := range c.ConnectionTemplates {
+		if key, text, found := strings.Cut(entry, "="); found && strings.TrimSpace(key) == name {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// ServiceAliases devuelve el servicio al que apunta cada alias de la configuración
+func (c *Config) ServiceAliases() map[string]string {
+	aliases := make(map[string]string)
+	for _, entry := range c.Aliases {
+		if alias, service, found := strings.Cut(entry, "="); found {
+			aliases[strings.TrimSpace(alias)] = strings.TrimSpace(service)
+		}
+	}
+	return aliases
+}
+
+// Origin indica de qué fuente proviene el valor de una clave
+type Origin string
+
+const (
+	OriginDefault     Origin = "default"
+	OriginUserFile    Origin = "user file"
+	OriginProjectFile Origin = "project file"
+	OriginEnv         Origin = "environment"
+	OriginFlag        Origin = "flag"
+)
+
+// Options controla de dónde se cargan los valores de configuración.
+// La precedencia es: flag > entorno > archivo de proyecto > archivo de usuario > valores por defecto.
+type Options struct {
+	// ConfigFile reemplaza al archivo de usuario (flag --config)
+	ConfigFile string
+	// Flags contiene los valores pasados por línea de comandos, indexados por clave
+	Flags map[string][]string
+	// LookupEnv permite sustituir os.LookupEnv, por ejemplo en pruebas
+	LookupEnv func(string) (string, bool)
+	// WorkingDir es el directorio desde el que se busca el archivo de proyecto
+	WorkingDir string
+}
+
+// Resolved es el resultado de combinar todas las fuentes de configuración
+type Resolved struct {
+	Config      *Config
+	UserFile    string
+	ProjectFile string
+	Origins     map[string]Origin
+}
+
+// options son las opciones usadas por LoadConfig, establecidas desde los flags globales
+var options Options
+
+// SetOptions establece las opciones usadas por LoadConfig y las funciones relacionadas
+func SetOptions(opts Options) {
+	options = opts
 }
 
 // GetDefaultConfig devuelve una configuración por defecto
 func GetDefaultConfig() *Config {
 	return &Config{
+		Version:      CurrentVersion,
 		ServicesPath: filepath.Join(os.Getenv("HOME"), "Development", "infrastructure", "services"),
 		ExcludedDirs: []string{"config", "scripts", "cmd"},
 	}
@@ -30,92 +172,360 @@ func GetDefaultConfig() *Config {
 
 // GetConfigDir devuelve la ruta del directorio de configuración
 func GetConfigDir() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	configPath, err := GetConfigFilePath()
 	if err != nil {
-		return "", fmt.Errorf("error getting home directory: %v", err)
+		return "", err
 	}
-	
-	// Ruta completa del directorio de configuración (~/.config/infracli)
-	configDir := filepath.Join(homeDir, ".config", ConfigDirName)
-	
-	return configDir, nil
+
+	return filepath.Dir(configPath), nil
 }
 
 // GetConfigFilePath devuelve la ruta completa del archivo de configuración
 func GetConfigFilePath() (string, error) {
-	configDir, err := GetConfigDir()
+	path, _, err := userConfigFile(options)
+	return path, err
+}
+
+// userConfigFile devuelve el archivo de usuario y si fue indicado explícitamente
+func userConfigFile(opts Options) (string, bool, error) {
+	if opts.ConfigFile != "" {
+		return opts.ConfigFile, true, nil
+	}
+	if path, ok := lookupEnv(opts, ConfigFileEnv); ok && path != "" {
+		return path, true, nil
+	}
+
+	// Respetar $XDG_CONFIG_HOME si está definido y es una ruta absoluta
+	if xdg, ok := lookupEnv(opts, "XDG_CONFIG_HOME"); ok && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, ConfigDirName, ConfigFileName), false, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return "", err
+		return "", false, fmt.Errorf("error getting home directory: %v", err)
 	}
-	
-	return filepath.Join(configDir, ConfigFileName), nil
+
+	// Ruta completa del archivo de configuración (~/.config/infracli/infracli.json)
+	return filepath.Join(homeDir, ".config", ConfigDirName, ConfigFileName), false, nil
 }
 
 // SaveConfig guarda la configuración en el archivo
 func SaveConfig(config *Config) error {
-	configDir, err := GetConfigDir()
+	configPath, err := GetConfigFilePath()
 	if err != nil {
 		return err
 	}
-	
+
+	return writeConfigFile(config, configPath)
+}
+
+// writeConfigFile serializa la configuración en la ruta indicada
+func writeConfigFile(config *Config, configPath string) error {
 	// Crear el directorio si no existe
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return fmt.Errorf("error creating config directory: %v", err)
 	}
-	
-	configPath, err := GetConfigFilePath()
-	if err != nil {
-		return err
-	}
-	
+
 	// Serializar la configuración a JSON con formato legible
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("error serializing config: %v", err)
 	}
-	
+
 	// Escribir en el archivo
 	if err := os.WriteFile(configPath, data, 0644); err != nil {
 		return fmt.Errorf("error writing config file: %v", err)
 	}
-	
+
 	return nil
 }
 
-// LoadConfig carga la configuración desde el archivo de configuración
+// LoadConfig carga la configuración efectiva combinando todas las fuentes
 func LoadConfig() (*Config, error) {
-	// Obtener la ruta del archivo de configuración
-	configPath, err := GetConfigFilePath()
+	resolved, err := Load(options)
 	if err != nil {
 		return nil, err
 	}
-	
+	return resolved.Config, nil
+}
+
+// LoadResolved carga la configuración efectiva junto con el origen de cada valor
+func LoadResolved() (*Resolved, error) {
+	return Load(options)
+}
+
+// LoadUserConfig carga solo los valores por defecto y el archivo de usuario.
+// Es la configuración que se debe modificar y guardar con SaveConfig.
+func LoadUserConfig() (*Config, error) {
+	configPath, exists, err := findUserConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	config := GetDefaultConfig()
+	if !exists {
+		return config, nil
+	}
+
+	if _, err := mergeFile(config, configPath); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// UserConfigExists indica si el archivo de usuario ya fue creado
+func UserConfigExists() (bool, error) {
+	_, exists, err := findUserConfig(options)
+	return exists, err
+}
+
+// Load combina los valores por defecto, el archivo de usuario, el archivo de
+// proyecto, las variables de entorno y los flags, en ese orden de precedencia.
+// Si el archivo de usuario no existe se usan los valores por defecto en memoria;
+// para crearlo se debe usar SaveConfig (por ejemplo con "infracli init").
+func Load(opts Options) (*Resolved, error) {
+	config := GetDefaultConfig()
+	resolved := &Resolved{
+		Config:  config,
+		Origins: make(map[string]Origin),
+	}
+	for _, field := range schema {
+		resolved.Origins[field.Key] = OriginDefault
+	}
+
+	// Archivo de usuario
+	userFile, exists, err := findUserConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	resolved.UserFile = userFile
+
+	if exists {
+		keys, err := mergeFile(config, userFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			resolved.Origins[key] = OriginUserFile
+		}
+	}
+
+	// Archivo de proyecto
+	if projectFile := findProjectFile(opts); projectFile != "" {
+		resolved.ProjectFile = projectFile
+
+		servicesPath := config.ServicesPath
+		keys, err := mergeFile(config, projectFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if field, _ := LookupField(key); field.UserOnly {
+				return nil, fmt.Errorf("%s cannot be set in the project file %s; set it in the user config file or with --%s", key, projectFile, field.FlagName())
+			}
+			resolved.Origins[key] = OriginProjectFile
+		}
+
+		// Las rutas relativas del archivo de proyecto son relativas a su directorio
+		if config.ServicesPath != servicesPath && isRelativePath(config.ServicesPath) {
+			config.ServicesPath = filepath.Join(filepath.Dir(projectFile), config.ServicesPath)
+		}
+	}
+
+	// Variables de entorno
+	for i := range schema {
+		field := &schema[i]
+		value, ok := lookupEnv(opts, field.EnvVar())
+		if !ok || value == "" {
+			continue
+		}
+		if field.UserOnly {
+			return nil, fmt.Errorf("%s cannot be set through %s; set it in the user config file or with --%s", field.Key, field.EnvVar(), field.FlagName())
+		}
+		values, err := splitValue(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in %s: %v", field.EnvVar(), err)
+		}
+		if err := field.Set(config, values); err != nil {
+			return nil, fmt.Errorf("invalid value in %s: %v", field.EnvVar(), err)
+		}
+		resolved.Origins[field.Key] = OriginEnv
+	}
+
+	// Flags de línea de comandos
+	for key, values := range opts.Flags {
+		field, err := LookupField(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := field.Set(config, values); err != nil {
+			return nil, fmt.Errorf("invalid value for --%s: %v", field.FlagName(), err)
+		}
+		resolved.Origins[field.Key] = OriginFlag
+	}
+
+	return resolved, nil
+}
+
+// findUserConfig devuelve la ruta del archivo de usuario y si existe. Un archivo
+// indicado explícitamente con --config o INFRACLI_CONFIG debe existir.
+func findUserConfig(opts Options) (string, bool, error) {
+	configPath, explicit, err := userConfigFile(opts)
+	if err != nil {
+		return "", false, err
+	}
+
 	// Comprobar si el archivo existe
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// Si no existe, creamos una configuración por defecto
-		config := GetDefaultConfig()
-		
-		// Guardamos la configuración en el archivo
-		if err := SaveConfig(config); err != nil {
-			return nil, fmt.Errorf("error creating default config: %v", err)
-		}
-		
-		fmt.Println("Created default configuration at:", configPath)
-		return config, nil
+		if explicit {
+			return "", false, fmt.Errorf("config file not found: %s", configPath)
+		}
+		return configPath, false, nil
 	}
-	
-	// Leer el archivo existente
-	data, err := os.ReadFile(configPath)
+
+	return configPath, true, nil
+}
+
+// mergeFile migra en memoria el archivo si es necesario, aplica sobre config
+// las claves presentes en él y las devuelve. Las claves desconocidas producen
+// un error.
+func mergeFile(config *Config, path string) ([]string, error) {
+	data, err := readMigrated(path)
 	if err != nil {
-		return nil, fmt.Errorf("error reading config file: %v", err)
+		return nil, err
+	}
+
+	var present map[string]interface{}
+	if err := json.Unmarshal(data, &present); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	if err := decodeStrict(data, config); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	var keys []string
+	for _, key := range flattenKeys("", present) {
+		if _, err := LookupField(key); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// flattenKeys devuelve las claves de un objeto JSON en notación con puntos,
+// por ejemplo "autoStop.after"
+func flattenKeys(prefix string, object map[string]interface{}) []string {
+	var keys []string
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		keys = append(keys, key)
+		if nested, ok := value.(map[string]interface{}); ok {
+			keys = append(keys, flattenKeys(key, nested)...)
+		}
+	}
+	return keys
+}
+
+// findProjectFile busca el archivo de proyecto desde el directorio de trabajo hacia arriba
+func findProjectFile(opts Options) string {
+	dir := opts.WorkingDir
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return ""
+		}
+		dir = wd
 	}
-	
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("error parsing config file: %v", err)
+
+	for {
+		candidate := filepath.Join(dir, ProjectConfigFileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
 	}
-	
-	return &config, nil
+}
+
+func lookupEnv(opts Options, name string) (string, bool) {
+	if opts.LookupEnv != nil {
+		return opts.LookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// splitValue separa los valores de entorno de las claves de tipo lista: un
+// array JSON o un valor por línea. No se usan comas porque pueden formar parte
+// de los valores, como en los hooks o las plantillas de conexión.
+func splitValue(field *Field, value string) ([]string, error) {
+	if field.Kind != StringListField {
+		return []string{value}, nil
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var values []string
+		if err := json.Unmarshal([]byte(value), &values); err != nil {
+			return nil, fmt.Errorf("expected a JSON array of strings: %v", err)
+		}
+		return values, nil
+	}
+
+	var values []string
+	for _, v := range strings.Split(value, "\n") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func isRelativePath(path string) bool {
+	return path != "" && !filepath.IsAbs(path) && !strings.HasPrefix(path, "~/")
+}
+
+// ExpandPath expande el prefijo ~/ al directorio home del usuario
+func ExpandPath(path string) (string, error) {
+	if len(path) >= 2 && path[:2] == "~/" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting home directory: %v", err)
+		}
+		return filepath.Join(homeDir, path[2:]), nil
+	}
+	return path, nil
+}
+
+// GetStateDir devuelve el directorio donde InfraCLI guarda su estado
+// ($XDG_STATE_HOME/infracli o ~/.local/state/infracli)
+func GetStateDir() (string, error) {
+	if xdg := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, ConfigDirName), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+
+	return filepath.Join(homeDir, ".local", "state", ConfigDirName), nil
+}
+
+// GetServicesPath devuelve la ruta de servicios configurada, ya expandida
+func GetServicesPath() (string, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return ExpandPath(config.ServicesPath)
 }
 
 // GetAvailableServices devuelve una lista de servicios disponibles
@@ -124,42 +534,42 @@ func GetAvailableServices() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	
-	// Usar directamente la ruta de servicios configurada
-	basePath := config.ServicesPath
-	
+
 	// Expandir la ruta si contiene ~/
-	if len(basePath) >= 2 && basePath[:2] == "~/" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("error getting home directory: %v", err)
-		}
-		basePath = filepath.Join(homeDir, basePath[2:])
+	basePath, err := ExpandPath(config.ServicesPath)
+	if err != nil {
+		return nil, err
 	}
-	
+
 	// Verificar que el directorio existe
 	if _, err := os.Stat(basePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("services directory not found: %s", basePath)
+		return nil, fmt.Errorf("services directory not found: %s (run 'infracli init' to configure it)", basePath)
 	}
-	
+
+	return ListServices(basePath, config.ExcludedDirs)
+}
+
+// ListServices devuelve los subdirectorios de basePath que contienen un
+// docker-compose.yml y no están excluidos
+func ListServices(basePath string, excludedDirs []string) ([]string, error) {
 	// Leer los directorios en la ubicación configurada
 	files, err := os.ReadDir(basePath)
 	if err != nil {
 		return nil, fmt.Errorf("error reading services directory: %v", err)
 	}
-	
+
 	var services []string
 	for _, file := range files {
 		if file.IsDir() {
 			// Comprobar si el directorio no está excluido
 			excluded := false
-			for _, excl := range config.ExcludedDirs {
+			for _, excl := range excludedDirs {
 				if file.Name() == excl {
 					excluded = true
 					break
 				}
 			}
-			
+
 			// Si no está excluido y contiene un docker-compose.yml, agregarlo a la lista
 			if !excluded {
 				dockerComposePath := filepath.Join(basePath, file.Name(), "docker-compose.yml")
@@ -169,6 +579,6 @@ func GetAvailableServices() ([]string, error) {
 			}
 		}
 	}
-	
+
 	return services, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// layers son los valores de cada fuente de configuración en una prueba
+type layers struct {
+	user    string
+	project string
+	env     map[string]string
+	flags   map[string][]string
+}
+
+// load escribe los archivos de usuario y de proyecto en directorios
+// temporales y carga la configuración desde un subdirectorio del proyecto
+func load(t *testing.T, l layers) (*Resolved, string, error) {
+	t.Helper()
+
+	xdg := t.TempDir()
+	if l.user != "" {
+		dir := filepath.Join(xdg, ConfigDirName)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(l.user), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	project := t.TempDir()
+	if l.project != "" {
+		if err := os.WriteFile(filepath.Join(project, ProjectConfigFileName), []byte(l.project), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workingDir := filepath.Join(project, "app", "internal")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{"XDG_CONFIG_HOME": xdg}
+	for key, value := range l.env {
+		env[key] = value
+	}
+	resolved, err := Load(Options{
+		Flags: l.flags,
+		LookupEnv: func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		},
+		WorkingDir: workingDir,
+	})
+	return resolved, project, err
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	const (
+		userFile    = `{"version": 1, "servicesPath": "/srv/user", "excludedDirs": ["user"], "autoStop": {"after": "1h"}}`
+		projectFile = `{"servicesPath": "/srv/project", "excludedDirs": ["project"]}`
+	)
+	env := map[string]string{"INFRACLI_SERVICES_PATH": "/srv/env"}
+	flags := map[string][]string{"servicesPath": {"/srv/flag"}}
+
+	tests := []struct {
+		name   string
+		layers layers
+		want   string
+		origin Origin
+	}{
+		{"defaults", layers{}, filepath.Join(os.Getenv("HOME"), "Development", "infrastructure", "services"), OriginDefault},
+		{"user file", layers{user: userFile}, "/srv/user", OriginUserFile},
+		{"project file", layers{user: userFile, project: projectFile}, "/srv/project", OriginProjectFile},
+		{"environment", layers{user: userFile, project: projectFile, env: env}, "/srv/env", OriginEnv},
+		{"flag", layers{user: userFile, project: projectFile, env: env, flags: flags}, "/srv/flag", OriginFlag},
+		{"flag without files", layers{flags: flags}, "/srv/flag", OriginFlag},
+		{"empty env is ignored", layers{user: userFile, env: map[string]string{"INFRACLI_SERVICES_PATH": ""}}, "/srv/user", OriginUserFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, _, err := load(t, tt.layers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resolved.Config.ServicesPath; got != tt.want {
+				t.Errorf("servicesPath = %q, want %q", got, tt.want)
+			}
+			if got := resolved.Origins["servicesPath"]; got != tt.origin {
+				t.Errorf("origin = %q, want %q", got, tt.origin)
+			}
+		})
+	}
+}
+
+// TestLoadMergesPerKey comprueba que cada clave toma su valor de la fuente
+// más prioritaria que la define, sin que una fuente borre las demás claves
+func TestLoadMergesPerKey(t *testing.T) {
+	resolved, _, err := load(t, layers{
+		user:    `{"version": 1, "servicesPath": "/srv/user", "excludedDirs": ["user"], "autoStop": {"after": "1h"}}`,
+		project: `{"excludedDirs": ["project"]}`,
+		env:     map[string]string{"INFRACLI_AUTO_STOP_AFTER": "2h"},
+		flags:   map[string][]string{"credentials.generate": {"postgres"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := resolved.Config
+	if config.ServicesPath != "/srv/user" || resolved.Origins["servicesPath"] != OriginUserFile {
+		t.Errorf("servicesPath = %q from %s", config.ServicesPath, resolved.Origins["servicesPath"])
+	}
+	if !reflect.DeepEqual(config.ExcludedDirs, []string{"project"}) || resolved.Origins["excludedDirs"] != OriginProjectFile {
+		t.Errorf("excludedDirs = %v from %s", config.ExcludedDirs, resolved.Origins["excludedDirs"])
+	}
+	if config.AutoStop.After != "2h" || resolved.Origins["autoStop.after"] != OriginEnv {
+		t.Errorf("autoStop.after = %q from %s", config.AutoStop.After, resolved.Origins["autoStop.after"])
+	}
+	if !reflect.DeepEqual(config.Credentials.Generate, []string{"postgres"}) || resolved.Origins["credentials.generate"] != OriginFlag {
+		t.Errorf("credentials.generate = %v from %s", config.Credentials.Generate, resolved.Origins["credentials.generate"])
+	}
+	if resolved.Origins["hooks.timeout"] != OriginDefault {
+		t.Errorf("hooks.timeout origin = %s", resolved.Origins["hooks.timeout"])
+	}
+}
+
+func TestLoadProjectRelativePath(t *testing.T) {
+	resolved, project, err := load(t, layers{
+		user:    `{"version": 1, "servicesPath": "/srv/user"}`,
+		project: `{"servicesPath": "infra/services"}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(project, "infra", "services"); resolved.Config.ServicesPath != want {
+		t.Errorf("servicesPath = %q, want %q", resolved.Config.ServicesPath, want)
+	}
+	if resolved.ProjectFile != filepath.Join(project, ProjectConfigFileName) {
+		t.Errorf("project file = %q", resolved.ProjectFile)
+	}
+}
+
+func TestLoadListEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"one per line", "postgres.postRun=psql -c 'select 1, 2'\nredis.preDown=echo bye", []string{"postgres.postRun=psql -c 'select 1, 2'", "redis.preDown=echo bye"}},
+		{"json array", `["jdbc=jdbc:postgresql://{{.Host}}:{{.Port}}/a,b", "dsn={{.URL}}"]`, []string{"jdbc=jdbc:postgresql://{{.Host}}:{{.Port}}/a,b", "dsn={{.URL}}"}},
+		{"commas are kept", "a=x,y", []string{"a=x,y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, _, err := load(t, layers{env: map[string]string{"INFRACLI_CONNECTION_TEMPLATES": tt.value}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resolved.Config.ConnectionTemplates; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, _, err := load(t, layers{env: map[string]string{"INFRACLI_EXCLUDED_DIRS": `["unterminated"`}}); err == nil {
+		t.Error("expected an error for an invalid JSON array")
+	}
+}
+
+func TestLoadUserOnly(t *testing.T) {
+	const hook = `postgres.postRun=echo ready`
+
+	tests := []struct {
+		name    string
+		layers  layers
+		wantErr string
+	}{
+		{"user file", layers{user: `{"version": 1, "hooks": {"commands": ["` + hook + `"]}}`}, ""},
+		{"flag", layers{flags: map[string][]string{"hooks.commands": {hook}}}, ""},
+		{"project file", layers{project: `{"hooks": {"commands": ["` + hook + `"]}}`}, "cannot be set in the project file"},
+		{"environment", layers{env: map[string]string{"INFRACLI_HOOKS_COMMANDS": hook}}, "cannot be set through INFRACLI_HOOKS_COMMANDS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, _, err := load(t, tt.layers)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resolved.Config.Hooks.Commands, []string{hook}) {
+				t.Errorf("hooks.commands = %v", resolved.Config.Hooks.Commands)
+			}
+		})
+	}
+
+	// Un proyecto tampoco puede confiar en sus propios hooks x-infracli
+	if _, _, err := load(t, layers{project: `{"servicesPath": "services", "hooks": {"trustedPaths": ["services"]}}`}); err == nil || !strings.Contains(err.Error(), "hooks.trustedPaths cannot be set in the project file") {
+		t.Errorf("expected hooks.trustedPaths to be rejected in the project file, got %v", err)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	_, _, err := load(t, layers{project: `{"servicePath": "/srv/typo"}`})
+	if err == nil || !strings.Contains(err.Error(), "servicePath") {
+		t.Errorf("expected an unknown key error, got %v", err)
+	}
+}
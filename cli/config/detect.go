@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ServicesCandidate es un directorio de servicios detectado automáticamente
+type ServicesCandidate struct {
+	Path     string
+	Services []string
+}
+
+// DetectServicesPaths busca directorios que contengan servicios: "services"
+// en el directorio de trabajo o en cualquiera de sus padres, el propio
+// directorio de trabajo y la ruta por defecto. Solo devuelve los que contienen
+// al menos un servicio, sin repetir.
+func DetectServicesPaths(workingDir string) []ServicesCandidate {
+	excluded := GetDefaultConfig().ExcludedDirs
+
+	var paths []string
+	if workingDir != "" {
+		paths = append(paths, workingDir)
+		for dir := workingDir; ; {
+			paths = append(paths, filepath.Join(dir, "services"))
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	paths = append(paths, GetDefaultConfig().ServicesPath)
+
+	var candidates []ServicesCandidate
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			continue
+		}
+
+		services, err := ListServices(path, excluded)
+		if err != nil || len(services) == 0 {
+			continue
+		}
+
+		candidates = append(candidates, ServicesCandidate{Path: path, Services: services})
+	}
+
+	return candidates
+}
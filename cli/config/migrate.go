@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+)
+
+// CurrentVersion es la versión del esquema de configuración que entiende esta versión de InfraCLI
+const CurrentVersion = 1
+
+// migration transforma un archivo de configuración de la versión from a from+1
+type migration struct {
+	from        int
+	description string
+	apply       func(raw map[string]interface{}) error
+}
+
+// migrations es la cadena de migraciones, ordenada por versión de origen.
+// CurrentVersion solo se incrementa cuando el formato cambia de forma
+// incompatible, añadiendo aquí la migración que convierte los archivos de la
+// versión anterior. Las claves opcionales nuevas no necesitan migración.
+var migrations = []migration{
+	{
+		from:        0,
+		description: "add schema version",
+		apply: func(raw map[string]interface{}) error {
+			// Los archivos sin versión solo contenían servicesPath y excludedDirs,
+			// que no cambian de formato
+			return nil
+		},
+	},
+}
+
+// fileVersion devuelve la versión declarada en el archivo, 0 si no tiene
+func fileVersion(raw map[string]interface{}) (int, error) {
+	value, ok := raw["version"]
+	if !ok {
+		return 0, nil
+	}
+
+	number, ok := value.(float64)
+	if !ok || number != float64(int(number)) || number < 0 {
+		return 0, fmt.Errorf("invalid config version %v", value)
+	}
+
+	return int(number), nil
+}
+
+// Migrate actualiza el contenido de un archivo de configuración a CurrentVersion.
+// Devuelve el contenido migrado y si fue necesario algún cambio.
+func Migrate(data []byte) ([]byte, bool, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, false, fmt.Errorf("error parsing config file: %v", err)
+	}
+
+	version, err := fileVersion(raw)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if version > CurrentVersion {
+		return nil, false, fmt.Errorf("config version %d is newer than the supported version %d, please upgrade infracli", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return data, false, nil
+	}
+
+	for _, m := range migrations {
+		if m.from != version {
+			continue
+		}
+		if err := m.apply(raw); err != nil {
+			return nil, false, fmt.Errorf("error migrating config from version %d (%s): %v", m.from, m.description, err)
+		}
+		version = m.from + 1
+		raw["version"] = version
+	}
+
+	if version != CurrentVersion {
+		return nil, false, fmt.Errorf("no migration path from config version %d to %d", version, CurrentVersion)
+	}
+
+	// encoding/json ordena las claves del mapa, así que el resultado es estable
+	migrated, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, false, fmt.Errorf("error serializing config: %v", err)
+	}
+
+	return migrated, true, nil
+}
+
+// readMigrated lee un archivo y lo migra en memoria, sin modificarlo. Solo
+// 'infracli config migrate' y los comandos que guardan la configuración
+// reescriben el archivo de usuario.
+func readMigrated(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	migrated, changed, err := Migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if changed {
+		logger.Debugf("Using %s migrated to config version %d in memory", path, CurrentVersion)
+	}
+	return migrated, nil
+}
+
+// MigrateFile reescribe un archivo de una versión anterior en CurrentVersion,
+// guardando una copia del original junto a él como <archivo>.v<versión>.bak.
+// Devuelve la ruta de la copia, o "" si el archivo ya estaba al día.
+func MigrateFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading config file: %v", err)
+	}
+
+	migrated, changed, err := Migrate(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	if !changed {
+		return "", nil
+	}
+
+	var raw map[string]interface{}
+	_ = json.Unmarshal(data, &raw)
+	version, _ := fileVersion(raw)
+
+	backupPath := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("error backing up %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, migrated, 0644); err != nil {
+		return "", fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return backupPath, nil
+}
+
+// decodeStrict decodifica data sobre config rechazando claves desconocidas
+func decodeStrict(data []byte, config *Config) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(config)
+}
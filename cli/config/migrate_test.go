@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestMigrationsGolden aplica cada migración a testdata/v<N>.json y compara el
+// resultado con testdata/v<N+1>.json
+func TestMigrationsGolden(t *testing.T) {
+	for _, m := range migrations {
+		t.Run(fmt.Sprintf("v%d", m.from), func(t *testing.T) {
+			var raw map[string]interface{}
+			if err := json.Unmarshal(readTestdata(t, m.from), &raw); err != nil {
+				t.Fatal(err)
+			}
+			if version, err := fileVersion(raw); err != nil || version != m.from {
+				t.Fatalf("testdata/v%d.json declares version %d (%v)", m.from, version, err)
+			}
+
+			if err := m.apply(raw); err != nil {
+				t.Fatal(err)
+			}
+			raw["version"] = m.from + 1
+			got, err := json.MarshalIndent(raw, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareGolden(t, m.from+1, got)
+		})
+	}
+}
+
+// TestMigrateToCurrent comprueba que el archivo de testdata de cada versión
+// llega a testdata/v<CurrentVersion>.json
+func TestMigrateToCurrent(t *testing.T) {
+	for version := 0; version <= CurrentVersion; version++ {
+		data := readTestdata(t, version)
+
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			migrated, changed, err := Migrate(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != (version != CurrentVersion) {
+				t.Errorf("changed = %v for version %d", changed, version)
+			}
+			if version == CurrentVersion {
+				if !bytes.Equal(migrated, data) {
+					t.Errorf("a current file must be returned unchanged")
+				}
+				return
+			}
+			compareGolden(t, CurrentVersion, migrated)
+
+			var config Config
+			if err := decodeStrict(migrated, &config); err != nil {
+				t.Errorf("migrated file does not decode: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateRejectsNewerVersion(t *testing.T) {
+	data := []byte(fmt.Sprintf(`{"version": %d, "servicesPath": "/srv/services"}`, CurrentVersion+1))
+	_, _, err := Migrate(data)
+	if err == nil || !strings.Contains(err.Error(), "newer than the supported version") {
+		t.Errorf("expected a newer version error, got %v", err)
+	}
+}
+
+func TestMigrateRejectsInvalidVersion(t *testing.T) {
+	for _, version := range []string{`-1`, `1.5`, `"1"`, `null`} {
+		data := []byte(`{"version": ` + version + `}`)
+		if _, _, err := Migrate(data); err == nil {
+			t.Errorf("version %s: expected an error", version)
+		}
+	}
+}
+
+func TestReadMigratedKeepsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "infracli.json")
+	original := readTestdata(t, 0)
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := readMigrated(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareGolden(t, CurrentVersion, migrated)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, original) {
+		t.Error("readMigrated modified the file")
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "infracli.json")
+	original := readTestdata(t, 0)
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	backupPath, err := MigrateFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backupPath != path+".v0.bak" {
+		t.Errorf("backup path = %q", backupPath)
+	}
+	if backup, _ := os.ReadFile(backupPath); !bytes.Equal(backup, original) {
+		t.Error("the backup differs from the original file")
+	}
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareGolden(t, CurrentVersion, migrated)
+
+	// Un archivo al día no se toca
+	if backupPath, err := MigrateFile(path); err != nil || backupPath != "" {
+		t.Errorf("migrating a current file: %q, %v", backupPath, err)
+	}
+}
+
+func readTestdata(t *testing.T, version int) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("v%d.json", version)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// compareGolden compara got con testdata/v<version>.json, o lo reescribe con -update
+func compareGolden(t *testing.T, version int, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", fmt.Sprintf("v%d.json", version))
+	if *update {
+		if err := os.WriteFile(path, append(bytes.TrimSpace(got), '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+		t.Errorf("result differs from %s:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,346 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FieldKind indica el tipo de valor que almacena una clave de configuración
+type FieldKind int
+
+const (
+	// StringField es una clave con un único valor de texto
+	StringField FieldKind = iota
+	// StringListField es una clave con una lista de valores de texto
+	StringListField
+)
+
+// String devuelve el nombre legible del tipo de campo
+func (k FieldKind) String() string {
+	switch k {
+	case StringListField:
+		return "list"
+	default:
+		return "string"
+	}
+}
+
+// Field describe una clave de configuración que se puede consultar y modificar
+type Field struct {
+	Key         string
+	Kind        FieldKind
+	Description string
+	// UserOnly limita la clave al archivo de usuario y a los flags. Un archivo
+	// de proyecto o el entorno pueden venir de un repositorio clonado y no deben
+	// poder ejecutar comandos, ni directamente ni apuntando servicesPath a
+	// servicios con hooks x-infracli (ver hooks.trustedPaths).
+	UserOnly bool
+
+	str  func(*Config) *string
+	list func(*Config) *[]string
+}
+
+// schema contiene todas las claves de configuración conocidas
+var schema = []Field{
+	{
+		Key:         "servicesPath",
+		Kind:        StringField,
+		Description: "Directory containing one sub-directory per service",
+		str:         func(c *Config) *string { return &c.ServicesPath },
+	},
+	{
+		Key:         "excludedDirs",
+		Kind:        StringListField,
+		Description: "Directories ignored during service discovery",
+		list:        func(c *Config) *[]string { return &c.ExcludedDirs },
+	},
+	{
+		Key:         "autoStop.after",
+		Kind:        StringField,
+		Description: "Idle time after which 'infracli reap' stops a service, e.g. 4h (empty disables it)",
+		str:         func(c *Config) *string { return &c.AutoStop.After },
+	},
+	{
+		Key:         "autoStop.services",
+		Kind:        StringListField,
+		Description: "Services that 'infracli reap' may stop (empty means all)",
+		list:        func(c *Config) *[]string { return &c.AutoStop.Services },
+	},
+	{
+		Key:         "credentials.generate",
+		Kind:        StringListField,
+		Description: "Services whose passwords are generated randomly on their first run ('all' for every service)",
+		list:        func(c *Config) *[]string { return &c.Credentials.Generate },
+	},
+	{
+		Key:         "connectionTemplates",
+		Kind:        StringListField,
+		Description: "Custom connection strings for 'infracli info --format', as name={{template}}",
+		list:        func(c *Config) *[]string { return &c.ConnectionTemplates },
+	},
+	{
+		Key:         "aliases",
+		Kind:        StringListField,
+		Description: "Alternative service names, as alias=service",
+		list:        func(c *Config) *[]string { return &c.Aliases },
+	},
+	{
+		Key:         "hooks.commands",
+		Kind:        StringListField,
+		Description: "Lifecycle hooks, as service.event=command or service.event@container=command (user file or flag only)",
+		UserOnly:    true,
+		list:        func(c *Config) *[]string { return &c.Hooks.Commands },
+	},
+	{
+		Key:         "hooks.timeout",
+		Kind:        StringField,
+		Description: "Maximum duration of a hook that sets no timeout of its own, e.g. 5m",
+		str:         func(c *Config) *string { return &c.Hooks.Timeout },
+	},
+	{
+		Key:         "hooks.onFailure",
+		Kind:        StringField,
+		Description: "What a failed hook does when it sets no policy of its own: abort or continue",
+		str:         func(c *Config) *string { return &c.Hooks.OnFailure },
+	},
+	{
+		Key:         "hooks.trustedPaths",
+		Kind:        StringListField,
+		Description: "Services directories whose x-infracli hooks run even when servicesPath comes from a project file or the environment (user file or flag only)",
+		UserOnly:    true,
+		list:        func(c *Config) *[]string { return &c.Hooks.TrustedPaths },
+	},
+}
+
+// Fields devuelve las claves de configuración conocidas
+func Fields() []Field {
+	fields := make([]Field, len(schema))
+	copy(fields, schema)
+	return fields
+}
+
+// FieldKeys devuelve los nombres de todas las claves conocidas, ordenados
+func FieldKeys() []string {
+	keys := make([]string, 0, len(schema))
+	for _, f := range schema {
+		keys = append(keys, f.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LookupField busca una clave en el esquema y devuelve un error si no existe
+func LookupField(key string) (*Field, error) {
+	for i := range schema {
+		if schema[i].Key == key {
+			return &schema[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown configuration key %q (valid keys: %s)", key, strings.Join(FieldKeys(), ", "))
+}
+
+// EnvVar devuelve la variable de entorno que sobrescribe la clave, por ejemplo
+// INFRACLI_SERVICES_PATH o INFRACLI_AUTO_STOP_AFTER
+func (f *Field) EnvVar() string {
+	return "INFRACLI_" + strings.ToUpper(strings.Join(splitKey(f.Key), "_"))
+}
+
+// FlagName devuelve el flag global que sobrescribe la clave, por ejemplo
+// services-path o auto-stop-after
+func (f *Field) FlagName() string {
+	return strings.ToLower(strings.Join(splitKey(f.Key), "-"))
+}
+
+// Get devuelve el valor de la clave como texto; las listas se unen con saltos de línea
+func (f *Field) Get(cfg *Config) string {
+	if f.Kind == StringListField {
+		return strings.Join(*f.list(cfg), "\n")
+	}
+	return *f.str(cfg)
+}
+
+// Set reemplaza el valor de la clave
+func (f *Field) Set(cfg *Config, values []string) error {
+	if f.Kind == StringListField {
+		*f.list(cfg) = append([]string{}, values...)
+		return nil
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("key %q expects exactly one value, got %d", f.Key, len(values))
+	}
+	*f.str(cfg) = values[0]
+	return nil
+}
+
+// Add agrega valores a una clave de tipo lista, ignorando los que ya existen
+func (f *Field) Add(cfg *Config, values []string) error {
+	if f.Kind != StringListField {
+		return fmt.Errorf("key %q is not a list", f.Key)
+	}
+	current := f.list(cfg)
+	for _, v := range values {
+		if !containsString(*current, v) {
+			*current = append(*current, v)
+		}
+	}
+	return nil
+}
+
+// Remove elimina valores de una clave de tipo lista
+func (f *Field) Remove(cfg *Config, values []string) error {
+	if f.Kind != StringListField {
+		return fmt.Errorf("key %q is not a list", f.Key)
+	}
+	current := f.list(cfg)
+	kept := []string{}
+	for _, v := range *current {
+		if !containsString(values, v) {
+			kept = append(kept, v)
+		}
+	}
+	*current = kept
+	return nil
+}
+
+// Unset restablece la clave a su valor por defecto
+func (f *Field) Unset(cfg *Config) {
+	defaults := GetDefaultConfig()
+	if f.Kind == StringListField {
+		*f.list(cfg) = append([]string{}, *f.list(defaults)...)
+		return
+	}
+	*f.str(cfg) = *f.str(defaults)
+}
+
+// Validate comprueba que la configuración tenga valores coherentes
+func Validate(cfg *Config) error {
+	var errs []error
+
+	if cfg.Version != CurrentVersion {
+		errs = append(errs, fmt.Errorf("unsupported config version %d (expected %d)", cfg.Version, CurrentVersion))
+	}
+
+	if strings.TrimSpace(cfg.ServicesPath) == "" {
+		errs = append(errs, errors.New("servicesPath must not be empty"))
+	}
+
+	seen := map[string]bool{}
+	for _, dir := range cfg.ExcludedDirs {
+		switch {
+		case strings.TrimSpace(dir) == "":
+			errs = append(errs, errors.New("excludedDirs must not contain empty entries"))
+		case strings.ContainsAny(dir, `/\`):
+			errs = append(errs, fmt.Errorf("excludedDirs entry %q must be a directory name, not a path", dir))
+		case seen[dir]:
+			errs = append(errs, fmt.Errorf("excludedDirs entry %q is duplicated", dir))
+		}
+		seen[dir] = true
+	}
+
+	if after := cfg.AutoStop.After; after != "" {
+		if d, err := time.ParseDuration(after); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("autoStop.after %q must be a positive duration such as 30m or 4h", after))
+		}
+	}
+
+	for _, service := range cfg.AutoStop.Services {
+		if strings.TrimSpace(service) == "" {
+			errs = append(errs, errors.New("autoStop.services must not contain empty entries"))
+		}
+	}
+
+	for _, service := range cfg.Credentials.Generate {
+		if strings.TrimSpace(service) == "" {
+			errs = append(errs, errors.New("credentials.generate must not contain empty entries"))
+		}
+	}
+
+	for _, entry := range cfg.ConnectionTemplates {
+		if name, text, found := strings.Cut(entry, "="); !found || strings.TrimSpace(name) == "" || text == "" {
+			errs = append(errs, fmt.Errorf("connectionTemplates entry %q must have the form name=template", entry))
+		}
+	}
+
+	for _, entry := range cfg.Aliases {
+		if alias, service, found := strings.Cut(entry, "="); !found || strings.TrimSpace(alias) == "" || strings.TrimSpace(service) == "" {
+			errs = append(errs, fmt.Errorf("aliases entry %q must have the form alias=service", entry))
+		}
+	}
+
+	for _, entry := range cfg.Hooks.Commands {
+		if _, err := ParseHookEntry(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if timeout := cfg.Hooks.Timeout; timeout != "" {
+		if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("hooks.timeout %q must be a positive duration such as 30s or 5m", timeout))
+		}
+	}
+
+	switch cfg.Hooks.OnFailure {
+	case "", "abort", "continue":
+	default:
+		errs = append(errs, fmt.Errorf("hooks.onFailure %q must be abort or continue", cfg.Hooks.OnFailure))
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateFile lee un archivo de configuración rechazando claves desconocidas y lo valida
+func ValidateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	// Validar el contenido tal como quedaría después de migrarlo
+	data, _, err = Migrate(data)
+	if err != nil {
+		return err
+	}
+
+	var cfg Config
+	if err := decodeStrict(data, &cfg); err != nil {
+		return fmt.Errorf("error parsing config file: %v", err)
+	}
+
+	return Validate(&cfg)
+}
+
+// splitKey separa una clave como "autoStop.after" en ["auto", "Stop", "after"]
+func splitKey(key string) []string {
+	var words []string
+	for _, part := range strings.Split(key, ".") {
+		words = append(words, splitCamelCase(part)...)
+	}
+	return words
+}
+
+// splitCamelCase separa una clave como "servicesPath" en ["services", "Path"]
+func splitCamelCase(key string) []string {
+	var words []string
+	start := 0
+	for i, r := range key {
+		if i > 0 && unicode.IsUpper(r) {
+			words = append(words, key[start:i])
+			start = i
+		}
+	}
+	return append(words, key[start:])
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
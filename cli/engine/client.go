@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHost es el socket por defecto del demonio de Docker
+	DefaultHost = "unix:///var/run/docker.sock"
+	// APIVersion es la versión de la API de Docker Engine que usa el cliente
+	APIVersion = "v1.41"
+)
+
+// Client habla con el demonio de Docker a través de su API HTTP
+type Client struct {
+	host    string
+	baseURL string
+	http    *http.Client
+}
+
+// APIError es un error devuelto por el demonio de Docker
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docker API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound indica si el error corresponde a un recurso inexistente
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// NewFromEnv crea un cliente usando $DOCKER_HOST o el socket por defecto
+func NewFromEnv() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = DefaultHost
+	}
+	return NewClient(host)
+}
+
+// NewClient crea un cliente para el host indicado: unix:///ruta/al/socket,
+// tcp://host:puerto o http://host:puerto
+func NewClient(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %v", host, err)
+	}
+
+	transport := &http.Transport{
+		MaxIdleConns:    10,
+		IdleConnTimeout: 30 * time.Second,
+	}
+
+	client := &Client{host: host}
+
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+		// El host de la URL no se usa al conectar por socket, pero debe ser válido
+		client.baseURL = "http://docker"
+	case "tcp", "http":
+		client.baseURL = "http://" + u.Host
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme %q (use unix://, tcp:// or http://)", u.Scheme)
+	}
+
+	client.http = &http.Client{Transport: transport}
+	return client, nil
+}
+
+// Host devuelve la dirección del demonio con la que se creó el cliente
+func (c *Client) Host() string {
+	return c.host
+}
+
+// Ping comprueba que el demonio responde
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.get(ctx, "/_ping", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Version devuelve la información de versión del demonio
+func (c *Client) Version(ctx context.Context) (*VersionInfo, error) {
+	var info VersionInfo
+	if err := c.getJSON(ctx, "/version", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Info devuelve la información general del demonio
+func (c *Client) Info(ctx context.Context) (*SystemInfo, error) {
+	var info SystemInfo
+	if err := c.getJSON(ctx, "/info", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// get hace una petición GET y devuelve la respuesta si el código es 2xx
+func (c *Client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, path, query, nil)
+}
+
+// do hace una petición a la API y convierte las respuestas de error en APIError
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	u := c.baseURL + "/" + APIVersion + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the docker daemon at %s: %w", c.host, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+		// El demonio responde los errores como {"message": "..."}
+		var payload struct {
+			Message string `json:"message"`
+		}
+		message := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &payload) == nil && payload.Message != "" {
+			message = payload.Message
+		}
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: message}
+	}
+
+	return resp, nil
+}
+
+// getJSON hace una petición GET y decodifica la respuesta JSON en out
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	resp, err := c.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding docker response: %v", err)
+	}
+	return nil
+}
+
+// Filters son los filtros de la API de Docker, por ejemplo {"label": ["a=b"]}
+type Filters map[string][]string
+
+// Add agrega un valor al filtro indicado
+func (f Filters) Add(key, value string) Filters {
+	f[key] = append(f[key], value)
+	return f
+}
+
+// encode serializa los filtros como los espera el parámetro "filters"
+func (f Filters) encode() string {
+	data, _ := json.Marshal(map[string][]string(f))
+	return string(data)
+}
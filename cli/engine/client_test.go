@@ -0,0 +1,306 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/engine/enginetest"
+)
+
+// newTestClient devuelve un cliente conectado a un demonio falso
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	client, err := NewClient(enginetest.NewDaemon(t, handler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantMessage string
+		notFound    bool
+	}{
+		{"json message", http.StatusNotFound, `{"message":"No such container: db"}`, "No such container: db", true},
+		{"plain text", http.StatusInternalServerError, "page not found\n", "page not found", false},
+		{"json without message", http.StatusConflict, `{"error":"conflict"}`, `{"error":"conflict"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+
+			_, err := client.InspectContainer(context.Background(), "db")
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.wantMessage {
+				t.Errorf("got %d %q, want %d %q", apiErr.StatusCode, apiErr.Message, tt.status, tt.wantMessage)
+			}
+			if IsNotFound(err) != tt.notFound {
+				t.Errorf("IsNotFound = %v, want %v", IsNotFound(err), tt.notFound)
+			}
+			// Los llamadores envuelven los errores con %w
+			if IsNotFound(fmt.Errorf("error inspecting db: %w", err)) != tt.notFound {
+				t.Errorf("IsNotFound does not see through wrapped errors")
+			}
+		})
+	}
+}
+
+// TestConnectErrorChain comprueba que los errores de conexión conservan la
+// causa, que doctor usa para explicar cómo solucionarlos
+func TestConnectErrorChain(t *testing.T) {
+	client, err := NewClient("unix://" + filepath.Join(t.TempDir(), "docker.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Ping(context.Background())
+	if !errors.Is(err, syscall.ENOENT) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the cause is lost: %v", err)
+	}
+}
+
+func TestEnsureNetworkConflict(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/networks/infracli"):
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message":"network infracli not found"}`)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/networks/create"):
+			// Otro proceso la creó entre la consulta y la creación
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `{"message":"network with name infracli already exists"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	if err := client.EnsureNetwork(context.Background(), "infracli", nil); err != nil {
+		t.Errorf("EnsureNetwork: %v", err)
+	}
+}
+
+func TestListContainersFilters(t *testing.T) {
+	var query map[string][]string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+APIVersion+"/containers/json" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		io.WriteString(w, `[{"Id":"abc","Names":["/postgres"],"State":"running","Labels":{"com.docker.compose.project":"postgres"}}]`)
+	}))
+
+	filters := Filters{}
+	filters.Add("label", LabelProject).Add("label", LabelService+"=db")
+	containers, err := client.ListContainers(context.Background(), ListOptions{All: true, Filters: filters})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := query["all"]; !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("all = %v, want [1]", got)
+	}
+	var decoded map[string][]string
+	if err := json.Unmarshal([]byte(query["filters"][0]), &decoded); err != nil {
+		t.Fatalf("filters is not JSON: %v", err)
+	}
+	want := map[string][]string{"label": {LabelProject, LabelService + "=db"}}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("filters = %v, want %v", decoded, want)
+	}
+
+	if len(containers) != 1 || containers[0].Name() != "postgres" || containers[0].Labels[LabelProject] != "postgres" {
+		t.Errorf("unexpected containers: %+v", containers)
+	}
+}
+
+func TestListContainersWithoutFilters(t *testing.T) {
+	var rawQuery string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQuery = r.URL.RawQuery
+		io.WriteString(w, `[]`)
+	}))
+
+	if _, err := client.ListContainers(context.Background(), ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if rawQuery != "" {
+		t.Errorf("query = %q, want none", rawQuery)
+	}
+}
+
+func TestServiceContainers(t *testing.T) {
+	servicePath := "/srv/services/postgres"
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]Container{
+			{ID: "service", Labels: map[string]string{LabelProject: "postgres", LabelWorkingDir: servicePath}},
+			{ID: "instance", Labels: map[string]string{LabelProject: "postgres-t1", LabelWorkingDir: servicePath, LabelInstance: "t1"}},
+			{ID: "oneoff", Labels: map[string]string{LabelProject: "postgres", LabelWorkingDir: servicePath, LabelOneOff: "True"}},
+			{ID: "other", Labels: map[string]string{LabelProject: "mysql", LabelWorkingDir: "/srv/services/mysql"}},
+			{ID: "by-project", Labels: map[string]string{LabelProject: "postgres"}},
+		})
+	}))
+
+	containers, err := client.ServiceContainers(context.Background(), servicePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, container := range containers {
+		ids = append(ids, container.ID)
+	}
+	if want := []string{"service", "by-project"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+}
+
+func TestContainerLogsDemultiplex(t *testing.T) {
+	var query map[string][]string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write(enginetest.LogFrame(1, "ready to accept connections\n"))
+		w.Write(enginetest.LogFrame(2, "WARNING: no password set\n"))
+		w.Write(enginetest.LogFrame(1, "listening on 5432\n"))
+		// Una trama vacía no corta el flujo
+		w.Write(enginetest.LogFrame(2, ""))
+	}))
+
+	logs, err := client.ContainerLogs(context.Background(), "db", LogsOptions{Tail: 10, Timestamps: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logs.Close()
+
+	var stdout, stderr bytes.Buffer
+	if err := StdCopy(&stdout, &stderr, logs); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "ready to accept connections\nlistening on 5432\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "WARNING: no password set\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+	if query["tail"][0] != "10" || query["timestamps"][0] != "1" || query["follow"] != nil {
+		t.Errorf("unexpected query %v", query)
+	}
+}
+
+func TestStdCopyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"unknown stream", enginetest.LogFrame(3, "data")},
+		{"truncated header", enginetest.LogFrame(1, "data")[:5]},
+		{"truncated frame", enginetest.LogFrame(1, "data")[:10]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := StdCopy(io.Discard, io.Discard, bytes.NewReader(tt.input)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	var query map[string][]string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		io.WriteString(w, `{"Type":"container","Action":"start","Actor":{"ID":"abc","Attributes":{"name":"postgres","com.docker.compose.service":"db"}},"time":1700000000,"timeNano":1700000000000000000}`+"\n")
+		io.WriteString(w, `{"Type":"container","Action":"health_status: healthy","Actor":{"ID":"abc","Attributes":{"name":"postgres"}},"time":1700000001}`+"\n")
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	filters := Filters{}
+	filters.Add("type", "container")
+	events, errs := client.Events(ctx, EventsOptions{Since: 1700000000, Until: 1700000010, Filters: filters})
+
+	var got []Event
+	for event := range events {
+		got = append(got, event)
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	default:
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	if got[0].Action != "start" || got[0].Actor.ID != "abc" || got[0].Actor.Attributes["name"] != "postgres" || got[0].TimeNano != 1700000000000000000 {
+		t.Errorf("unexpected first event: %+v", got[0])
+	}
+	if got[1].Action != "health_status: healthy" || got[1].Time != 1700000001 {
+		t.Errorf("unexpected second event: %+v", got[1])
+	}
+	if query["since"][0] != "1700000000" || query["until"][0] != "1700000010" || query["filters"][0] != `{"type":["container"]}` {
+		t.Errorf("unexpected query %v", query)
+	}
+}
+
+func TestEventsDecodeError(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Type":"container","Action":"start"}`+"\n")
+		io.WriteString(w, `{"Type":`)
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	events, errs := client.Events(ctx, EventsOptions{})
+
+	count := 0
+	for range events {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("got %d events before the error, want 1", count)
+	}
+	if err := <-errs; err == nil || !strings.Contains(err.Error(), "error decoding docker event") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEventsAPIError(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"message":"invalid filter 'foo'"}`)
+	}))
+
+	events, errs := client.Events(context.Background(), EventsOptions{})
+	for range events {
+		t.Error("unexpected event")
+	}
+	var apiErr *APIError
+	if err := <-errs; !errors.As(err, &apiErr) || apiErr.Message != "invalid filter 'foo'" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Etiquetas que docker-compose agrega a los contenedores que crea
+const (
+	LabelProject    = "com.docker.compose.project"
+	LabelService    = "com.docker.compose.service"
+	LabelWorkingDir = "com.docker.compose.project.working_dir"
+	LabelOneOff     = "com.docker.compose.oneoff"
+)
+
+// LabelInstance marca con su nombre los contenedores de las instancias
+// aisladas de un servicio
+const LabelInstance = "io.infracli.instance"
+
+var invalidProjectChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// ProjectName devuelve el nombre de proyecto que docker-compose asigna por
+// defecto a un directorio: el nombre en minúsculas sin caracteres inválidos
+func ProjectName(dir string) string {
+	name := strings.ToLower(filepath.Base(dir))
+	return strings.TrimLeft(invalidProjectChars.ReplaceAllString(name, ""), "_-")
+}
+
+// InstanceProject devuelve el nombre de proyecto de una instancia aislada del
+// servicio cuyo docker-compose.yml está en servicePath
+func InstanceProject(servicePath, instance string) string {
+	return ProjectName(servicePath) + "-" + instance
+}
+
+// ComposeContainers devuelve todos los contenedores creados por docker-compose,
+// incluidos los detenidos
+func (c *Client) ComposeContainers(ctx context.Context) ([]Container, error) {
+	filters := Filters{}
+	filters.Add("label", LabelProject)
+	return c.ListContainers(ctx, ListOptions{All: true, Filters: filters})
+}
+
+// BelongsTo indica si un contenedor pertenece al servicio de infracli cuyo
+// docker-compose.yml está en servicePath. Se compara el directorio de trabajo
+// del proyecto y, si no está disponible, el nombre del proyecto. Los
+// contenedores de las instancias aisladas, que comparten el directorio de
+// trabajo del servicio, no cuentan como del servicio.
+func BelongsTo(container Container, servicePath string) bool {
+	if container.Labels[LabelOneOff] == "True" || container.Labels[LabelInstance] != "" {
+		return false
+	}
+
+	if dir := container.Labels[LabelWorkingDir]; dir != "" {
+		return filepath.Clean(dir) == filepath.Clean(servicePath)
+	}
+
+	return container.Labels[LabelProject] == ProjectName(servicePath)
+}
+
+// GroupByService agrupa los contenedores por servicio de infracli. Las claves
+// del resultado son los servicios indicados; los que no tienen contenedores
+// aparecen con una lista vacía.
+func GroupByService(containers []Container, basePath string, services []string) map[string][]Container {
+	groups := make(map[string][]Container, len(services))
+	for _, service := range services {
+		servicePath := filepath.Join(basePath, service)
+		groups[service] = []Container{}
+		for _, container := range containers {
+			if BelongsTo(container, servicePath) {
+				groups[service] = append(groups[service], container)
+			}
+		}
+	}
+	return groups
+}
+
+// ServiceContainers devuelve los contenedores de un único servicio de infracli
+func (c *Client) ServiceContainers(ctx context.Context, servicePath string) ([]Container, error) {
+	containers, err := c.ComposeContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Container
+	for _, container := range containers {
+		if BelongsTo(container, servicePath) {
+			result = append(result, container)
+		}
+	}
+	return result, nil
+}
+
+// InstanceContainers devuelve los contenedores de una instancia aislada del
+// servicio cuyo docker-compose.yml está en servicePath
+func (c *Client) InstanceContainers(ctx context.Context, servicePath, instance string) ([]Container, error) {
+	filters := Filters{}
+	filters.Add("label", LabelProject+"="+InstanceProject(servicePath, instance))
+	containers, err := c.ListContainers(ctx, ListOptions{All: true, Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Container
+	for _, container := range containers {
+		if container.Labels[LabelOneOff] != "True" {
+			result = append(result, container)
+		}
+	}
+	return result, nil
+}
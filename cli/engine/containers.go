@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// ListOptions controla qué contenedores devuelve ListContainers
+type ListOptions struct {
+	// All incluye los contenedores detenidos
+	All bool
+	// Filters son filtros de la API, por ejemplo de etiquetas
+	Filters Filters
+}
+
+// ListContainers devuelve los contenedores que cumplen los filtros
+func (c *Client) ListContainers(ctx context.Context, opts ListOptions) ([]Container, error) {
+	query := url.Values{}
+	if opts.All {
+		query.Set("all", "1")
+	}
+	if len(opts.Filters) > 0 {
+		query.Set("filters", opts.Filters.encode())
+	}
+
+	var containers []Container
+	if err := c.getJSON(ctx, "/containers/json", query, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// InspectContainer devuelve los detalles de un contenedor por ID o nombre
+func (c *Client) InspectContainer(ctx context.Context, id string) (*ContainerDetails, error) {
+	var details ContainerDetails
+	if err := c.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/json", nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// LogsOptions controla la salida de ContainerLogs
+type LogsOptions struct {
+	Follow     bool
+	Timestamps bool
+	// Tail es el número de líneas finales a mostrar; 0 muestra todas
+	Tail int
+	// Since limita los logs a los posteriores a este instante (Unix)
+	Since int64
+}
+
+// ContainerLogs devuelve el flujo de logs de un contenedor. Si el contenedor
+// no usa TTY el flujo está multiplexado y se debe leer con StdCopy.
+func (c *Client) ContainerLogs(ctx context.Context, id string, opts LogsOptions) (io.ReadCloser, error) {
+	query := url.Values{}
+	query.Set("stdout", "1")
+	query.Set("stderr", "1")
+	if opts.Follow {
+		query.Set("follow", "1")
+	}
+	if opts.Timestamps {
+		query.Set("timestamps", "1")
+	}
+	if opts.Tail > 0 {
+		query.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	if opts.Since > 0 {
+		query.Set("since", strconv.FormatInt(opts.Since, 10))
+	}
+
+	resp, err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/logs", query)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// StdCopy separa un flujo multiplexado de Docker en stdout y stderr.
+// Cada trama tiene una cabecera de 8 bytes: el tipo de flujo (1 stdout,
+// 2 stderr), tres bytes vacíos y el tamaño de la trama en big endian.
+func StdCopy(stdout, stderr io.Writer, src io.Reader) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(src, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		var dst io.Writer
+		switch header[0] {
+		case 0, 1:
+			dst = stdout
+		case 2:
+			dst = stderr
+		default:
+			return fmt.Errorf("unexpected stream type %d in docker log stream", header[0])
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(dst, src, size); err != nil {
+			return err
+		}
+	}
+}
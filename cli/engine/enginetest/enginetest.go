@@ -0,0 +1,39 @@
+// Package enginetest arranca demonios de Docker falsos para probar el código
+// que usa el cliente de engine sin un Docker real.
+package enginetest
+
+import (
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// NewDaemon arranca un demonio falso en un socket unix que responde con
+// handler y devuelve su dirección, lista para engine.NewClient. El demonio se
+// cierra al terminar la prueba.
+func NewDaemon(t testing.TB, handler http.Handler) string {
+	t.Helper()
+
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return "unix://" + socket
+}
+
+// LogFrame construye una trama del flujo multiplexado de logs: stream es 1
+// para stdout y 2 para stderr
+func LogFrame(stream byte, data string) []byte {
+	frame := []byte{stream, 0, 0, 0}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(data)))
+	return append(frame, data...)
+}
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// EventsOptions controla qué eventos devuelve Events
+type EventsOptions struct {
+	// Since y Until limitan los eventos a un intervalo (Unix); sin Until el flujo no termina
+	Since   int64
+	Until   int64
+	Filters Filters
+}
+
+// Events envía los eventos del demonio al canal hasta que se cancele el
+// contexto, el demonio cierre la conexión o se alcance Until
+func (c *Client) Events(ctx context.Context, opts EventsOptions) (<-chan Event, <-chan error) {
+	out := make(chan Event)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+
+		query := url.Values{}
+		if opts.Since > 0 {
+			query.Set("since", fmt.Sprint(opts.Since))
+		}
+		if opts.Until > 0 {
+			query.Set("until", fmt.Sprint(opts.Until))
+		}
+		if len(opts.Filters) > 0 {
+			query.Set("filters", opts.Filters.encode())
+		}
+
+		resp, err := c.get(ctx, "/events", query)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var event Event
+			if err := decoder.Decode(&event); err != nil {
+				if ctx.Err() == nil && !errors.Is(err, io.EOF) {
+					errs <- fmt.Errorf("error decoding docker event: %v", err)
+				}
+				return
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, errs
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ImageDetails es la respuesta de /images/{name}/json
+type ImageDetails struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+	Created     string   `json:"Created"`
+	Size        int64    `json:"Size"`
+}
+
+// RepoDigest devuelve el digest con el que se descargó la imagen desde el
+// repositorio indicado (por ejemplo "mongo"), o "" si no tiene ninguno
+func (i *ImageDetails) RepoDigest(name string) string {
+	for _, repoDigest := range i.RepoDigests {
+		repo, digest, found := strings.Cut(repoDigest, "@")
+		if found && repo == name {
+			return digest
+		}
+	}
+	return ""
+}
+
+// InspectImage devuelve los detalles de una imagen local por ID o referencia
+func (c *Client) InspectImage(ctx context.Context, name string) (*ImageDetails, error) {
+	var details ImageDetails
+	if err := c.getJSON(ctx, "/images/"+name+"/json", nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// pullMessage es una línea del progreso de /images/create
+type pullMessage struct {
+	Status      string `json:"status"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// PullImage descarga una imagen. tag puede ser una etiqueta o un digest.
+// La llamada termina cuando el demonio ha completado la descarga.
+func (c *Client) PullImage(ctx context.Context, name, tag string) error {
+	query := url.Values{}
+	query.Set("fromImage", name)
+	query.Set("tag", tag)
+
+	resp, err := c.do(ctx, http.MethodPost, "/images/create", query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// El demonio responde 200 aunque la descarga falle; el error llega en el flujo
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message pullMessage
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("error decoding pull progress: %v", err)
+		}
+		if message.ErrorDetail.Message != "" {
+			return errors.New(message.ErrorDetail.Message)
+		}
+		if message.Error != "" {
+			return errors.New(message.Error)
+		}
+	}
+}
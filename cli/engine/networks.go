@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Network es una red de Docker tal como la devuelve /networks/{id}
+type Network struct {
+	ID     string            `json:"Id"`
+	Name   string            `json:"Name"`
+	Driver string            `json:"Driver"`
+	Labels map[string]string `json:"Labels"`
+	// Containers son los contenedores conectados, indexados por ID
+	Containers map[string]NetworkEndpoint `json:"Containers"`
+}
+
+// NetworkEndpoint es la conexión de un contenedor a una red
+type NetworkEndpoint struct {
+	Name        string `json:"Name"`
+	IPv4Address string `json:"IPv4Address"`
+}
+
+// HasContainer indica si el contenedor está conectado a la red
+func (n *Network) HasContainer(id string) bool {
+	_, ok := n.Containers[id]
+	return ok
+}
+
+// InspectNetwork devuelve una red por nombre o ID. Si no existe el error
+// cumple IsNotFound.
+func (c *Client) InspectNetwork(ctx context.Context, name string) (*Network, error) {
+	var network Network
+	if err := c.getJSON(ctx, "/networks/"+url.PathEscape(name), nil, &network); err != nil {
+		return nil, err
+	}
+	return &network, nil
+}
+
+// CreateNetwork crea una red bridge con las etiquetas indicadas
+func (c *Client) CreateNetwork(ctx context.Context, name string, labels map[string]string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"Name":           name,
+		"Driver":         "bridge",
+		"CheckDuplicate": true,
+		"Labels":         labels,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, "/networks/create", body)
+}
+
+// EnsureNetwork crea la red si todavía no existe
+func (c *Client) EnsureNetwork(ctx context.Context, name string, labels map[string]string) error {
+	if _, err := c.InspectNetwork(ctx, name); err == nil || !IsNotFound(err) {
+		return err
+	}
+	err := c.CreateNetwork(ctx, name, labels)
+	// Otro proceso pudo crearla al mismo tiempo
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+// RemoveNetwork elimina una red
+func (c *Client) RemoveNetwork(ctx context.Context, name string) error {
+	resp, err := c.do(ctx, http.MethodDelete, "/networks/"+url.PathEscape(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ConnectNetwork conecta un contenedor a una red con alias de DNS
+func (c *Client) ConnectNetwork(ctx context.Context, network, container string, aliases []string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"Container":      container,
+		"EndpointConfig": map[string]interface{}{"Aliases": aliases},
+	})
+	if err != nil {
+		return err
+	}
+	err = c.post(ctx, "/networks/"+url.PathEscape(network)+"/connect", body)
+	// El demonio responde 403 si el contenedor ya está conectado
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden && strings.Contains(apiErr.Message, "already exists") {
+		return nil
+	}
+	return err
+}
+
+// DisconnectNetwork desconecta un contenedor de una red
+func (c *Client) DisconnectNetwork(ctx context.Context, network, container string) error {
+	body, err := json.Marshal(map[string]interface{}{"Container": container})
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, "/networks/"+url.PathEscape(network)+"/disconnect", body)
+}
+
+// post envía un cuerpo JSON y descarta la respuesta
+func (c *Client) post(ctx context.Context, path string, body []byte) error {
+	resp, err := c.do(ctx, http.MethodPost, path, nil, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error calling %s: %w", path, err)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CPUUsage es el uso de CPU acumulado de un contenedor
+type CPUUsage struct {
+	TotalUsage  uint64   `json:"total_usage"`
+	PercpuUsage []uint64 `json:"percpu_usage"`
+}
+
+// CPUStats son las estadísticas de CPU de una muestra
+type CPUStats struct {
+	CPUUsage       CPUUsage `json:"cpu_usage"`
+	SystemCPUUsage uint64   `json:"system_cpu_usage"`
+	OnlineCPUs     int      `json:"online_cpus"`
+}
+
+// MemoryStats son las estadísticas de memoria de una muestra
+type MemoryStats struct {
+	Usage uint64            `json:"usage"`
+	Limit uint64            `json:"limit"`
+	Stats map[string]uint64 `json:"stats"`
+}
+
+// NetworkStats son los contadores de una interfaz de red
+type NetworkStats struct {
+	RxBytes uint64 `json:"rx_bytes"`
+	TxBytes uint64 `json:"tx_bytes"`
+}
+
+// BlkioEntry es un contador de entrada/salida de bloques
+type BlkioEntry struct {
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+	Op    string `json:"op"`
+	Value uint64 `json:"value"`
+}
+
+// BlkioStats son las estadísticas de entrada/salida de bloques
+type BlkioStats struct {
+	IoServiceBytesRecursive []BlkioEntry `json:"io_service_bytes_recursive"`
+}
+
+// Stats es una muestra de /containers/{id}/stats
+type Stats struct {
+	Read        string                  `json:"read"`
+	CPUStats    CPUStats                `json:"cpu_stats"`
+	PreCPUStats CPUStats                `json:"precpu_stats"`
+	MemoryStats MemoryStats             `json:"memory_stats"`
+	Networks    map[string]NetworkStats `json:"networks"`
+	BlkioStats  BlkioStats              `json:"blkio_stats"`
+}
+
+// ContainerStats devuelve una única muestra de estadísticas del contenedor
+func (c *Client) ContainerStats(ctx context.Context, id string) (*Stats, error) {
+	query := url.Values{}
+	query.Set("stream", "0")
+
+	var stats Stats
+	if err := c.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/stats", query, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// StreamStats envía una muestra por segundo al canal hasta que se cancele el contexto
+func (c *Client) StreamStats(ctx context.Context, id string) (<-chan *Stats, <-chan error) {
+	out := make(chan *Stats)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+
+		query := url.Values{}
+		query.Set("stream", "1")
+		resp, err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/stats", query)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var stats Stats
+			if err := decoder.Decode(&stats); err != nil {
+				if ctx.Err() == nil {
+					errs <- fmt.Errorf("error decoding docker stats: %v", err)
+				}
+				return
+			}
+			select {
+			case out <- &stats:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, errs
+}
+
+// CPUPercent calcula el porcentaje de CPU igual que "docker stats"
+func (s *Stats) CPUPercent() float64 {
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemCPUUsage) - float64(s.PreCPUStats.SystemCPUUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpus == 0 {
+		cpus = 1
+	}
+
+	return cpuDelta / systemDelta * cpus * 100
+}
+
+// MemoryUsage devuelve la memoria usada sin contar la caché de páginas
+func (s *Stats) MemoryUsage() uint64 {
+	usage := s.MemoryStats.Usage
+
+	// cgroup v2 informa inactive_file, cgroup v1 total_inactive_file o cache
+	for _, key := range []string{"inactive_file", "total_inactive_file", "cache"} {
+		if v, ok := s.MemoryStats.Stats[key]; ok && v < usage {
+			return usage - v
+		}
+	}
+	return usage
+}
+
+// NetworkIO devuelve los bytes recibidos y enviados por todas las interfaces
+func (s *Stats) NetworkIO() (rx, tx uint64) {
+	for _, n := range s.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	return rx, tx
+}
+
+// BlockIO devuelve los bytes leídos y escritos en disco
+func (s *Stats) BlockIO() (read, write uint64) {
+	for _, entry := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += entry.Value
+		case "write":
+			write += entry.Value
+		}
+	}
+	return read, write
+}
@@ -0,0 +1,167 @@
+package engine
+
+import "strings"
+
+// VersionInfo es la respuesta de /version
+type VersionInfo struct {
+	Version       string `json:"Version"`
+	APIVersion    string `json:"ApiVersion"`
+	MinAPIVersion string `json:"MinAPIVersion"`
+	Os            string `json:"Os"`
+	Arch          string `json:"Arch"`
+}
+
+// SystemInfo es la respuesta de /info
+type SystemInfo struct {
+	Name            string `json:"Name"`
+	ServerVersion   string `json:"ServerVersion"`
+	OperatingSystem string `json:"OperatingSystem"`
+	// DockerRootDir es donde el demonio guarda imágenes y volúmenes, visto
+	// desde la máquina en la que se ejecuta
+	DockerRootDir string `json:"DockerRootDir"`
+	NCPU          int    `json:"NCPU"`
+	MemTotal      int64  `json:"MemTotal"`
+}
+
+// Port es un puerto publicado en el listado de contenedores
+type Port struct {
+	IP          string `json:"IP"`
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort"`
+	Type        string `json:"Type"`
+}
+
+// Container es un elemento del listado de contenedores (/containers/json)
+type Container struct {
+	ID      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	ImageID string            `json:"ImageID"`
+	Command string            `json:"Command"`
+	Created int64             `json:"Created"`
+	State   string            `json:"State"`
+	Status  string            `json:"Status"`
+	Ports   []Port            `json:"Ports"`
+	Labels  map[string]string `json:"Labels"`
+}
+
+// Name devuelve el nombre del contenedor sin la barra inicial
+func (c Container) Name() string {
+	if len(c.Names) == 0 {
+		return shortID(c.ID)
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// ComposeService devuelve el nombre del servicio de compose del contenedor
+func (c Container) ComposeService() string {
+	return c.Labels[LabelService]
+}
+
+// HealthLog es el resultado de una ejecución del healthcheck
+type HealthLog struct {
+	Start    string `json:"Start"`
+	End      string `json:"End"`
+	ExitCode int    `json:"ExitCode"`
+	Output   string `json:"Output"`
+}
+
+// Health es el estado del healthcheck de un contenedor
+type Health struct {
+	Status        string      `json:"Status"`
+	FailingStreak int         `json:"FailingStreak"`
+	Log           []HealthLog `json:"Log"`
+}
+
+// ContainerState es el estado detallado de un contenedor
+type ContainerState struct {
+	Status     string  `json:"Status"`
+	Running    bool    `json:"Running"`
+	Paused     bool    `json:"Paused"`
+	Restarting bool    `json:"Restarting"`
+	OOMKilled  bool    `json:"OOMKilled"`
+	Dead       bool    `json:"Dead"`
+	Pid        int     `json:"Pid"`
+	ExitCode   int     `json:"ExitCode"`
+	Error      string  `json:"Error"`
+	StartedAt  string  `json:"StartedAt"`
+	FinishedAt string  `json:"FinishedAt"`
+	Health     *Health `json:"Health"`
+}
+
+// HealthStatus devuelve el estado del healthcheck o "none" si no tiene
+func (s ContainerState) HealthStatus() string {
+	if s.Health == nil || s.Health.Status == "" {
+		return "none"
+	}
+	return s.Health.Status
+}
+
+// ContainerConfig es la configuración con la que se creó el contenedor
+type ContainerConfig struct {
+	Image  string            `json:"Image"`
+	Env    []string          `json:"Env"`
+	Labels map[string]string `json:"Labels"`
+	Tty    bool              `json:"Tty"`
+}
+
+// PortBinding es un puerto del host asociado a un puerto del contenedor
+type PortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// EndpointSettings es la conexión de un contenedor a una red
+type EndpointSettings struct {
+	NetworkID string   `json:"NetworkID"`
+	IPAddress string   `json:"IPAddress"`
+	Aliases   []string `json:"Aliases"`
+}
+
+// NetworkSettings contiene las redes y puertos de un contenedor
+type NetworkSettings struct {
+	Ports    map[string][]PortBinding     `json:"Ports"`
+	Networks map[string]*EndpointSettings `json:"Networks"`
+}
+
+// Mount es un volumen o bind mount del contenedor
+type Mount struct {
+	Type        string `json:"Type"`
+	Name        string `json:"Name"`
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+}
+
+// ContainerDetails es la respuesta de /containers/{id}/json
+type ContainerDetails struct {
+	ID              string          `json:"Id"`
+	Name            string          `json:"Name"`
+	Created         string          `json:"Created"`
+	Image           string          `json:"Image"`
+	State           ContainerState  `json:"State"`
+	Config          ContainerConfig `json:"Config"`
+	NetworkSettings NetworkSettings `json:"NetworkSettings"`
+	Mounts          []Mount         `json:"Mounts"`
+}
+
+// Event es un evento del demonio (/events)
+type Event struct {
+	Type     string `json:"Type"`
+	Action   string `json:"Action"`
+	Actor    Actor  `json:"Actor"`
+	Time     int64  `json:"time"`
+	TimeNano int64  `json:"timeNano"`
+}
+
+// Actor es el objeto al que se refiere un evento
+type Actor struct {
+	ID         string            `json:"ID"`
+	Attributes map[string]string `json:"Attributes"`
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"context"
+	"net/url"
+)
+
+// Volume es un volumen de Docker tal como lo devuelve /volumes
+type Volume struct {
+	Name   string            `json:"Name"`
+	Driver string            `json:"Driver"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// ListVolumes devuelve los volúmenes que cumplen los filtros
+func (c *Client) ListVolumes(ctx context.Context, filters Filters) ([]Volume, error) {
+	query := url.Values{}
+	if len(filters) > 0 {
+		query.Set("filters", filters.encode())
+	}
+
+	var response struct {
+		Volumes []Volume `json:"Volumes"`
+	}
+	if err := c.getJSON(ctx, "/volumes", query, &response); err != nil {
+		return nil, err
+	}
+	return response.Volumes, nil
+}
+
+// ProjectVolumes devuelve los volúmenes creados por docker-compose para un proyecto
+func (c *Client) ProjectVolumes(ctx context.Context, project string) ([]Volume, error) {
+	filters := Filters{}
+	filters.Add("label", LabelProject+"="+project)
+	return c.ListVolumes(ctx, filters)
+}
@@ -0,0 +1,348 @@
+package infracli
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+)
+
+// Connection es la información para conectarse a un servicio, leída de su
+// docker-compose.yml con los mismos criterios que 'infracli info'
+type Connection struct {
+	Service string
+	// Engine es el tipo de servicio, por ejemplo "postgres" o "mysql"; vacío
+	// si infracli no lo conoce
+	Engine   string
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Database string
+	// URL es la URL principal del servicio, lista para pasarla a un driver;
+	// es nil si infracli no conoce el tipo de servicio
+	URL *url.URL
+	// URLs son otras URLs del servicio por nombre, por ejemplo "jdbc" o "kibana"
+	URLs map[string]*url.URL
+
+	// network indica que la conexión se hace desde otro contenedor de una red
+	// compartida en lugar de desde el host
+	network bool
+	// aliases son los nombres de DNS de cada contenedor en la red
+	aliases map[string][]string
+}
+
+// String devuelve la URL principal, o host:puerto si no hay
+func (c *Connection) String() string {
+	if c.URL != nil {
+		return c.URL.String()
+	}
+	return c.Address()
+}
+
+// Address devuelve host:puerto
+func (c *Connection) Address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// ConnectionInfo devuelve la información de conexión de un servicio, con las
+// contraseñas guardadas como secretos o generadas por credentials.generate en
+// lugar de las de su docker-compose.yml
+func (c *Client) ConnectionInfo(service string) (*Connection, error) {
+	servicePath, err := c.servicePath(service)
+	if err != nil {
+		return nil, err
+	}
+	content, err := compose.Read(servicePath)
+	if err != nil {
+		return nil, err
+	}
+	values, err := stack.ServiceSecrets(service, c.stackOptions())
+	if err != nil {
+		return nil, fmt.Errorf("error reading the secrets of %s: %v", service, err)
+	}
+	return ConnectionFromCompose(service, compose.SetEnvironment(content, values)), nil
+}
+
+// ConnectionFromCompose devuelve la información de conexión de un servicio a
+// partir del contenido de su docker-compose.yml
+func ConnectionFromCompose(service, content string) *Connection {
+	conn := &Connection{Service: service, Host: "localhost", URLs: make(map[string]*url.URL)}
+	buildConnection(conn, content)
+	return conn
+}
+
+// NetworkConnectionFromCompose devuelve la información de conexión desde otro
+// contenedor conectado a la misma red que el servicio, con 'infracli link':
+// el host es el nombre del contenedor en la red y los puertos son los del
+// contenedor, no los publicados en el host
+func NetworkConnectionFromCompose(service, content string) *Connection {
+	conn := &Connection{Service: service, URLs: make(map[string]*url.URL), network: true, aliases: NetworkAliases(service, content)}
+	buildConnection(conn, content)
+	return conn
+}
+
+// HookEnv devuelve las variables con la información de conexión que reciben
+// los hooks de un servicio: INFRACLI_HOST, INFRACLI_PORT, INFRACLI_USER,
+// INFRACLI_PASSWORD, INFRACLI_DATABASE e INFRACLI_URL. Dentro de un contenedor
+// se usan los nombres de la red y los puertos del contenedor, como en
+// 'infracli info --network'.
+func HookEnv(service, content string, inContainer bool) []string {
+	conn := ConnectionFromCompose(service, content)
+	if inContainer {
+		conn = NetworkConnectionFromCompose(service, content)
+	}
+
+	env := []string{"INFRACLI_HOST=" + conn.Host}
+	if conn.Port != 0 {
+		env = append(env, "INFRACLI_PORT="+strconv.Itoa(conn.Port))
+	}
+	if conn.User != "" {
+		env = append(env, "INFRACLI_USER="+conn.User)
+	}
+	if conn.Password != "" {
+		env = append(env, "INFRACLI_PASSWORD="+conn.Password)
+	}
+	if conn.Database != "" {
+		env = append(env, "INFRACLI_DATABASE="+conn.Database)
+	}
+	if conn.URL != nil {
+		env = append(env, "INFRACLI_URL="+conn.URL.String())
+	}
+	return env
+}
+
+// NetworkAliases devuelve los nombres de DNS de cada contenedor del servicio
+// en una red compartida, indexados por servicio de compose: el nombre del
+// servicio de infracli para su contenedor principal, el container_name y el
+// nombre del servicio de compose
+func NetworkAliases(service, content string) map[string][]string {
+	return compose.NetworkAliases(service, content)
+}
+
+func buildConnection(conn *Connection, content string) {
+	switch conn.Service {
+	case "mysql":
+		conn.Engine = "mysql"
+		mysqlConnection(conn, content)
+	case "postgres":
+		conn.Engine = "postgres"
+		postgresConnection(conn, content)
+	case "mongo":
+		conn.Engine = "mongo"
+		mongoConnection(conn, content)
+	case "redis":
+		conn.Engine = "redis"
+		redisConnection(conn, content)
+	case "elasticsearch-kibana":
+		conn.Engine = "elasticsearch"
+		elasticsearchConnection(conn, content)
+	case "neo4j":
+		conn.Engine = "neo4j"
+		neo4jConnection(conn, content)
+	default:
+		genericConnection(conn, content)
+	}
+}
+
+// WithPassword devuelve una copia de la conexión con otra contraseña, también
+// en las URLs, por ejemplo para mostrarla enmascarada
+func (c *Connection) WithPassword(password string) *Connection {
+	copied := *c
+	if copied.Password != "" {
+		copied.Password = password
+	}
+	copied.URL = replacePassword(c.URL, password)
+	copied.URLs = make(map[string]*url.URL, len(c.URLs))
+	for name, u := range c.URLs {
+		copied.URLs[name] = replacePassword(u, password)
+	}
+	return &copied
+}
+
+func replacePassword(u *url.URL, password string) *url.URL {
+	if u == nil {
+		return nil
+	}
+	copied := *u
+	if _, ok := u.User.Password(); ok {
+		copied.User = url.UserPassword(u.User.Username(), password)
+	}
+	return &copied
+}
+
+func mysqlConnection(conn *Connection, content string) {
+	container := compose.FindContainer(content, "mysql")
+	env := compose.Environment(content, servicePrefix(container))
+
+	conn.Host = conn.host(container)
+	conn.Port = conn.port(content, container, "3306")
+	conn.Database = env["MYSQL_DATABASE"]
+	conn.User = env["MYSQL_USER"]
+	conn.Password = env["MYSQL_PASSWORD"]
+	conn.URL = &url.URL{Scheme: "mysql", User: url.UserPassword(conn.User, conn.Password), Host: conn.Address(), Path: "/" + conn.Database}
+	conn.URLs["jdbc"] = &url.URL{Scheme: "jdbc", Opaque: "mysql://" + conn.Address() + "/" + conn.Database}
+}
+
+func postgresConnection(conn *Connection, content string) {
+	container := compose.FindContainer(content, "postgres")
+	env := compose.Environment(content, servicePrefix(container))
+
+	conn.Host = conn.host(container)
+	conn.Port = conn.port(content, container, "5432")
+	conn.User = env["POSTGRES_USER"]
+	conn.Password = env["POSTGRES_PASSWORD"]
+	conn.Database = env["POSTGRES_DB"]
+	// Si no se indica la base de datos, es la del usuario
+	if conn.Database == "" {
+		conn.Database = conn.User
+	}
+	// Los contenedores locales no usan TLS
+	conn.URL = &url.URL{Scheme: "postgresql", User: url.UserPassword(conn.User, conn.Password), Host: conn.Address(), Path: "/" + conn.Database, RawQuery: "sslmode=disable"}
+	conn.URLs["jdbc"] = &url.URL{Scheme: "jdbc", Opaque: "postgresql://" + conn.Address() + "/" + conn.Database}
+}
+
+func mongoConnection(conn *Connection, content string) {
+	container := compose.FindContainer(content, "mongo")
+	env := compose.Environment(content, servicePrefix(container))
+
+	conn.Host = conn.host(container)
+	conn.Port = conn.port(content, container, "27017")
+	conn.User = env["MONGO_INITDB_ROOT_USERNAME"]
+	conn.Password = env["MONGO_INITDB_ROOT_PASSWORD"]
+	conn.Database = "admin"
+	conn.URL = &url.URL{Scheme: "mongodb", Host: conn.Address(), Path: "/" + conn.Database}
+	if conn.User != "" {
+		conn.URL.User = url.UserPassword(conn.User, conn.Password)
+	}
+}
+
+func redisConnection(conn *Connection, content string) {
+	container := compose.FindContainer(content, "redis")
+
+	conn.Host = conn.host(container)
+	conn.Port = conn.port(content, container, "6379")
+	conn.Database = "0"
+	// La contraseña se pasa como argumento de redis-server
+	for _, line := range strings.Split(content, "\n") {
+		if _, password, found := strings.Cut(line, "requirepass"); found {
+			conn.Password = strings.Trim(strings.TrimSpace(password), `"'`)
+			break
+		}
+	}
+
+	conn.URL = &url.URL{Scheme: "redis", Host: conn.Address(), Path: "/" + conn.Database}
+	if conn.Password != "" {
+		conn.URL.User = url.UserPassword("", conn.Password)
+	}
+}
+
+func elasticsearchConnection(conn *Connection, content string) {
+	elasticsearch := compose.FindContainer(content, "elastic")
+	kibana := compose.FindContainer(content, "kibana")
+
+	conn.Host = conn.host(elasticsearch)
+	conn.Port = conn.port(content, elasticsearch, "9200")
+	if strings.Contains(content, "xpack.security.enabled=true") {
+		conn.User = "elastic"
+		conn.Password = compose.Environment(content, servicePrefix(elasticsearch))["ELASTIC_PASSWORD"]
+	}
+	conn.URL = &url.URL{Scheme: "http", Host: conn.Address()}
+	if kibana != "" {
+		conn.URLs["kibana"] = &url.URL{Scheme: "http", Host: net.JoinHostPort(conn.host(kibana), strconv.Itoa(conn.port(content, kibana, "5601")))}
+	}
+}
+
+func neo4jConnection(conn *Connection, content string) {
+	container := compose.FindContainer(content, "neo4j")
+	env := compose.Environment(content, servicePrefix(container))
+
+	conn.Host = conn.host(container)
+	conn.Port = conn.port(content, container, "7687")
+	// NEO4J_AUTH tiene el formato usuario/contraseña, o "none" sin autenticación
+	if user, password, found := strings.Cut(env["NEO4J_AUTH"], "/"); found {
+		conn.User = user
+		conn.Password = password
+	}
+	conn.URL = &url.URL{Scheme: "bolt", Host: conn.Address()}
+	conn.URLs["http"] = &url.URL{Scheme: "http", Host: net.JoinHostPort(conn.Host, strconv.Itoa(conn.port(content, container, "7474")))}
+	conn.URLs["https"] = &url.URL{Scheme: "https", Host: net.JoinHostPort(conn.Host, strconv.Itoa(conn.port(content, container, "7473")))}
+}
+
+// genericConnection solo conoce el primer puerto publicado del servicio
+func genericConnection(conn *Connection, content string) {
+	conn.Host = conn.host(compose.PrimaryContainer(conn.Service, content))
+
+	containers := make([]string, 0)
+	for container := range compose.Images(content) {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	for _, container := range containers {
+		for _, mapping := range compose.Ports(content, servicePrefix(container)) {
+			host, target, _ := strings.Cut(mapping, ":")
+			if conn.network {
+				host = strings.Split(target, "/")[0]
+			}
+			if port, err := strconv.Atoi(strings.TrimSpace(host)); err == nil {
+				if conn.network && conn.Host == "" {
+					conn.Host = conn.host(container)
+				}
+				conn.Port = port
+				return
+			}
+		}
+	}
+}
+
+// host devuelve el host con el que se llega al contenedor: localhost desde
+// el host o su primer alias desde la red
+func (c *Connection) host(container string) string {
+	if !c.network {
+		return "localhost"
+	}
+	if aliases := c.aliases[container]; len(aliases) > 0 {
+		return aliases[0]
+	}
+	return container
+}
+
+// port devuelve el puerto con el que se llega a containerPort: el publicado
+// en el host o, desde la red, el propio puerto del contenedor
+func (c *Connection) port(content, container, containerPort string) int {
+	if c.network {
+		port, _ := strconv.Atoi(containerPort)
+		return port
+	}
+	return hostPort(content, container, containerPort)
+}
+
+// hostPort devuelve el puerto del host publicado para containerPort, o
+// containerPort si no está publicado con otro número
+func hostPort(content, container, containerPort string) int {
+	port, _ := strconv.Atoi(containerPort)
+	if container == "" {
+		return port
+	}
+
+	for _, mapping := range compose.Ports(content, servicePrefix(container)) {
+		host, target, found := strings.Cut(mapping, ":")
+		if !found || strings.TrimSpace(strings.Split(target, "/")[0]) != containerPort {
+			continue
+		}
+		if p, err := strconv.Atoi(strings.TrimSpace(host)); err == nil {
+			return p
+		}
+	}
+	return port
+}
+
+func servicePrefix(container string) string {
+	return "  " + container + ":"
+}
@@ -0,0 +1,230 @@
+package infracli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Format es una plantilla de text/template que produce la cadena de conexión
+// de un driver o framework a partir de una Connection, por ejemplo
+// "postgresql+psycopg2://{{.URL.User}}@{{.Address}}/{{.Database}}"
+type Format struct {
+	Name        string
+	Description string
+	// Engines son los tipos de servicio a los que se aplica; vacío para todos
+	Engines  []string
+	Template string
+}
+
+// formatFuncs son las funciones disponibles en las plantillas
+var formatFuncs = template.FuncMap{
+	// quote devuelve una cadena entre comillas dobles con los caracteres escapados
+	"quote": strconv.Quote,
+	// envname convierte un nombre en prefijo de variable de entorno: "elasticsearch-kibana" -> "ELASTICSEARCH_KIBANA"
+	"envname": envName,
+}
+
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9]+`)
+
+func envName(name string) string {
+	return strings.Trim(invalidEnvChars.ReplaceAllString(strings.ToUpper(name), "_"), "_")
+}
+
+// formats es el catálogo de formatos incluidos
+var formats = []Format{
+	{Name: "url", Description: "Connection URL", Template: "{{.}}"},
+	// Variables al estilo de Kubernetes (<SERVICIO>_SERVICE_HOST), para archivos .env o ConfigMaps
+	{Name: "env", Description: "Kubernetes-style environment variables", Template: `{{$p := envname .Service}}{{$p}}_SERVICE_HOST={{.Host}}
+{{$p}}_SERVICE_PORT={{.Port}}
+{{- if .User}}
+{{$p}}_USER={{.User}}{{end}}
+{{- if .Password}}
+{{$p}}_PASSWORD={{.Password}}{{end}}
+{{- if .Database}}
+{{$p}}_DATABASE={{.Database}}{{end}}
+{{- if .URL}}
+{{$p}}_URL={{.URL}}{{end}}`},
+
+	// PostgreSQL
+	{Name: "go", Description: "Go DSN for pgx and lib/pq", Engines: []string{"postgres"},
+		Template: "host={{.Host}} port={{.Port}} user={{.User}} password={{.Password}} dbname={{.Database}} sslmode=disable"},
+	{Name: "jdbc", Description: "JDBC URL", Engines: []string{"postgres"},
+		Template: `jdbc:postgresql://{{.Address}}/{{.Database}}?user={{urlquery .User}}&password={{urlquery .Password}}`},
+	{Name: "sqlalchemy", Description: "SQLAlchemy URL (psycopg2)", Engines: []string{"postgres"},
+		Template: "postgresql+psycopg2://{{.URL.User}}@{{.Address}}/{{.Database}}"},
+	{Name: "prisma", Description: "Prisma DATABASE_URL", Engines: []string{"postgres"},
+		Template: `DATABASE_URL="postgresql://{{.URL.User}}@{{.Address}}/{{.Database}}?schema=public"`},
+	{Name: "spring", Description: "Spring Boot application.properties", Engines: []string{"postgres"},
+		Template: "spring.datasource.url=jdbc:postgresql://{{.Address}}/{{.Database}}\nspring.datasource.username={{.User}}\nspring.datasource.password={{.Password}}"},
+	{Name: "django", Description: "Django DATABASES setting", Engines: []string{"postgres"},
+		Template: djangoTemplate("django.db.backends.postgresql")},
+	{Name: "node", Description: "node-postgres (pg) pool", Engines: []string{"postgres"},
+		Template: "const pool = new Pool({ connectionString: {{quote .URL.String}} });"},
+	{Name: "dotnet", Description: "Npgsql connection string", Engines: []string{"postgres"},
+		Template: "Host={{.Host}};Port={{.Port}};Database={{.Database}};Username={{.User}};Password={{.Password}}"},
+	{Name: "cli", Description: "psql command", Engines: []string{"postgres"},
+		Template: "psql -h {{.Host}} -p {{.Port}} -U {{.User}} -d {{.Database}}"},
+
+	// MySQL
+	{Name: "go", Description: "Go DSN for go-sql-driver/mysql", Engines: []string{"mysql"},
+		Template: "{{.User}}:{{.Password}}@tcp({{.Address}})/{{.Database}}?parseTime=true"},
+	{Name: "jdbc", Description: "JDBC URL", Engines: []string{"mysql"},
+		Template: `jdbc:mysql://{{.Address}}/{{.Database}}?user={{urlquery .User}}&password={{urlquery .Password}}`},
+	{Name: "sqlalchemy", Description: "SQLAlchemy URL (PyMySQL)", Engines: []string{"mysql"},
+		Template: "mysql+pymysql://{{.URL.User}}@{{.Address}}/{{.Database}}"},
+	{Name: "prisma", Description: "Prisma DATABASE_URL", Engines: []string{"mysql"},
+		Template: `DATABASE_URL="{{.URL}}"`},
+	{Name: "spring", Description: "Spring Boot application.properties", Engines: []string{"mysql"},
+		Template: "spring.datasource.url=jdbc:mysql://{{.Address}}/{{.Database}}\nspring.datasource.username={{.User}}\nspring.datasource.password={{.Password}}"},
+	{Name: "django", Description: "Django DATABASES setting", Engines: []string{"mysql"},
+		Template: djangoTemplate("django.db.backends.mysql")},
+	{Name: "node", Description: "mysql2 connection", Engines: []string{"mysql"},
+		Template: "const connection = await mysql.createConnection({{quote .URL.String}});"},
+	{Name: "dotnet", Description: "MySqlConnector connection string", Engines: []string{"mysql"},
+		Template: "Server={{.Host}};Port={{.Port}};Database={{.Database}};User ID={{.User}};Password={{.Password}}"},
+	{Name: "cli", Description: "mysql command", Engines: []string{"mysql"},
+		Template: "mysql -h {{.Host}} -P {{.Port}} -u {{.User}}{{if .Password}} -p{{.Password}}{{end}} {{.Database}}"},
+
+	// MongoDB
+	{Name: "go", Description: "mongo-go-driver URI", Engines: []string{"mongo"},
+		Template: `mongo.Connect(ctx, options.Client().ApplyURI({{quote .URL.String}}))`},
+	{Name: "prisma", Description: "Prisma DATABASE_URL", Engines: []string{"mongo"},
+		Template: `DATABASE_URL="mongodb://{{.URL.User}}@{{.Address}}/{{.Database}}?authSource=admin"`},
+	{Name: "spring", Description: "Spring Boot application.properties", Engines: []string{"mongo"},
+		Template: "spring.data.mongodb.uri={{.URL}}?authSource=admin"},
+	{Name: "node", Description: "MongoDB Node.js driver", Engines: []string{"mongo"},
+		Template: "const client = new MongoClient({{quote .URL.String}});"},
+	{Name: "dotnet", Description: "MongoDB .NET driver", Engines: []string{"mongo"},
+		Template: "var client = new MongoClient({{quote .URL.String}});"},
+	{Name: "cli", Description: "mongosh command", Engines: []string{"mongo"},
+		Template: "mongosh {{.URL}}"},
+
+	// Redis
+	{Name: "go", Description: "go-redis options", Engines: []string{"redis"},
+		Template: `redis.NewClient(&redis.Options{Addr: {{quote .Address}}, Password: {{quote .Password}}, DB: {{.Database}}})`},
+	{Name: "spring", Description: "Spring Boot application.properties", Engines: []string{"redis"},
+		Template: "spring.data.redis.host={{.Host}}\nspring.data.redis.port={{.Port}}{{if .Password}}\nspring.data.redis.password={{.Password}}{{end}}"},
+	{Name: "node", Description: "ioredis client", Engines: []string{"redis"},
+		Template: "const redis = new Redis({{quote .URL.String}});"},
+	{Name: "dotnet", Description: "StackExchange.Redis configuration", Engines: []string{"redis"},
+		Template: "{{.Address}}{{if .Password}},password={{.Password}}{{end}}"},
+	{Name: "cli", Description: "redis-cli command", Engines: []string{"redis"},
+		Template: "redis-cli -h {{.Host}} -p {{.Port}}{{if .Password}} -a {{.Password}}{{end}}"},
+
+	// Neo4j
+	{Name: "go", Description: "neo4j-go-driver", Engines: []string{"neo4j"},
+		Template: `neo4j.NewDriverWithContext({{quote .URL.String}}, neo4j.BasicAuth({{quote .User}}, {{quote .Password}}, ""))`},
+	{Name: "jdbc", Description: "Neo4j JDBC URL", Engines: []string{"neo4j"},
+		Template: `jdbc:neo4j:{{.URL}}?user={{urlquery .User}}&password={{urlquery .Password}}`},
+	{Name: "spring", Description: "Spring Boot application.properties", Engines: []string{"neo4j"},
+		Template: "spring.neo4j.uri={{.URL}}\nspring.neo4j.authentication.username={{.User}}\nspring.neo4j.authentication.password={{.Password}}"},
+	{Name: "node", Description: "neo4j-driver for Node.js", Engines: []string{"neo4j"},
+		Template: "const driver = neo4j.driver({{quote .URL.String}}, neo4j.auth.basic({{quote .User}}, {{quote .Password}}));"},
+	{Name: "dotnet", Description: "Neo4j .NET driver", Engines: []string{"neo4j"},
+		Template: "var driver = GraphDatabase.Driver({{quote .URL.String}}, AuthTokens.Basic({{quote .User}}, {{quote .Password}}));"},
+	{Name: "cli", Description: "cypher-shell command", Engines: []string{"neo4j"},
+		Template: "cypher-shell -a {{.URL}}{{if .User}} -u {{.User}} -p {{.Password}}{{end}}"},
+
+	// Elasticsearch
+	{Name: "go", Description: "go-elasticsearch config", Engines: []string{"elasticsearch"},
+		Template: `elasticsearch.Config{Addresses: []string{ {{- quote .URL.String -}} }}`},
+	{Name: "spring", Description: "Spring Boot application.properties", Engines: []string{"elasticsearch"},
+		Template: "spring.elasticsearch.uris={{.URL}}"},
+	{Name: "node", Description: "Elasticsearch Node.js client", Engines: []string{"elasticsearch"},
+		Template: "const client = new Client({ node: {{quote .URL.String}} });"},
+	{Name: "dotnet", Description: "Elasticsearch .NET client", Engines: []string{"elasticsearch"},
+		Template: "var client = new ElasticsearchClient(new Uri({{quote .URL.String}}));"},
+	{Name: "cli", Description: "curl health check", Engines: []string{"elasticsearch"},
+		Template: "curl{{if .User}} -u {{.User}}:{{.Password}}{{end}} {{.URL}}/_cluster/health?pretty"},
+}
+
+func djangoTemplate(engine string) string {
+	return `DATABASES = {
+    "default": {
+        "ENGINE": "` + engine + `",
+        "NAME": {{quote .Database}},
+        "USER": {{quote .User}},
+        "PASSWORD": {{quote .Password}},
+        "HOST": {{quote .Host}},
+        "PORT": "{{.Port}}",
+    }
+}`
+}
+
+// Formats devuelve los formatos incluidos que se aplican al tipo de servicio,
+// ordenados por nombre
+func Formats(engine string) []Format {
+	var result []Format
+	for _, format := range formats {
+		if format.appliesTo(engine) {
+			result = append(result, format)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// LookupFormat busca un formato incluido para el tipo de servicio
+func LookupFormat(engine, name string) (Format, bool) {
+	for _, format := range formats {
+		if format.Name == name && format.appliesTo(engine) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+func (f Format) appliesTo(engine string) bool {
+	if len(f.Engines) == 0 {
+		return true
+	}
+	for _, e := range f.Engines {
+		if e == engine {
+			return true
+		}
+	}
+	return false
+}
+
+// Render aplica la plantilla a la conexión
+func (f Format) Render(conn *Connection) (string, error) {
+	return RenderTemplate(f.Name, f.Template, conn)
+}
+
+// Format devuelve la cadena de conexión en el formato incluido indicado
+func (c *Connection) Format(name string) (string, error) {
+	format, ok := LookupFormat(c.Engine, name)
+	if !ok {
+		var names []string
+		for _, f := range Formats(c.Engine) {
+			names = append(names, f.Name)
+		}
+		return "", fmt.Errorf("unknown format %q for %s (available: %s)", name, c.Service, strings.Join(names, ", "))
+	}
+	return format.Render(c)
+}
+
+// ParseTemplate comprueba que text sea una plantilla válida
+func ParseTemplate(name, text string) error {
+	_, err := template.New(name).Funcs(formatFuncs).Parse(text)
+	return err
+}
+
+// RenderTemplate aplica una plantilla de text/template a la conexión. Además
+// de los campos de Connection se puede usar quote para entrecomillar valores.
+func RenderTemplate(name, text string, conn *Connection) (string, error) {
+	tmpl, err := template.New(name).Funcs(formatFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %s: %v", name, err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, conn); err != nil {
+		return "", fmt.Errorf("error rendering template %s: %v", name, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,102 @@
+package infracli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWaitTimeout es el tiempo máximo de WaitHealthy si el contexto no tiene plazo
+	DefaultWaitTimeout = 2 * time.Minute
+	// waitInterval es cada cuánto WaitHealthy vuelve a consultar los contenedores
+	waitInterval = 500 * time.Millisecond
+)
+
+// WaitHealthy espera a que todos los contenedores de los servicios estén en
+// ejecución y, si definen un healthcheck, sanos. Falla enseguida si un
+// contenedor termina y, si el contexto no tiene plazo, tras DefaultWaitTimeout.
+func (c *Client) WaitHealthy(ctx context.Context, services ...string) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultWaitTimeout)
+		defer cancel()
+	}
+
+	for _, service := range services {
+		if err := c.waitService(ctx, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) waitService(ctx context.Context, service string) error {
+	servicePath, err := c.servicePath(service)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(waitInterval)
+	defer ticker.Stop()
+
+	for {
+		ready, pending, err := c.serviceReady(ctx, service, servicePath)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s: %s", service, pending)
+		case <-ticker.C:
+		}
+	}
+}
+
+// serviceReady indica si los contenedores del servicio están listos y, si no,
+// qué se está esperando. Devuelve error si un contenedor ha terminado.
+func (c *Client) serviceReady(ctx context.Context, service, servicePath string) (bool, string, error) {
+	containers, err := c.docker.ServiceContainers(ctx, servicePath)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, "cannot reach the docker daemon", nil
+		}
+		return false, "", err
+	}
+	if len(containers) == 0 {
+		return false, "", fmt.Errorf("service %s has no containers; call Start first", service)
+	}
+
+	var pending []string
+	for _, container := range containers {
+		details, err := c.docker.InspectContainer(ctx, container.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, "cannot reach the docker daemon", nil
+			}
+			return false, "", err
+		}
+
+		state := details.State
+		switch {
+		case state.Running && !state.Restarting:
+			if health := state.HealthStatus(); health != "none" && health != "healthy" {
+				pending = append(pending, fmt.Sprintf("%s is %s", container.Name(), health))
+			}
+		case state.Status == "exited" || state.Status == "dead":
+			return false, "", fmt.Errorf("container %s of %s exited with code %d", container.Name(), service, state.ExitCode)
+		default:
+			pending = append(pending, fmt.Sprintf("%s is %s", container.Name(), state.Status))
+		}
+	}
+
+	if len(pending) > 0 {
+		return false, strings.Join(pending, ", "), nil
+	}
+	return true, "", nil
+}
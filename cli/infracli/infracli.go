@@ -0,0 +1,267 @@
+// Package infracli permite usar los servicios de infraestructura desde código
+// Go, con las mismas definiciones de docker-compose que 'infracli run'. Está
+// pensado para las pruebas de integración, en lugar de testcontainers:
+//
+//	func TestMain(m *testing.M) {
+//		ctx := context.Background()
+//		if err := infracli.Start(ctx, "postgres"); err != nil {
+//			log.Fatal(err)
+//		}
+//		if err := infracli.WaitHealthy(ctx, "postgres"); err != nil {
+//			log.Fatal(err)
+//		}
+//		conn, err := infracli.ConnectionInfo("postgres")
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		db, _ = sql.Open("pgx", conn.URL.String())
+//
+//		code := m.Run()
+//		infracli.Reset(ctx, "postgres")
+//		os.Exit(code)
+//	}
+//
+// La ruta de servicios se resuelve igual que en la línea de comandos:
+// $INFRACLI_SERVICES_PATH, .infracli.json, el archivo de usuario o el valor
+// por defecto. El demonio de Docker se toma de $DOCKER_HOST. Para usar otros
+// valores se crea un Client con New.
+package infracli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/stack"
+)
+
+// Options configura un Client. Los campos vacíos usan la configuración de infracli.
+type Options struct {
+	// ServicesPath es el directorio con un subdirectorio por servicio
+	ServicesPath string
+	// DockerHost es la dirección del demonio, por ejemplo unix:///var/run/docker.sock
+	DockerHost string
+	// IgnoreLock usa las imágenes del docker-compose.yml aunque exista infracli.lock
+	IgnoreLock bool
+}
+
+// Client controla los servicios de una ruta de servicios
+type Client struct {
+	servicesPath string
+	docker       *engine.Client
+	ignoreLock   bool
+}
+
+// ComposeError es un fallo de docker-compose junto con la salida que produjo
+type ComposeError struct {
+	Service string
+	Args    []string
+	Output  string
+	Err     error
+}
+
+func (e *ComposeError) Error() string {
+	return fmt.Sprintf("docker-compose %s for %s failed: %v", strings.Join(e.Args, " "), e.Service, e.Err)
+}
+
+func (e *ComposeError) Unwrap() error {
+	return e.Err
+}
+
+// New crea un Client
+func New(opts Options) (*Client, error) {
+	servicesPath := opts.ServicesPath
+	if servicesPath == "" {
+		path, err := config.GetServicesPath()
+		if err != nil {
+			return nil, err
+		}
+		servicesPath = path
+	}
+
+	var docker *engine.Client
+	var err error
+	if opts.DockerHost != "" {
+		docker, err = engine.NewClient(opts.DockerHost)
+	} else {
+		docker, err = engine.NewFromEnv()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{servicesPath: servicesPath, docker: docker, ignoreLock: opts.IgnoreLock}, nil
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *Client
+	defaultErr    error
+)
+
+// Default devuelve el Client que usan las funciones del paquete, creado con
+// las opciones por defecto la primera vez que se necesita
+func Default() (*Client, error) {
+	defaultOnce.Do(func() {
+		defaultClient, defaultErr = New(Options{})
+	})
+	return defaultClient, defaultErr
+}
+
+// Start inicia los servicios con el Client por defecto
+func Start(ctx context.Context, services ...string) error {
+	c, err := Default()
+	if err != nil {
+		return err
+	}
+	return c.Start(ctx, services...)
+}
+
+// Stop detiene los servicios con el Client por defecto
+func Stop(ctx context.Context, services ...string) error {
+	c, err := Default()
+	if err != nil {
+		return err
+	}
+	return c.Stop(ctx, services...)
+}
+
+// Reset elimina los contenedores y volúmenes de los servicios con el Client por defecto
+func Reset(ctx context.Context, services ...string) error {
+	c, err := Default()
+	if err != nil {
+		return err
+	}
+	return c.Reset(ctx, services...)
+}
+
+// WaitHealthy espera a que los servicios estén listos con el Client por defecto
+func WaitHealthy(ctx context.Context, services ...string) error {
+	c, err := Default()
+	if err != nil {
+		return err
+	}
+	return c.WaitHealthy(ctx, services...)
+}
+
+// ConnectionInfo devuelve la información de conexión de un servicio con el Client por defecto
+func ConnectionInfo(service string) (*Connection, error) {
+	c, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return c.ConnectionInfo(service)
+}
+
+// ServicesPath devuelve la ruta de servicios del Client
+func (c *Client) ServicesPath() string {
+	return c.servicesPath
+}
+
+// Services devuelve los servicios disponibles
+func (c *Client) Services() ([]string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return config.ListServices(c.servicesPath, cfg.ExcludedDirs)
+}
+
+// Start inicia los servicios con docker-compose up igual que 'infracli run':
+// genera sus credenciales si credentials.generate lo pide, usa las imágenes
+// fijadas en infracli.lock, aplica sus secretos, los une a sus redes
+// compartidas y ejecuta sus hooks. La frase de paso de los secretos se toma
+// del llavero del sistema o de $INFRACLI_SECRETS_PASSPHRASE. Solo espera a que
+// acepten clientes si tienen hooks onHealthy; para eso está WaitReady.
+func (c *Client) Start(ctx context.Context, services ...string) error {
+	opts := c.stackOptions()
+	for _, service := range services {
+		if _, err := c.servicePath(service); err != nil {
+			return err
+		}
+		err := stack.Start(service, c.servicesPath, opts, func(args ...string) error {
+			return c.compose(ctx, service, args...)
+		})
+		if err != nil {
+			return err
+		}
+
+		if stack.HasHooks(service, c.servicesPath, stack.HookOnHealthy) {
+			if err := c.WaitReady(ctx, service); err != nil {
+				return err
+			}
+			if err := stack.RunHooks(service, c.servicesPath, stack.HookOnHealthy, opts); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Stop detiene los servicios con docker-compose down, conservando sus
+// volúmenes, y ejecuta sus hooks preDown y postDown
+func (c *Client) Stop(ctx context.Context, services ...string) error {
+	for _, service := range services {
+		if err := c.down(ctx, service, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset elimina los contenedores y los volúmenes de los servicios, de modo que
+// el siguiente Start empieza con los datos iniciales
+func (c *Client) Reset(ctx context.Context, services ...string) error {
+	for _, service := range services {
+		if err := c.down(ctx, service, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) down(ctx context.Context, service string, removeVolumes bool) error {
+	if _, err := c.servicePath(service); err != nil {
+		return err
+	}
+	return stack.Stop(service, c.servicesPath, removeVolumes, c.stackOptions(), func(args ...string) error {
+		return c.compose(ctx, service, args...)
+	})
+}
+
+// stackOptions prepara los servicios sin terminal y falla si infracli.lock no
+// está al día, para que las pruebas no usen otras imágenes sin avisar. Cada
+// operación usa sus propias opciones para desbloquear el almacén una vez.
+func (c *Client) stackOptions() stack.Options {
+	return stack.Options{IgnoreLock: c.ignoreLock, StrictLock: true, Docker: c.docker, HookEnv: HookEnv, Secrets: &stack.SecretsCache{}}
+}
+
+// servicePath devuelve el directorio del servicio y comprueba que tenga docker-compose.yml
+func (c *Client) servicePath(service string) (string, error) {
+	path := filepath.Join(c.servicesPath, service)
+	if _, err := os.Stat(filepath.Join(path, compose.FileName)); err != nil {
+		return "", fmt.Errorf("service '%s' not found in %s", service, c.servicesPath)
+	}
+	return path, nil
+}
+
+func (c *Client) compose(ctx context.Context, service string, args ...string) error {
+	servicePath, err := c.servicePath(service)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "docker-compose", args...)
+	cmd.Dir = servicePath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return &ComposeError{Service: service, Args: args, Output: strings.TrimSpace(string(output)), Err: err}
+	}
+	return nil
+}
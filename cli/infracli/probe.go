@@ -0,0 +1,76 @@
+package infracli
+
+import (
+	"context"
+
+	"github.com/solrac97gr/infrastructure/infracli/probe"
+)
+
+// Probes devuelve las comprobaciones con las que se sabe desde el host si el
+// servicio acepta clientes, hablando su protocolo. Los servicios que infracli
+// no conoce solo comprueban que su puerto acepte conexiones.
+func (c *Connection) Probes() []probe.Target {
+	target := probe.Target{Name: c.Service, Address: c.Address(), User: c.User, Password: c.Password, Database: c.Database}
+
+	switch c.Engine {
+	case "mysql":
+		target.Protocol = probe.MySQL
+	case "postgres":
+		target.Protocol = probe.Postgres
+	case "mongo":
+		target.Protocol = probe.Mongo
+	case "redis":
+		target.Protocol = probe.Redis
+		// La contraseña de requirepass es la del usuario por defecto
+		target.User = ""
+	case "neo4j":
+		target.Protocol = probe.Bolt
+	case "elasticsearch":
+		target.Name = "elasticsearch"
+		target.Protocol = probe.Elasticsearch
+		targets := []probe.Target{target}
+		if kibana := c.URLs["kibana"]; kibana != nil {
+			targets = append(targets, probe.Target{Name: "kibana", Protocol: probe.Kibana, Address: kibana.Host, User: c.User, Password: c.Password})
+		}
+		return targets
+	default:
+		if c.Port == 0 {
+			return nil
+		}
+		target.Protocol = probe.TCP
+	}
+	return []probe.Target{target}
+}
+
+// WaitReady espera a que los servicios acepten clientes según sus Probes.
+// Complementa a WaitHealthy con los servicios sin healthcheck y comprueba
+// además los puertos publicados. Si el contexto no tiene plazo se usa
+// DefaultWaitTimeout.
+func (c *Client) WaitReady(ctx context.Context, services ...string) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultWaitTimeout)
+		defer cancel()
+	}
+
+	var targets []probe.Target
+	for _, service := range services {
+		conn, err := c.ConnectionInfo(service)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, conn.Probes()...)
+	}
+
+	_, err := probe.Wait(ctx, targets, probe.DefaultInterval)
+	return err
+}
+
+// WaitReady espera a que los servicios acepten clientes con el Client por defecto
+func WaitReady(ctx context.Context, services ...string) error {
+	c, err := Default()
+	if err != nil {
+		return err
+	}
+	return c.WaitReady(ctx, services...)
+}
@@ -0,0 +1,152 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/registry"
+)
+
+const (
+	// FileName es el nombre del lockfile, guardado en la raíz de la ruta de servicios
+	FileName = "infracli.lock"
+	// CurrentVersion es la versión del formato del lockfile
+	CurrentVersion = 1
+)
+
+// Entry es la imagen fijada para un contenedor de un servicio
+type Entry struct {
+	// Image es la imagen tal como aparece en el docker-compose.yml
+	Image string `json:"image"`
+	// Digest es el digest al que apuntaba la imagen al actualizar el lockfile
+	Digest string `json:"digest"`
+}
+
+// File es el contenido del lockfile: servicio -> contenedor -> imagen fijada
+type File struct {
+	Version  int                         `json:"version"`
+	Services map[string]map[string]Entry `json:"services"`
+}
+
+// New devuelve un lockfile vacío
+func New() *File {
+	return &File{Version: CurrentVersion, Services: make(map[string]map[string]Entry)}
+}
+
+// Path devuelve la ruta del lockfile para una ruta de servicios
+func Path(basePath string) string {
+	return filepath.Join(basePath, FileName)
+}
+
+// Load lee un lockfile. Si no existe devuelve el error de os.ReadFile,
+// que se puede comprobar con os.IsNotExist.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := New()
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if file.Version > CurrentVersion {
+		return nil, fmt.Errorf("%s has version %d, but this infracli only supports up to version %d", path, file.Version, CurrentVersion)
+	}
+	if file.Services == nil {
+		file.Services = make(map[string]map[string]Entry)
+	}
+	return file, nil
+}
+
+// Save escribe el lockfile con las claves ordenadas para que los cambios
+// se puedan revisar en un diff
+func (f *File) Save(path string) error {
+	f.Version = CurrentVersion
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding lock file: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// Lookup devuelve la entrada de un contenedor de un servicio
+func (f *File) Lookup(service, container string) (Entry, bool) {
+	entry, ok := f.Services[service][container]
+	return entry, ok
+}
+
+// Set guarda la entrada de un contenedor de un servicio
+func (f *File) Set(service, container string, entry Entry) {
+	if f.Services[service] == nil {
+		f.Services[service] = make(map[string]Entry)
+	}
+	f.Services[service][container] = entry
+}
+
+// Pinned devuelve la referencia con la que se debe usar la imagen: el nombre
+// de la imagen seguido del digest fijado, por ejemplo mongo@sha256:...
+func (e Entry) Pinned() (string, error) {
+	ref, err := registry.ParseReference(e.Image)
+	if err != nil {
+		return "", err
+	}
+	return ref.Name() + "@" + e.Digest, nil
+}
+
+// Pins devuelve la referencia fijada de cada contenedor de un servicio a partir
+// de sus imágenes en el docker-compose.yml (contenedor -> imagen). Los
+// contenedores cuya imagen cambió respecto al lockfile se devuelven en stale
+// y no se fijan.
+func (f *File) Pins(service string, images map[string]string) (pins map[string]string, stale []string) {
+	pins = make(map[string]string)
+	for container, image := range images {
+		entry, ok := f.Lookup(service, container)
+		if !ok {
+			continue
+		}
+		if entry.Image != image {
+			stale = append(stale, container)
+			continue
+		}
+		if pinned, err := entry.Pinned(); err == nil {
+			pins[container] = pinned
+		}
+	}
+	sort.Strings(stale)
+	return pins, stale
+}
+
+// WriteOverride escribe en dir un archivo de compose que sustituye la imagen
+// de cada contenedor por su referencia fijada y devuelve su ruta. Se usa como
+// segundo -f de docker-compose para no modificar el docker-compose.yml.
+func WriteOverride(dir, service string, pins map[string]string) (string, error) {
+	containers := make([]string, 0, len(pins))
+	for container := range pins {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	var override strings.Builder
+	override.WriteString("services:\n")
+	for _, container := range containers {
+		fmt.Fprintf(&override, "  %s:\n    image: %s\n", container, pins[container])
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating %s: %v", dir, err)
+	}
+	path := filepath.Join(dir, service+".lock.yml")
+	if err := os.WriteFile(path, []byte(override.String()), 0644); err != nil {
+		return "", fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Options configura el logger global
+type Options struct {
+	// Level es el nivel mínimo: debug, info, warn o error
+	Level string
+	// Quiet muestra solo errores, sin importar Level
+	Quiet bool
+	// Format es el formato de salida: text o json
+	Format string
+	// Output es el destino de los logs, os.Stderr por defecto
+	Output io.Writer
+}
+
+var (
+	level  = new(slog.LevelVar)
+	logger = slog.New(newTextHandler(os.Stderr, level))
+)
+
+// Setup configura el logger global. Todos los logs van a stderr para que
+// stdout quede libre para los datos que producen los comandos.
+func Setup(opts Options) error {
+	lvl, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	if opts.Quiet {
+		lvl = slog.LevelError
+	}
+	level.Set(lvl)
+
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+
+	switch strings.ToLower(opts.Format) {
+	case "", "text":
+		logger = slog.New(newTextHandler(out, level))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level}))
+	default:
+		return fmt.Errorf("invalid log format %q (valid formats: text, json)", opts.Format)
+	}
+
+	return nil
+}
+
+// ParseLevel convierte el nombre de un nivel en un slog.Level
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q (valid levels: debug, info, warn, error)", name)
+	}
+}
+
+// Redirect envía los logs en formato texto a out, conservando el nivel
+// configurado, hasta que se llame a la función devuelta
+func Redirect(out io.Writer) (restore func()) {
+	previous := logger
+	logger = slog.New(newTextHandler(out, level))
+	return func() {
+		logger = previous
+	}
+}
+
+// L devuelve el logger global para registrar atributos estructurados
+func L() *slog.Logger {
+	return logger
+}
+
+// Enabled indica si los mensajes del nivel indicado se van a mostrar
+func Enabled(lvl slog.Level) bool {
+	return logger.Enabled(context.Background(), lvl)
+}
+
+// Debugf registra un mensaje de depuración
+func Debugf(format string, args ...interface{}) {
+	logger.Debug(fmt.Sprintf(format, args...))
+}
+
+// Infof registra un mensaje informativo
+func Infof(format string, args ...interface{}) {
+	logger.Info(fmt.Sprintf(format, args...))
+}
+
+// Warnf registra una advertencia
+func Warnf(format string, args ...interface{}) {
+	logger.Warn(fmt.Sprintf(format, args...))
+}
+
+// Errorf registra un error
+func Errorf(format string, args ...interface{}) {
+	logger.Error(fmt.Sprintf(format, args...))
+}
+
+// Writer devuelve un io.WriteCloser que registra cada línea escrita con el
+// nivel indicado. Sirve para redirigir la salida de procesos externos al
+// logger; Close registra la última línea si no terminaba en salto de línea.
+func Writer(lvl slog.Level, attrs ...interface{}) io.WriteCloser {
+	return &lineWriter{level: lvl, attrs: attrs}
+}
+
+// lineWriter acumula la salida hasta completar cada línea
+type lineWriter struct {
+	mu    sync.Mutex
+	level slog.Level
+	attrs []interface{}
+	buf   []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.log(string(w.buf))
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *lineWriter) log(line string) {
+	line = strings.TrimRight(line, "\r")
+	if line != "" {
+		logger.Log(context.Background(), w.level, line, w.attrs...)
+	}
+}
+
+// textHandler escribe los mensajes de forma legible para las personas:
+// el mensaje seguido de los atributos como clave=valor
+type textHandler struct {
+	mu    *sync.Mutex
+	out   io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+func newTextHandler(out io.Writer, level slog.Leveler) *textHandler {
+	return &textHandler{mu: &sync.Mutex{}, out: out, level: level}
+}
+
+func (h *textHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return lvl >= h.level.Level()
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	buf.WriteString(r.Message)
+
+	writeAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&buf, " %s=%v", a.Key, a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.out.Write(buf.Bytes())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	// Los grupos no se representan en el formato de texto
+	return h
+}
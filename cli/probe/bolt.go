@@ -0,0 +1,37 @@
+package probe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// boltMagic abre la negociación de Bolt
+var boltMagic = []byte{0x60, 0x60, 0xB0, 0x17}
+
+// boltVersions son las versiones propuestas, de la preferida a la menos:
+// cada una es [reservado, rango, menor, mayor], así 5.4 a 5.0, 4.4 a 4.2, 4.1 y 3
+var boltVersions = []uint32{0x00040405, 0x00020404, 0x00000104, 0x00000003}
+
+// checkBolt negocia la versión de Bolt. El servidor responde con la versión
+// elegida, o con cero si no admite ninguna.
+func checkBolt(conn net.Conn) (string, error) {
+	handshake := make([]byte, 4, 20)
+	copy(handshake, boltMagic)
+	for _, version := range boltVersions {
+		handshake = binary.BigEndian.AppendUint32(handshake, version)
+	}
+	if _, err := conn.Write(handshake); err != nil {
+		return "", fmt.Errorf("error sending Bolt handshake: %v", err)
+	}
+
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return "", fmt.Errorf("error reading Bolt handshake: %v", err)
+	}
+	if binary.BigEndian.Uint32(reply) == 0 {
+		return "", fmt.Errorf("the server supports none of Bolt 3 to 5.4")
+	}
+	return fmt.Sprintf("Bolt %d.%d", reply[3], reply[2]), nil
+}
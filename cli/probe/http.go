@@ -0,0 +1,98 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// checkElasticsearch consulta /_cluster/health. El estado red significa que
+// faltan shards primarios, así que el clúster todavía no está listo.
+func checkElasticsearch(ctx context.Context, target Target) (string, error) {
+	var health struct {
+		ClusterName string `json:"cluster_name"`
+		Status      string `json:"status"`
+	}
+	status, err := getJSON(ctx, target, "/_cluster/health", &health)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case status == http.StatusUnauthorized:
+		return "", fmt.Errorf("authentication failed")
+	case status != http.StatusOK:
+		return "", notReady("HTTP %d", status)
+	case health.Status == "red":
+		return "", notReady("cluster %s is red", health.ClusterName)
+	}
+	return fmt.Sprintf("cluster %s is %s", health.ClusterName, health.Status), nil
+}
+
+// checkKibana consulta /api/status. Kibana 8 informa del nivel general
+// ("available") y Kibana 7 de su estado ("green"); mientras arranca responde 503.
+func checkKibana(ctx context.Context, target Target) (string, error) {
+	var response struct {
+		Version struct {
+			Number string `json:"number"`
+		} `json:"version"`
+		Status struct {
+			Overall struct {
+				Level string `json:"level"`
+				State string `json:"state"`
+			} `json:"overall"`
+		} `json:"status"`
+	}
+	status, err := getJSON(ctx, target, "/api/status", &response)
+	if err != nil {
+		return "", err
+	}
+	if status == http.StatusUnauthorized {
+		return "", fmt.Errorf("authentication failed")
+	}
+
+	overall := response.Status.Overall
+	level := overall.Level
+	if level == "" {
+		level = overall.State
+	}
+	if status != http.StatusOK || (level != "available" && level != "green") {
+		if level == "" {
+			return "", notReady("HTTP %d", status)
+		}
+		return "", notReady("status is %s", level)
+	}
+	return fmt.Sprintf("Kibana %s is %s", response.Version.Number, level), nil
+}
+
+// getJSON hace una petición GET al servicio y decodifica la respuesta si es
+// JSON. Devuelve el código de estado también cuando no es 200.
+func getJSON(ctx context.Context, target Target, path string, v interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+target.Address+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	if target.User != "" {
+		req.SetBasicAuth(target.User, target.Password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		// Una respuesta de error puede no tener la forma esperada
+		if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+			return resp.StatusCode, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,178 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+)
+
+const (
+	// opMsg es el código de operación de OP_MSG, disponible desde MongoDB 3.6
+	opMsg = 2013
+	// maxMongoMessage limita el tamaño de la respuesta que se acepta
+	maxMongoMessage = 16 << 20
+)
+
+// checkMongo envía el comando hello, que no necesita autenticación, y
+// comprueba que la respuesta tenga ok: 1
+func checkMongo(conn net.Conn) (string, error) {
+	var doc bsonDocument
+	doc.int32("hello", 1)
+	doc.string("$db", "admin")
+	body := doc.bytes()
+
+	// Cabecera: longitud, requestID, responseTo y código de operación;
+	// después los flags y una sección de tipo 0 con el documento
+	message := make([]byte, 21, 21+len(body))
+	binary.LittleEndian.PutUint32(message[0:4], uint32(21+len(body)))
+	binary.LittleEndian.PutUint32(message[4:8], 1)
+	binary.LittleEndian.PutUint32(message[12:16], opMsg)
+	message = append(message, body...)
+	if _, err := conn.Write(message); err != nil {
+		return "", fmt.Errorf("error sending hello: %v", err)
+	}
+
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", fmt.Errorf("error reading hello reply: %v", err)
+	}
+	length := int(binary.LittleEndian.Uint32(header[0:4]))
+	if length < 16 || length > maxMongoMessage {
+		return "", fmt.Errorf("invalid reply length %d", length)
+	}
+	if opCode := binary.LittleEndian.Uint32(header[12:16]); opCode != opMsg {
+		return "", fmt.Errorf("unexpected reply opcode %d", opCode)
+	}
+	reply := make([]byte, length-16)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return "", fmt.Errorf("error reading hello reply: %v", err)
+	}
+	// Flags de 4 bytes y el tipo de la sección
+	if len(reply) < 5 || reply[4] != 0 {
+		return "", fmt.Errorf("unexpected hello reply")
+	}
+
+	fields, err := parseBSON(reply[5:])
+	if err != nil {
+		return "", fmt.Errorf("error parsing hello reply: %v", err)
+	}
+	if ok, _ := fields["ok"].(float64); ok != 1 {
+		message, _ := fields["errmsg"].(string)
+		return "", notReady("hello failed: %s", message)
+	}
+
+	detail := "MongoDB accepting connections"
+	if version, ok := fields["maxWireVersion"].(int32); ok {
+		detail += fmt.Sprintf(" (wire version %d)", version)
+	}
+	return detail, nil
+}
+
+// bsonDocument construye un documento BSON con los pocos tipos que se envían
+type bsonDocument struct {
+	elements bytes.Buffer
+}
+
+func (d *bsonDocument) int32(name string, value int32) {
+	d.elements.WriteByte(0x10)
+	d.elements.WriteString(name)
+	d.elements.WriteByte(0)
+	binary.Write(&d.elements, binary.LittleEndian, value)
+}
+
+func (d *bsonDocument) string(name, value string) {
+	d.elements.WriteByte(0x02)
+	d.elements.WriteString(name)
+	d.elements.WriteByte(0)
+	binary.Write(&d.elements, binary.LittleEndian, int32(len(value)+1))
+	d.elements.WriteString(value)
+	d.elements.WriteByte(0)
+}
+
+func (d *bsonDocument) bytes() []byte {
+	doc := make([]byte, 4, 5+d.elements.Len())
+	binary.LittleEndian.PutUint32(doc, uint32(5+d.elements.Len()))
+	doc = append(doc, d.elements.Bytes()...)
+	return append(doc, 0)
+}
+
+// parseBSON lee los campos del primer nivel de un documento. Devuelve los
+// double, string, bool, int32 e int64; los demás tipos se saltan.
+func parseBSON(doc []byte) (map[string]interface{}, error) {
+	if len(doc) < 5 {
+		return nil, fmt.Errorf("document too short")
+	}
+	length := int(binary.LittleEndian.Uint32(doc[0:4]))
+	if length > len(doc) || length < 5 {
+		return nil, fmt.Errorf("invalid document length %d", length)
+	}
+
+	fields := make(map[string]interface{})
+	data := doc[4 : length-1]
+	for len(data) > 0 {
+		kind := data[0]
+		name, rest, found := bytes.Cut(data[1:], []byte{0})
+		if !found {
+			return nil, fmt.Errorf("unterminated field name")
+		}
+
+		var size int
+		switch kind {
+		case 0x01: // double
+			size = 8
+			if len(rest) >= size {
+				fields[string(name)] = math.Float64frombits(binary.LittleEndian.Uint64(rest))
+			}
+		case 0x02, 0x0D, 0x0E: // string, JavaScript, símbolo
+			if len(rest) < 4 {
+				return nil, fmt.Errorf("truncated field %s", name)
+			}
+			size = 4 + int(binary.LittleEndian.Uint32(rest))
+			if kind == 0x02 && len(rest) >= size && size > 4 {
+				fields[string(name)] = string(rest[4 : size-1])
+			}
+		case 0x03, 0x04: // documento, array
+			if len(rest) < 4 {
+				return nil, fmt.Errorf("truncated field %s", name)
+			}
+			size = int(binary.LittleEndian.Uint32(rest))
+		case 0x05: // binario: longitud, subtipo y datos
+			if len(rest) < 4 {
+				return nil, fmt.Errorf("truncated field %s", name)
+			}
+			size = 5 + int(binary.LittleEndian.Uint32(rest))
+		case 0x07: // ObjectId
+			size = 12
+		case 0x08: // bool
+			size = 1
+			if len(rest) >= size {
+				fields[string(name)] = rest[0] == 1
+			}
+		case 0x09, 0x11, 0x12: // fecha, timestamp, int64
+			size = 8
+			if kind == 0x12 && len(rest) >= size {
+				fields[string(name)] = int64(binary.LittleEndian.Uint64(rest))
+			}
+		case 0x0A, 0x06, 0xFF, 0x7F: // null, undefined, minKey, maxKey
+			size = 0
+		case 0x10: // int32
+			size = 4
+			if len(rest) >= size {
+				fields[string(name)] = int32(binary.LittleEndian.Uint32(rest))
+			}
+		case 0x13: // decimal128
+			size = 16
+		default:
+			return nil, fmt.Errorf("unsupported BSON type 0x%02x", kind)
+		}
+
+		if size < 0 || size > len(rest) {
+			return nil, fmt.Errorf("truncated field %s", name)
+		}
+		data = rest[size:]
+	}
+	return fields, nil
+}
@@ -0,0 +1,205 @@
+// Package probe comprueba desde el host que un servicio está listo hablando su
+// propio protocolo: el saludo de MySQL, el mensaje de inicio de PostgreSQL,
+// el comando hello de MongoDB, PING de Redis, la salud del clúster de
+// Elasticsearch, el estado de Kibana o la negociación de Bolt de Neo4j. A
+// diferencia de los healthchecks de Docker, así se comprueba también que el
+// puerto publicado llega al servicio.
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Protocol es el protocolo con el que se comprueba un servicio
+type Protocol string
+
+const (
+	MySQL         Protocol = "mysql"
+	Postgres      Protocol = "postgres"
+	Mongo         Protocol = "mongo"
+	Redis         Protocol = "redis"
+	Elasticsearch Protocol = "elasticsearch"
+	Kibana        Protocol = "kibana"
+	Bolt          Protocol = "bolt"
+	// TCP solo comprueba que el puerto acepta conexiones
+	TCP Protocol = "tcp"
+)
+
+const (
+	// DefaultTimeout es el plazo de una comprobación si el contexto no tiene otro
+	DefaultTimeout = 5 * time.Second
+	// DefaultInterval es cada cuánto Wait repite las comprobaciones pendientes
+	DefaultInterval = time.Second
+)
+
+// Target es un servicio que se comprueba
+type Target struct {
+	// Name identifica la comprobación, por ejemplo "mysql" o "kibana"
+	Name     string
+	Protocol Protocol
+	// Address es host:puerto
+	Address  string
+	User     string
+	Password string
+	Database string
+}
+
+// Result es el resultado de una comprobación
+type Result struct {
+	Target Target
+	// Err es nil si el servicio está listo
+	Err error
+	// Detail describe lo que respondió el servicio, por ejemplo su versión
+	Detail   string
+	Duration time.Duration
+}
+
+// Ready indica si el servicio está listo
+func (r Result) Ready() bool {
+	return r.Err == nil
+}
+
+// ErrNotReady indica que el servicio responde pero todavía no acepta clientes,
+// por ejemplo mientras PostgreSQL arranca o Redis carga sus datos
+var ErrNotReady = errors.New("not ready")
+
+// notReady devuelve un error que cumple errors.Is(err, ErrNotReady)
+func notReady(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrNotReady, fmt.Sprintf(format, args...))
+}
+
+// Run comprueba un servicio una vez
+func Run(ctx context.Context, target Target) Result {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	detail, err := run(ctx, target)
+	return Result{Target: target, Err: err, Detail: detail, Duration: time.Since(start)}
+}
+
+func run(ctx context.Context, target Target) (string, error) {
+	switch target.Protocol {
+	case Elasticsearch:
+		return checkElasticsearch(ctx, target)
+	case Kibana:
+		return checkKibana(ctx, target)
+	}
+
+	conn, err := dial(ctx, target.Address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	switch target.Protocol {
+	case MySQL:
+		return checkMySQL(conn)
+	case Postgres:
+		return checkPostgres(conn, target)
+	case Mongo:
+		return checkMongo(conn)
+	case Redis:
+		return checkRedis(conn, target)
+	case Bolt:
+		return checkBolt(conn)
+	case TCP:
+		return "accepting connections", nil
+	default:
+		return "", fmt.Errorf("unknown protocol %q", target.Protocol)
+	}
+}
+
+// dial abre una conexión TCP que respeta el plazo del contexto también en
+// las lecturas y escrituras
+func dial(ctx context.Context, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// RunAll comprueba varios servicios a la vez y devuelve los resultados en el
+// mismo orden
+func RunAll(ctx context.Context, targets []Target) []Result {
+	results := make([]Result, len(targets))
+	done := make(chan struct{})
+	for i, target := range targets {
+		go func(i int, target Target) {
+			results[i] = Run(ctx, target)
+			done <- struct{}{}
+		}(i, target)
+	}
+	for range targets {
+		<-done
+	}
+	return results
+}
+
+// Wait repite las comprobaciones cada interval hasta que todos los servicios
+// estén listos o venza el contexto. Devuelve los últimos resultados y, si no
+// todos están listos, un error con el primero que falla.
+func Wait(ctx context.Context, targets []Target, interval time.Duration) ([]Result, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last []Result
+	for {
+		// Cada intento tiene su propio plazo para no agotar el de la espera
+		attemptCtx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+		results := RunAll(attemptCtx, targets)
+		cancel()
+
+		// Un intento cortado por el fin de la espera solo informa del plazo;
+		// el anterior explica mejor por qué el servicio no está listo
+		if expired(ctx) && last != nil && firstFailure(results) != nil {
+			results = last
+		}
+		last = results
+
+		pending := firstFailure(results)
+		if pending == nil {
+			return results, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return results, fmt.Errorf("timed out waiting for %s: %v", pending.Target.Name, pending.Err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// expired indica si venció el plazo del contexto. Las conexiones fallan al
+// llegar el plazo aunque el contexto todavía no se haya cancelado.
+func expired(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}
+
+func firstFailure(results []Result) *Result {
+	for i := range results {
+		if !results[i].Ready() {
+			return &results[i]
+		}
+	}
+	return nil
+}
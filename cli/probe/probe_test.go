@@ -0,0 +1,295 @@
+package probe
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Estados esperados de una comprobación
+const (
+	ready    = "ready"
+	starting = "starting"
+	failed   = "failed"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol Protocol
+		serve    func(t *testing.T) string
+		want     string
+		detail   string
+	}{
+		{"mysql handshake", MySQL, tcpServer(func(conn net.Conn) {
+			writeMySQLPacket(conn, append([]byte{10}, "8.0.36\x00rest-of-handshake"...))
+		}), ready, "MySQL 8.0.36"},
+		{"mysql error packet", MySQL, tcpServer(func(conn net.Conn) {
+			writeMySQLPacket(conn, append([]byte{0xff, 0x10, 0x04}, "#08004Too many connections"...))
+		}), starting, "Too many connections"},
+		{"mysql error packet without sqlstate", MySQL, tcpServer(func(conn net.Conn) {
+			writeMySQLPacket(conn, append([]byte{0xff, 0x10, 0x04}, "Host is blocked"...))
+		}), starting, "Host is blocked"},
+		{"mysql truncated error packet", MySQL, tcpServer(func(conn net.Conn) {
+			writeMySQLPacket(conn, []byte{0xff, 0x10})
+		}), failed, "truncated error packet"},
+
+		{"postgres authentication request", Postgres, tcpServer(func(conn net.Conn) {
+			readPostgresStartup(conn)
+			writePostgresMessage(conn, 'R', []byte{0, 0, 0, 3})
+		}), ready, "accepting connections"},
+		{"postgres starting up", Postgres, tcpServer(func(conn net.Conn) {
+			readPostgresStartup(conn)
+			writePostgresError(conn, "57P03", "the database system is starting up")
+		}), starting, "the database system is starting up"},
+		{"postgres invalid authorization", Postgres, tcpServer(func(conn net.Conn) {
+			readPostgresStartup(conn)
+			writePostgresError(conn, "28000", "role \"probe\" does not exist")
+		}), ready, "accepting connections"},
+		{"postgres invalid password", Postgres, tcpServer(func(conn net.Conn) {
+			readPostgresStartup(conn)
+			writePostgresError(conn, "28P01", "password authentication failed")
+		}), ready, "accepting connections"},
+		{"postgres other error", Postgres, tcpServer(func(conn net.Conn) {
+			readPostgresStartup(conn)
+			writePostgresError(conn, "53300", "too many clients")
+		}), failed, "too many clients (53300)"},
+
+		{"mongo hello ok", Mongo, tcpServer(func(conn net.Conn) {
+			readMongoMessage(conn)
+			var doc bsonDocument
+			bsonDouble(&doc, "ok", 1)
+			doc.int32("maxWireVersion", 21)
+			writeMongoReply(conn, doc.bytes())
+		}), ready, "wire version 21"},
+		{"mongo hello not ok", Mongo, tcpServer(func(conn net.Conn) {
+			readMongoMessage(conn)
+			var doc bsonDocument
+			bsonDouble(&doc, "ok", 0)
+			doc.string("errmsg", "node is recovering")
+			writeMongoReply(conn, doc.bytes())
+		}), starting, "node is recovering"},
+
+		{"redis pong", Redis, redisServer("+PONG"), ready, "Redis PONG"},
+		{"redis loading", Redis, redisServer("-LOADING Redis is loading the dataset in memory"), starting, "LOADING"},
+		{"redis noauth", Redis, redisServer("-NOAUTH Authentication required."), failed, "requires a password"},
+
+		{"bolt version", Bolt, tcpServer(func(conn net.Conn) {
+			io.ReadFull(conn, make([]byte, 20))
+			conn.Write([]byte{0, 0, 4, 5})
+		}), ready, "Bolt 5.4"},
+		{"bolt no version", Bolt, tcpServer(func(conn net.Conn) {
+			io.ReadFull(conn, make([]byte, 20))
+			conn.Write([]byte{0, 0, 0, 0})
+		}), failed, "supports none"},
+
+		{"elasticsearch green", Elasticsearch, httpServer("/_cluster/health", http.StatusOK,
+			`{"cluster_name":"docker-cluster","status":"green"}`), ready, "cluster docker-cluster is green"},
+		{"elasticsearch yellow", Elasticsearch, httpServer("/_cluster/health", http.StatusOK,
+			`{"cluster_name":"docker-cluster","status":"yellow"}`), ready, "cluster docker-cluster is yellow"},
+		{"elasticsearch red", Elasticsearch, httpServer("/_cluster/health", http.StatusOK,
+			`{"cluster_name":"docker-cluster","status":"red"}`), starting, "cluster docker-cluster is red"},
+		{"elasticsearch unauthorized", Elasticsearch, httpServer("/_cluster/health", http.StatusUnauthorized,
+			`{"error":"missing authentication credentials"}`), failed, "authentication failed"},
+
+		{"kibana 7 green", Kibana, httpServer("/api/status", http.StatusOK,
+			`{"version":{"number":"7.17.18"},"status":{"overall":{"state":"green"}}}`), ready, "Kibana 7.17.18 is green"},
+		{"kibana 7 red", Kibana, httpServer("/api/status", http.StatusServiceUnavailable,
+			`{"version":{"number":"7.17.18"},"status":{"overall":{"state":"red"}}}`), starting, "status is red"},
+		{"kibana 8 available", Kibana, httpServer("/api/status", http.StatusOK,
+			`{"version":{"number":"8.12.2"},"status":{"overall":{"level":"available"}}}`), ready, "Kibana 8.12.2 is available"},
+		{"kibana 8 unavailable", Kibana, httpServer("/api/status", http.StatusServiceUnavailable,
+			`{"version":{"number":"8.12.2"},"status":{"overall":{"level":"unavailable"}}}`), starting, "status is unavailable"},
+		{"kibana not ready yet", Kibana, httpServer("/api/status", http.StatusServiceUnavailable,
+			`Kibana server is not ready yet`), starting, "HTTP 503"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			result := Run(ctx, Target{Name: tt.name, Protocol: tt.protocol, Address: tt.serve(t)})
+
+			got, message := ready, result.Detail
+			if result.Err != nil {
+				got, message = failed, result.Err.Error()
+				if errors.Is(result.Err, ErrNotReady) {
+					got = starting
+				}
+			}
+			if got != tt.want {
+				t.Fatalf("got %s (%s), want %s", got, message, tt.want)
+			}
+			if !strings.Contains(message, tt.detail) {
+				t.Errorf("got %q, want it to contain %q", message, tt.detail)
+			}
+		})
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	address := redisServer("-LOADING Redis is loading the dataset in memory")(t)
+	targets := []Target{{Name: "redis", Protocol: Redis, Address: address}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	results, err := Wait(ctx, targets, 50*time.Millisecond)
+
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if !strings.Contains(err.Error(), "timed out waiting for redis") || !strings.Contains(err.Error(), "LOADING") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Wait returned after %s, long after its deadline", elapsed)
+	}
+	if len(results) != 1 || !errors.Is(results[0].Err, ErrNotReady) {
+		t.Errorf("expected the last result to be not ready, got %+v", results)
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	targets := []Target{
+		{Name: "redis", Protocol: Redis, Address: redisServer("+PONG")(t)},
+		{Name: "tcp", Protocol: TCP, Address: tcpServer(func(net.Conn) {})(t)},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	results, err := Wait(ctx, targets, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if !result.Ready() {
+			t.Errorf("%s is not ready: %v", result.Target.Name, result.Err)
+		}
+	}
+}
+
+// tcpServer devuelve un servidor que atiende cada conexión con handle
+func tcpServer(handle func(conn net.Conn)) func(t *testing.T) string {
+	return func(t *testing.T) string {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { listener.Close() })
+
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					handle(conn)
+				}()
+			}
+		}()
+		return listener.Addr().String()
+	}
+}
+
+// httpServer devuelve un servidor que responde JSON en path
+func httpServer(path string, status int, body string) func(t *testing.T) string {
+	return func(t *testing.T) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != path {
+				http.NotFound(w, r)
+				return
+			}
+			if strings.HasPrefix(body, "{") {
+				w.Header().Set("Content-Type", "application/json")
+			}
+			w.WriteHeader(status)
+			io.WriteString(w, body)
+		}))
+		t.Cleanup(server.Close)
+		return strings.TrimPrefix(server.URL, "http://")
+	}
+}
+
+// redisServer devuelve un servidor que responde reply a cada comando
+func redisServer(reply string) func(t *testing.T) string {
+	return tcpServer(func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		for {
+			// Un comando es un array de N bulk strings: N pares de líneas
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+			for i := 0; i < 2*n; i++ {
+				if _, err := reader.ReadString('\n'); err != nil {
+					return
+				}
+			}
+			io.WriteString(conn, reply+"\r\n")
+		}
+	})
+}
+
+func writeMySQLPacket(conn net.Conn, payload []byte) {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), 0}
+	conn.Write(append(header, payload...))
+}
+
+func readPostgresStartup(conn net.Conn) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	io.ReadFull(conn, make([]byte, binary.BigEndian.Uint32(header)-4))
+}
+
+func writePostgresMessage(conn net.Conn, kind byte, body []byte) {
+	message := []byte{kind}
+	message = binary.BigEndian.AppendUint32(message, uint32(4+len(body)))
+	conn.Write(append(message, body...))
+}
+
+func writePostgresError(conn net.Conn, code, message string) {
+	body := "SFATAL\x00C" + code + "\x00M" + message + "\x00\x00"
+	writePostgresMessage(conn, 'E', []byte(body))
+}
+
+func readMongoMessage(conn net.Conn) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	io.ReadFull(conn, make([]byte, binary.LittleEndian.Uint32(header)-4))
+}
+
+// writeMongoReply envía un OP_MSG con una sección de tipo 0 con el documento
+func writeMongoReply(conn net.Conn, doc []byte) {
+	message := make([]byte, 21, 21+len(doc))
+	binary.LittleEndian.PutUint32(message[0:4], uint32(21+len(doc)))
+	binary.LittleEndian.PutUint32(message[4:8], 2)
+	binary.LittleEndian.PutUint32(message[8:12], 1)
+	binary.LittleEndian.PutUint32(message[12:16], opMsg)
+	conn.Write(append(message, doc...))
+}
+
+func bsonDouble(d *bsonDocument, name string, value float64) {
+	d.elements.WriteByte(0x01)
+	d.elements.WriteString(name)
+	d.elements.WriteByte(0)
+	d.elements.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(value)))
+}
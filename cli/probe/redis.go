@@ -0,0 +1,61 @@
+package probe
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// checkRedis se autentica si hay contraseña y envía PING
+func checkRedis(conn net.Conn, target Target) (string, error) {
+	reader := bufio.NewReader(conn)
+
+	if target.Password != "" {
+		args := []string{"AUTH", target.Password}
+		if target.User != "" {
+			args = []string{"AUTH", target.User, target.Password}
+		}
+		reply, err := redisCommand(conn, reader, args...)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(reply, "-") {
+			return "", fmt.Errorf("authentication failed: %s", strings.TrimPrefix(reply, "-"))
+		}
+	}
+
+	reply, err := redisCommand(conn, reader, "PING")
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case reply == "+PONG":
+		return "Redis PONG", nil
+	// Mientras carga el AOF o el RDB responde -LOADING
+	case strings.HasPrefix(reply, "-LOADING"), strings.HasPrefix(reply, "-BUSY"), strings.HasPrefix(reply, "-MASTERDOWN"):
+		return "", notReady("%s", strings.TrimPrefix(reply, "-"))
+	case strings.HasPrefix(reply, "-NOAUTH"):
+		return "", fmt.Errorf("the server requires a password")
+	default:
+		return "", fmt.Errorf("unexpected reply to PING: %s", reply)
+	}
+}
+
+// redisCommand envía un comando en RESP y devuelve la primera línea de la respuesta
+func redisCommand(conn net.Conn, reader *bufio.Reader, args ...string) (string, error) {
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(command.String())); err != nil {
+		return "", fmt.Errorf("error sending %s: %v", args[0], err)
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("error reading reply to %s: %v", args[0], err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
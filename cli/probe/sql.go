@@ -0,0 +1,137 @@
+package probe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// checkMySQL lee el paquete de saludo que el servidor envía al conectar. No
+// hace falta autenticarse: el servidor solo lo envía cuando acepta clientes.
+func checkMySQL(conn net.Conn) (string, error) {
+	// Cabecera: longitud de 3 bytes en little endian y número de secuencia
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", fmt.Errorf("error reading handshake: %v", err)
+	}
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return "", fmt.Errorf("error reading handshake: %v", err)
+	}
+	if len(payload) == 0 {
+		return "", fmt.Errorf("empty handshake")
+	}
+
+	switch payload[0] {
+	case 10:
+		// Protocolo 10 seguido de la versión terminada en cero
+		version, _, _ := bytes.Cut(payload[1:], []byte{0})
+		return "MySQL " + string(version), nil
+	case 0xff:
+		// Paquete de error: código de 2 bytes y, en algunas versiones, '#' y el SQLSTATE
+		if len(payload) < 3 {
+			return "", fmt.Errorf("truncated error packet")
+		}
+		message := payload[3:]
+		if len(message) > 6 && message[0] == '#' {
+			message = message[6:]
+		}
+		return "", notReady("%s", message)
+	default:
+		return "", fmt.Errorf("unexpected handshake protocol %d", payload[0])
+	}
+}
+
+// pgProtocolVersion es la versión 3.0 del protocolo de PostgreSQL
+const pgProtocolVersion = 3 << 16
+
+// checkPostgres envía el mensaje de inicio. Si el servidor pide
+// autenticarse, o no la necesita, acepta clientes; mientras arranca responde
+// con el error 57P03.
+func checkPostgres(conn net.Conn, target Target) (string, error) {
+	user := target.User
+	if user == "" {
+		user = "postgres"
+	}
+	database := target.Database
+	if database == "" {
+		database = user
+	}
+
+	var params bytes.Buffer
+	for _, value := range []string{"user", user, "database", database, "application_name", "infracli-probe"} {
+		params.WriteString(value)
+		params.WriteByte(0)
+	}
+	params.WriteByte(0)
+
+	startup := make([]byte, 8, 8+params.Len())
+	binary.BigEndian.PutUint32(startup[0:4], uint32(8+params.Len()))
+	binary.BigEndian.PutUint32(startup[4:8], pgProtocolVersion)
+	startup = append(startup, params.Bytes()...)
+	if _, err := conn.Write(startup); err != nil {
+		return "", fmt.Errorf("error sending startup message: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	kind, body, err := readPostgresMessage(reader)
+	if err != nil {
+		return "", err
+	}
+
+	switch kind {
+	case 'R':
+		// Terminate, para que el servidor no registre una conexión abortada
+		conn.Write([]byte{'X', 0, 0, 0, 4})
+		return "PostgreSQL accepting connections", nil
+	case 'E':
+		fields := postgresErrorFields(body)
+		switch fields['C'] {
+		// cannot_connect_now: arrancando, deteniéndose o en recuperación
+		case "57P03":
+			return "", notReady("%s", fields['M'])
+		// Errores de autenticación o de permisos: el servidor ya acepta clientes
+		case "28000", "28P01":
+			return "PostgreSQL accepting connections", nil
+		default:
+			return "", fmt.Errorf("%s (%s)", fields['M'], fields['C'])
+		}
+	default:
+		return "", fmt.Errorf("unexpected message %q", kind)
+	}
+}
+
+// readPostgresMessage lee un mensaje: tipo de 1 byte y longitud de 4 bytes que se incluye a sí misma
+func readPostgresMessage(reader *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, nil, fmt.Errorf("error reading response: %v", err)
+	}
+	length := int(binary.BigEndian.Uint32(header[1:5])) - 4
+	if length < 0 || length > 1<<20 {
+		return 0, nil, fmt.Errorf("invalid message length %d", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return 0, nil, fmt.Errorf("error reading response: %v", err)
+	}
+	return header[0], body, nil
+}
+
+// postgresErrorFields separa los campos de un ErrorResponse: un byte con el
+// tipo, como 'C' para el código o 'M' para el mensaje, y un texto terminado en cero
+func postgresErrorFields(body []byte) map[byte]string {
+	fields := make(map[byte]string)
+	for len(body) > 1 {
+		kind := body[0]
+		value, rest, _ := bytes.Cut(body[1:], []byte{0})
+		fields[kind] = strings.TrimSpace(string(value))
+		body = rest
+	}
+	return fields
+}
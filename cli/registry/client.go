@@ -0,0 +1,209 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// manifestTypes son los formatos de manifiesto aceptados. Se piden primero
+// las listas multiplataforma porque su digest es el que Docker guarda en
+// RepoDigests al descargar una imagen por etiqueta.
+var manifestTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// Client consulta la API HTTP v2 de registros de imágenes
+type Client struct {
+	// Mirror, si no está vacío, es la URL base que se consulta en lugar del
+	// registro de cada imagen, por ejemplo http://localhost:5000
+	Mirror string
+	http   *http.Client
+}
+
+// NewClient crea un cliente de registros; mirror puede estar vacío
+func NewClient(mirror string) *Client {
+	return &Client{
+		Mirror: strings.TrimSuffix(mirror, "/"),
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Digest devuelve el digest del manifiesto al que apunta la etiqueta de la
+// referencia en el registro
+func (c *Client) Digest(ctx context.Context, ref Reference) (string, error) {
+	tag := ref.Tag
+	if tag == "" {
+		tag = ref.Digest
+	}
+
+	base := ref.endpoint()
+	if c.Mirror != "" {
+		base = c.Mirror
+	}
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", base, ref.Repository, tag)
+
+	resp, err := c.request(ctx, http.MethodHead, manifestURL, ref)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// Algunos registros no devuelven la cabecera en HEAD; el digest es el
+	// sha256 del manifiesto tal como lo sirve el registro
+	resp, err = c.request(ctx, http.MethodGet, manifestURL, ref)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", fmt.Errorf("error reading manifest of %s: %v", ref, err)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// request hace la petición al registro y, si responde 401, obtiene un token
+// anónimo según la cabecera WWW-Authenticate y la repite
+func (c *Client) request(ctx context.Context, method, manifestURL string, ref Reference) (*http.Response, error) {
+	resp, err := c.send(ctx, method, manifestURL, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		token, err := c.token(ctx, challenge, ref)
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = c.send(ctx, method, manifestURL, token); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s not found in registry", ref)
+		}
+		return nil, fmt.Errorf("registry returned %s for %s", resp.Status, ref)
+	}
+	return resp, nil
+}
+
+func (c *Client) send(ctx context.Context, method, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach registry: %v", err)
+	}
+	return resp, nil
+}
+
+// token pide un token anónimo de lectura al servicio de autenticación
+// indicado en el desafío, por ejemplo:
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func (c *Client) token(ctx context.Context, challenge string, ref Reference) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported registry authentication %q", scheme)
+	}
+
+	values := parseChallenge(params)
+	realm := values["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry authentication challenge without realm")
+	}
+
+	query := url.Values{}
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := values["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("cannot reach registry authentication service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry authentication service returned %s", resp.Status)
+	}
+
+	var payload struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("error decoding registry token: %v", err)
+	}
+	if payload.Token != "" {
+		return payload.Token, nil
+	}
+	return payload.AccessToken, nil
+}
+
+// parseChallenge convierte `realm="a",service="b"` en un mapa
+func parseChallenge(params string) map[string]string {
+	values := make(map[string]string)
+	for len(params) > 0 {
+		key, rest, found := strings.Cut(params, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, params = rest[1:], ""
+			} else {
+				value, params = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, params, _ = strings.Cut(rest, ",")
+		}
+		values[key] = value
+		params = strings.TrimLeft(params, ", ")
+	}
+	return values
+}
@@ -0,0 +1,215 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	listDigest     = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	manifestList   = `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.list.v2+json","manifests":[{"digest":"sha256:2222222222222222222222222222222222222222222222222222222222222222","platform":{"architecture":"amd64","os":"linux"}}]}`
+	manifestListCT = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+func TestDigestTokenChallenge(t *testing.T) {
+	var tokenRequests, manifestRequests int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			atomic.AddInt32(&tokenRequests, 1)
+			query := r.URL.Query()
+			if query.Get("service") != "registry.test" || query.Get("scope") != "repository:library/mongo:pull" {
+				t.Errorf("unexpected token query %v", query)
+			}
+			io.WriteString(w, `{"token":"secret-token"}`)
+		case "/v2/library/mongo/manifests/7.0":
+			atomic.AddInt32(&manifestRequests, 1)
+			if r.Header.Get("Authorization") != "Bearer secret-token" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry.test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", listDigest)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ref, err := ParseReference("mongo:7.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := NewClient(server.URL).Digest(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != listDigest {
+		t.Errorf("digest = %s, want %s", digest, listDigest)
+	}
+	if tokenRequests != 1 || manifestRequests != 2 {
+		t.Errorf("got %d token and %d manifest requests, want 1 and 2", tokenRequests, manifestRequests)
+	}
+}
+
+func TestDigestChallengeScopeAndAccessToken(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth":
+			if scope := r.URL.Query().Get("scope"); scope != "repository:team/api:pull,push" {
+				t.Errorf("scope = %q, want the one from the challenge", scope)
+			}
+			// Algunos servicios solo devuelven access_token
+			io.WriteString(w, `{"access_token":"oauth-token"}`)
+		case "/v2/team/api/manifests/v1":
+			if r.Header.Get("Authorization") != "Bearer oauth-token" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/auth",scope="repository:team/api:pull,push"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", listDigest)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	digest, err := NewClient(server.URL).Digest(context.Background(), Reference{Domain: "registry.test", Repository: "team/api", Tag: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != listDigest {
+		t.Errorf("digest = %s, want %s", digest, listDigest)
+	}
+}
+
+// TestDigestManifestList comprueba que sin la cabecera Docker-Content-Digest
+// el digest es el del manifiesto multiplataforma tal como lo sirve el registro,
+// que es el que Docker guarda en RepoDigests
+func TestDigestManifestList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/library/redis/manifests/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		accept := strings.Split(r.Header.Get("Accept"), ", ")
+		if len(accept) == 0 || accept[0] != manifestListCT {
+			t.Errorf("Accept = %q, want the manifest list type first", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", manifestListCT)
+		if r.Method == http.MethodGet {
+			io.WriteString(w, manifestList)
+		}
+	}))
+	defer server.Close()
+
+	digest, err := NewClient(server.URL).Digest(context.Background(), Reference{Domain: DockerHub, Repository: "library/redis", Tag: "latest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(manifestList))
+	if want := "sha256:" + hex.EncodeToString(sum[:]); digest != want {
+		t.Errorf("digest = %s, want %s", digest, want)
+	}
+}
+
+func TestDigestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{"not found", func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		}, "not found in registry"},
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, "registry returned 502"},
+		{"basic challenge", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}, "unsupported registry authentication"},
+		{"challenge without realm", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("WWW-Authenticate", `Bearer service="registry.test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}, "without realm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			_, err := NewClient(server.URL).Digest(context.Background(), Reference{Domain: DockerHub, Repository: "library/mongo", Tag: "latest"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	tests := []struct {
+		params string
+		want   map[string]string
+	}{
+		{`realm="https://auth.docker.io/token",service="registry.docker.io"`,
+			map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io"}},
+		{`realm="https://ghcr.io/token",service="ghcr.io",scope="repository:org/app:pull"`,
+			map[string]string{"realm": "https://ghcr.io/token", "service": "ghcr.io", "scope": "repository:org/app:pull"}},
+		{`Realm=https://auth.test/token, service=auth.test`,
+			map[string]string{"realm": "https://auth.test/token", "service": "auth.test"}},
+		{`scope="repository:a:pull,push",realm="https://auth.test"`,
+			map[string]string{"scope": "repository:a:pull,push", "realm": "https://auth.test"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseChallenge(tt.params); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseChallenge(%q) = %v, want %v", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image    string
+		want     Reference
+		endpoint string
+	}{
+		{"mongo", Reference{Domain: DockerHub, Repository: "library/mongo", Tag: "latest"}, "https://registry-1.docker.io"},
+		{"bitnami/redis:7.2", Reference{Domain: DockerHub, Repository: "bitnami/redis", Tag: "7.2"}, "https://registry-1.docker.io"},
+		{"localhost:5000/api", Reference{Domain: "localhost:5000", Repository: "api", Tag: "latest"}, "http://localhost:5000"},
+		{"ghcr.io/org/app:v1@" + listDigest, Reference{Domain: "ghcr.io", Repository: "org/app", Tag: "v1", Digest: listDigest}, "https://ghcr.io"},
+		{`"postgres:16"`, Reference{Domain: DockerHub, Repository: "library/postgres", Tag: "16"}, "https://registry-1.docker.io"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReference(tt.image)
+		if err != nil {
+			t.Errorf("ParseReference(%q): %v", tt.image, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.image, got, tt.want)
+		}
+		if endpoint := got.endpoint(); endpoint != tt.endpoint {
+			t.Errorf("endpoint of %q = %s, want %s", tt.image, endpoint, tt.endpoint)
+		}
+	}
+
+	for _, image := range []string{"", "Mongo:latest"} {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("ParseReference(%q): expected an error", image)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DockerHub es el dominio con el que se nombran las imágenes de Docker Hub
+	DockerHub = "docker.io"
+	// dockerHubEndpoint es el host real de la API de registro de Docker Hub
+	dockerHubEndpoint = "registry-1.docker.io"
+)
+
+// Reference es una referencia de imagen como "mongo:latest" o
+// "localhost:5000/equipo/api@sha256:..." separada en sus partes
+type Reference struct {
+	// Domain es el registro, por ejemplo docker.io o localhost:5000
+	Domain string
+	// Repository es la ruta dentro del registro, por ejemplo library/mongo
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference interpreta una referencia de imagen aplicando las mismas
+// reglas que Docker: sin dominio se usa Docker Hub, las imágenes oficiales
+// van bajo library/ y sin etiqueta ni digest se usa "latest"
+func ParseReference(image string) (Reference, error) {
+	image = strings.Trim(strings.TrimSpace(image), `"'`)
+	if image == "" {
+		return Reference{}, fmt.Errorf("empty image reference")
+	}
+
+	var ref Reference
+	name := image
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+	}
+
+	// La etiqueta va después del último ":" siempre que no forme parte del
+	// dominio, como en localhost:5000/api
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	// El primer componente es un dominio si contiene "." o ":" o es localhost
+	first, rest, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Domain = first
+		ref.Repository = rest
+	} else {
+		ref.Domain = DockerHub
+		ref.Repository = name
+	}
+
+	if ref.Domain == DockerHub && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	if ref.Repository == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+	return ref, nil
+}
+
+// Name devuelve el nombre de la imagen como lo muestra Docker, sin la
+// etiqueta: "mongo", "bitnami/redis" o "localhost:5000/api"
+func (r Reference) Name() string {
+	if r.Domain == DockerHub {
+		return strings.TrimPrefix(r.Repository, "library/")
+	}
+	return r.Domain + "/" + r.Repository
+}
+
+// String devuelve la referencia completa en su forma abreviada
+func (r Reference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Pinned indica si la referencia fija la imagen por digest
+func (r Reference) Pinned() bool {
+	return r.Digest != ""
+}
+
+// endpoint devuelve la URL base de la API del registro. Los registros en
+// localhost se consultan por HTTP, igual que los trata Docker por defecto.
+func (r Reference) endpoint() string {
+	host := r.Domain
+	if host == DockerHub {
+		host = dockerHubEndpoint
+	}
+
+	hostname := host
+	if h, _, found := strings.Cut(host, ":"); found {
+		hostname = h
+	}
+	if hostname == "localhost" || hostname == "127.0.0.1" {
+		return "http://" + host
+	}
+	return "https://" + host
+}
@@ -42,7 +42,7 @@ go install
 
 echo -e "${GREEN}Installation completed successfully!${NC}"
 echo "You can now use infracli by running: infracli"
-echo "The configuration will be automatically created at ~/.config/infracli/infracli.json on first run"
+echo "Run 'infracli init' to create the configuration file"
 echo "Try 'infracli --help' to see available commands"
 
 # Notificar que go install coloca los binarios en GOPATH/bin
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// envPrefix distingue las variables de los secretos de las del entorno al
+// interpolar el archivo de compose
+const envPrefix = "INFRACLI_SECRET_"
+
+// WriteEnvFile escribe un archivo para --env-file de docker-compose con los
+// valores de los secretos, legible solo por el usuario
+func WriteEnvFile(dir, service string, values map[string]string) (string, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var env strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&env, "%s%s=%s\n", envPrefix, name, quoteEnvValue(values[name]))
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("error creating %s: %v", dir, err)
+	}
+	path := filepath.Join(dir, service+".secrets.env")
+	if err := os.WriteFile(path, []byte(env.String()), 0600); err != nil {
+		return "", fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return path, nil
+}
+
+// WriteOverride escribe un archivo de compose que asigna a cada contenedor
+// las variables de entorno de sus secretos, tomadas del archivo de WriteEnvFile.
+// targets asocia cada contenedor con los nombres de los secretos que recibe.
+func WriteOverride(dir, service string, targets map[string][]string) (string, error) {
+	containers := make([]string, 0, len(targets))
+	for container := range targets {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	var override strings.Builder
+	override.WriteString("services:\n")
+	for _, container := range containers {
+		fmt.Fprintf(&override, "  %s:\n    environment:\n", container)
+		names := append([]string{}, targets[container]...)
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&override, "      %s: ${%s%s}\n", name, envPrefix, name)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("error creating %s: %v", dir, err)
+	}
+	path := filepath.Join(dir, service+".secrets.yml")
+	if err := os.WriteFile(path, []byte(override.String()), 0644); err != nil {
+		return "", fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return path, nil
+}
+
+// quoteEnvValue entrecomilla un valor para un archivo de entorno de compose.
+// Entre comillas simples no se interpreta nada; con comillas dobles se
+// escapan las barras, las comillas, los saltos de línea y los '$'.
+func quoteEnvValue(value string) string {
+	if !strings.ContainsAny(value, "'\n") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
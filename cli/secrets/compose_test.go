@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuoteEnvValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", `'plain'`},
+		{"", `''`},
+		// Entre comillas simples '$', '"' y '\' se toman literalmente
+		{`pa$$word`, `'pa$$word'`},
+		{`a"b\c`, `'a"b\c'`},
+		{"it's", `"it's"`},
+		{"line1\nline2", `"line1\nline2"`},
+		{`it's $HOME "x" \n`, `"it's \$HOME \"x\" \\n"`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteEnvValue(tt.value); got != tt.want {
+			t.Errorf("quoteEnvValue(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteEnvFileAndOverride(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "compose")
+
+	envPath, err := WriteEnvFile(dir, "postgres", map[string]string{"POSTGRES_PASSWORD": "pg'secret", "POSTGRES_USER": "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(envPath)
+	if want := "INFRACLI_SECRET_POSTGRES_PASSWORD=\"pg'secret\"\nINFRACLI_SECRET_POSTGRES_USER='app'\n"; string(data) != want {
+		t.Errorf("env file:\n%s\nwant:\n%s", data, want)
+	}
+	if info, _ := os.Stat(envPath); info.Mode().Perm() != 0600 {
+		t.Errorf("env file permissions = %v, want 0600", info.Mode().Perm())
+	}
+
+	overridePath, err := WriteOverride(dir, "postgres", map[string][]string{"db": {"POSTGRES_USER", "POSTGRES_PASSWORD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(overridePath)
+	want := `services:
+  db:
+    environment:
+      POSTGRES_PASSWORD: ${INFRACLI_SECRET_POSTGRES_PASSWORD}
+      POSTGRES_USER: ${INFRACLI_SECRET_POSTGRES_USER}
+`
+	if string(data) != want {
+		t.Errorf("override:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// keySize es el tamaño de la clave de AES-256
+	keySize = 32
+	// saltSize es el tamaño de la sal para derivar la clave de una frase
+	saltSize = 16
+	// defaultIterations son las iteraciones de PBKDF2-HMAC-SHA256
+	defaultIterations = 600000
+)
+
+// errWrongKey indica que la clave no descifra el almacén
+var errWrongKey = errors.New("wrong passphrase or key")
+
+// randomBytes devuelve n bytes aleatorios
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("error generating random data: %v", err)
+	}
+	return b, nil
+}
+
+// deriveKey obtiene una clave de AES-256 a partir de una frase con PBKDF2-HMAC-SHA256
+func deriveKey(passphrase string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, keySize, sha256.New)
+}
+
+// encrypt cifra plaintext con AES-256-GCM. data se autentica sin cifrarse,
+// de modo que un valor no se puede mover a otro nombre. El resultado es
+// el nonce seguido del texto cifrado, en base64.
+func encrypt(key []byte, plaintext, data string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomBytes(gcm.NonceSize())
+	if err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(data))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt descifra un valor generado por encrypt
+func decrypt(key []byte, ciphertext, data string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(data))
+	if err != nil {
+		return "", errWrongKey
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
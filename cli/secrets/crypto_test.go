@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// TestDeriveKey usa los vectores de PBKDF2-HMAC-SHA256 de RFC 7914 (sección 11)
+// y los habituales de RFC 6070 calculados con SHA-256, recortados a keySize
+func TestDeriveKey(t *testing.T) {
+	tests := []struct {
+		passphrase string
+		salt       string
+		iterations int
+		want       string
+	}{
+		{"password", "salt", 1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{"password", "salt", 2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56"},
+	}
+
+	for _, tt := range tests {
+		got := hex.EncodeToString(deriveKey(tt.passphrase, []byte(tt.salt), tt.iterations))
+		if got != tt.want {
+			t.Errorf("deriveKey(%q, %q, %d) = %s, want %s", tt.passphrase, tt.salt, tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, keySize)
+
+	for _, value := range []string{"", "s3cret", "with 'quotes' and\nnewlines", "ñandú $HOME"} {
+		ciphertext, err := encrypt(key, value, "postgres/POSTGRES_PASSWORD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decrypt(key, ciphertext, "postgres/POSTGRES_PASSWORD")
+		if err != nil || got != value {
+			t.Errorf("decrypt = %q, %v; want %q", got, err, value)
+		}
+	}
+
+	// Cada cifrado usa un nonce distinto
+	first, _ := encrypt(key, "same", "a/B")
+	second, _ := encrypt(key, "same", "a/B")
+	if first == second {
+		t.Error("two encryptions of the same value are identical")
+	}
+}
+
+func TestDecryptFailures(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, keySize)
+	ciphertext, err := encrypt(key, "s3cret", "postgres/POSTGRES_PASSWORD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(ciphertext)
+
+	tamper := func(i int) string {
+		changed := append([]byte{}, sealed...)
+		changed[i] ^= 1
+		return base64.StdEncoding.EncodeToString(changed)
+	}
+
+	tests := []struct {
+		name       string
+		key        []byte
+		ciphertext string
+		data       string
+		wrongKey   bool
+	}{
+		{"wrong key", bytes.Repeat([]byte{8}, keySize), ciphertext, "postgres/POSTGRES_PASSWORD", true},
+		// El nombre se autentica: el valor no se puede mover a otro secreto
+		{"other name", key, ciphertext, "postgres/POSTGRES_USER", true},
+		{"other service", key, ciphertext, "mysql/POSTGRES_PASSWORD", true},
+		{"tampered nonce", key, tamper(0), "postgres/POSTGRES_PASSWORD", true},
+		{"tampered ciphertext", key, tamper(len(sealed) - 20), "postgres/POSTGRES_PASSWORD", true},
+		{"tampered tag", key, tamper(len(sealed) - 1), "postgres/POSTGRES_PASSWORD", true},
+		{"not base64", key, "%%%", "postgres/POSTGRES_PASSWORD", false},
+		{"too short", key, base64.StdEncoding.EncodeToString([]byte("short")), "postgres/POSTGRES_PASSWORD", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := decrypt(tt.key, tt.ciphertext, tt.data)
+			if err == nil {
+				t.Fatalf("decrypted %q", value)
+			}
+			if errors.Is(err, errWrongKey) != tt.wrongKey {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	// keyringService y keyringAccount identifican la clave en el llavero del sistema
+	keyringService = "infracli"
+	keyringAccount = "secrets"
+)
+
+// ErrKeyringUnavailable indica que no hay un llavero del sistema utilizable
+var ErrKeyringUnavailable = errors.New("no OS keyring available")
+
+// keyringGet lee la clave del llavero del sistema: el llavero de macOS con
+// security o el Secret Service de Linux con secret-tool
+func keyringGet() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", keyringAccount, "-w")
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", keyringAccount)
+	default:
+		return "", ErrKeyringUnavailable
+	}
+
+	// cmd.Err está definido cuando el programa no se encuentra en el PATH
+	if cmd.Err != nil {
+		return "", ErrKeyringUnavailable
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error reading the key from the OS keyring: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	value := strings.TrimSpace(string(output))
+	if value == "" {
+		return "", fmt.Errorf("the key is not in the OS keyring")
+	}
+	return value, nil
+}
+
+// keyringSet guarda la clave en el llavero del sistema
+func keyringSet(value string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// La orden se pasa por la entrada estándar del modo interactivo para que
+		// la clave no aparezca en la lista de procesos. -U actualiza la entrada
+		// si ya existe y -X recibe el valor en hexadecimal.
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", keyringService, keyringAccount, hex.EncodeToString([]byte(value))))
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "store", "--label=infracli secrets", "service", keyringService, "account", keyringAccount)
+		cmd.Stdin = strings.NewReader(value)
+	default:
+		return ErrKeyringUnavailable
+	}
+
+	if cmd.Err != nil {
+		return ErrKeyringUnavailable
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error saving the key in the OS keyring: %v %s", err, strings.TrimSpace(string(output)))
+	}
+	// En modo interactivo security termina bien aunque falle la orden, que
+	// solo escribe algo en ese caso
+	if runtime.GOOS == "darwin" && strings.TrimSpace(string(output)) != "" {
+		return fmt.Errorf("error saving the key in the OS keyring: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Package secrets guarda contraseñas y otros valores sensibles de los
+// servicios cifrados con AES-256-GCM. La clave está en el llavero del sistema
+// o se deriva de una frase de paso. Los nombres de los secretos se guardan en
+// claro para poder listarlos sin desbloquear el almacén.
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+const (
+	// FileName es el nombre del almacén, guardado en el directorio de configuración
+	FileName = "secrets.json"
+	// CurrentVersion es la versión del formato del almacén
+	CurrentVersion = 1
+
+	// KeySourceKeyring indica que la clave está en el llavero del sistema
+	KeySourceKeyring = "keyring"
+	// KeySourcePassphrase indica que la clave se deriva de una frase de paso
+	KeySourcePassphrase = "passphrase"
+
+	// checkValue se cifra al crear el almacén para comprobar la clave al desbloquearlo
+	checkValue = "infracli"
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Store es el almacén de secretos: servicio -> nombre -> valor cifrado
+type Store struct {
+	Version    int                          `json:"version"`
+	KeySource  string                       `json:"key_source"`
+	Salt       string                       `json:"salt,omitempty"`
+	Iterations int                          `json:"iterations,omitempty"`
+	Check      string                       `json:"check"`
+	Secrets    map[string]map[string]string `json:"secrets"`
+
+	key []byte
+}
+
+// Path devuelve la ruta del almacén dentro del directorio de configuración
+func Path(configDir string) string {
+	return filepath.Join(configDir, FileName)
+}
+
+// NewWithKeyring crea un almacén con una clave aleatoria guardada en el
+// llavero del sistema. Devuelve ErrKeyringUnavailable si no hay llavero.
+func NewWithKeyring() (*Store, error) {
+	key, err := randomBytes(keySize)
+	if err != nil {
+		return nil, err
+	}
+	if err := keyringSet(hex.EncodeToString(key)); err != nil {
+		return nil, err
+	}
+	return newStore(KeySourceKeyring, key, nil, 0)
+}
+
+// NewWithPassphrase crea un almacén cuya clave se deriva de passphrase
+func NewWithPassphrase(passphrase string) (*Store, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("the passphrase cannot be empty")
+	}
+	salt, err := randomBytes(saltSize)
+	if err != nil {
+		return nil, err
+	}
+	key := deriveKey(passphrase, salt, defaultIterations)
+	return newStore(KeySourcePassphrase, key, salt, defaultIterations)
+}
+
+func newStore(source string, key, salt []byte, iterations int) (*Store, error) {
+	check, err := encrypt(key, checkValue, "")
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{
+		Version:    CurrentVersion,
+		KeySource:  source,
+		Iterations: iterations,
+		Check:      check,
+		Secrets:    make(map[string]map[string]string),
+		key:        key,
+	}
+	if salt != nil {
+		store.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+	return store, nil
+}
+
+// Load lee el almacén. Si no existe devuelve el error de os.ReadFile,
+// que se puede comprobar con os.IsNotExist.
+func Load(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if store.Version > CurrentVersion {
+		return nil, fmt.Errorf("%s has version %d, but this infracli only supports up to version %d", path, store.Version, CurrentVersion)
+	}
+	if store.Secrets == nil {
+		store.Secrets = make(map[string]map[string]string)
+	}
+	return store, nil
+}
+
+// Save escribe el almacén con permisos solo para el usuario
+func (s *Store) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding secrets: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// Unlock obtiene la clave del almacén. passphrase solo se llama cuando la
+// clave se deriva de una frase de paso.
+func (s *Store) Unlock(passphrase func() (string, error)) error {
+	if s.key != nil {
+		return nil
+	}
+
+	var key []byte
+	switch s.KeySource {
+	case KeySourceKeyring:
+		value, err := keyringGet()
+		if err != nil {
+			return err
+		}
+		if key, err = hex.DecodeString(value); err != nil || len(key) != keySize {
+			return fmt.Errorf("invalid key in the OS keyring")
+		}
+	case KeySourcePassphrase:
+		salt, err := base64.StdEncoding.DecodeString(s.Salt)
+		if err != nil {
+			return fmt.Errorf("invalid salt in the secrets file")
+		}
+		value, err := passphrase()
+		if err != nil {
+			return err
+		}
+		key = deriveKey(value, salt, s.Iterations)
+	default:
+		return fmt.Errorf("unknown key source %q in the secrets file", s.KeySource)
+	}
+
+	if value, err := decrypt(key, s.Check, ""); err != nil || value != checkValue {
+		return fmt.Errorf("cannot unlock the secrets: %w", errWrongKey)
+	}
+	s.key = key
+	return nil
+}
+
+// ValidateName comprueba que el nombre sirva como variable de entorno
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use an environment variable name such as MYSQL_PASSWORD", name)
+	}
+	return nil
+}
+
+// Services devuelve los servicios con secretos, ordenados
+func (s *Store) Services() []string {
+	services := make([]string, 0, len(s.Secrets))
+	for service, names := range s.Secrets {
+		if len(names) > 0 {
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Names devuelve los nombres de los secretos de un servicio, ordenados
+func (s *Store) Names(service string) []string {
+	names := make([]string, 0, len(s.Secrets[service]))
+	for name := range s.Secrets[service] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has indica si existe un secreto
+func (s *Store) Has(service, name string) bool {
+	_, ok := s.Secrets[service][name]
+	return ok
+}
+
+// Set cifra y guarda un secreto. El almacén debe estar desbloqueado.
+func (s *Store) Set(service, name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if s.key == nil {
+		return fmt.Errorf("the secrets are locked")
+	}
+
+	ciphertext, err := encrypt(s.key, value, service+"/"+name)
+	if err != nil {
+		return err
+	}
+	if s.Secrets[service] == nil {
+		s.Secrets[service] = make(map[string]string)
+	}
+	s.Secrets[service][name] = ciphertext
+	return nil
+}
+
+// Get descifra un secreto. El almacén debe estar desbloqueado.
+func (s *Store) Get(service, name string) (string, error) {
+	ciphertext, ok := s.Secrets[service][name]
+	if !ok {
+		return "", fmt.Errorf("secret %s of %s not found", name, service)
+	}
+	if s.key == nil {
+		return "", fmt.Errorf("the secrets are locked")
+	}
+
+	value, err := decrypt(s.key, ciphertext, service+"/"+name)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting %s of %s: %v", name, service, err)
+	}
+	return value, nil
+}
+
+// Values descifra todos los secretos de un servicio
+func (s *Store) Values(service string) (map[string]string, error) {
+	values := make(map[string]string, len(s.Secrets[service]))
+	for _, name := range s.Names(service) {
+		value, err := s.Get(service, name)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// Remove elimina un secreto y devuelve si existía
+func (s *Store) Remove(service, name string) bool {
+	if !s.Has(service, name) {
+		return false
+	}
+	delete(s.Secrets[service], name)
+	if len(s.Secrets[service]) == 0 {
+		delete(s.Secrets, service)
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestStore crea un almacén con frase de paso y pocas iteraciones
+func newTestStore(t *testing.T, passphrase string) *Store {
+	t.Helper()
+	salt := []byte("0123456789abcdef")
+	store, err := newStore(KeySourcePassphrase, deriveKey(passphrase, salt, 1000), salt, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestStoreSaveLoadUnlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "infracli", FileName)
+	store := newTestStore(t, "correct horse")
+	if err := store.Set("postgres", "POSTGRES_PASSWORD", "pg-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("postgres", "POSTGRES_USER", "app"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("redis", "REDIS_PASSWORD", "redis-secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("permissions = %v, want 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "pg-secret") {
+		t.Error("the file contains a secret in clear text")
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Los nombres se leen sin desbloquear el almacén
+	if got := loaded.Services(); !reflect.DeepEqual(got, []string{"postgres", "redis"}) {
+		t.Errorf("services = %v", got)
+	}
+	if got := loaded.Names("postgres"); !reflect.DeepEqual(got, []string{"POSTGRES_PASSWORD", "POSTGRES_USER"}) {
+		t.Errorf("names = %v", got)
+	}
+	if _, err := loaded.Get("postgres", "POSTGRES_PASSWORD"); err == nil {
+		t.Error("a locked store returned a secret")
+	}
+
+	wrong := func() (string, error) { return "wrong", nil }
+	if err := loaded.Unlock(wrong); !errors.Is(err, errWrongKey) {
+		t.Errorf("unlocking with a wrong passphrase: %v", err)
+	}
+
+	calls := 0
+	right := func() (string, error) {
+		calls++
+		return "correct horse", nil
+	}
+	if err := loaded.Unlock(right); err != nil {
+		t.Fatal(err)
+	}
+	// Un almacén desbloqueado no vuelve a pedir la frase de paso
+	if err := loaded.Unlock(right); err != nil || calls != 1 {
+		t.Errorf("second unlock: %v after %d calls", err, calls)
+	}
+
+	values, err := loaded.Values("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"POSTGRES_PASSWORD": "pg-secret", "POSTGRES_USER": "app"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+
+	if !loaded.Remove("redis", "REDIS_PASSWORD") || loaded.Remove("redis", "REDIS_PASSWORD") {
+		t.Error("Remove should report whether the secret existed")
+	}
+	if got := loaded.Services(); !reflect.DeepEqual(got, []string{"postgres"}) {
+		t.Errorf("services after remove = %v", got)
+	}
+}
+
+// TestStoreMovedCiphertext comprueba que copiar el valor cifrado de un secreto
+// a otro nombre en el archivo no permite descifrarlo
+func TestStoreMovedCiphertext(t *testing.T) {
+	store := newTestStore(t, "passphrase")
+	if err := store.Set("postgres", "POSTGRES_PASSWORD", "pg-secret"); err != nil {
+		t.Fatal(err)
+	}
+	store.Secrets["mysql"] = map[string]string{"MYSQL_PASSWORD": store.Secrets["postgres"]["POSTGRES_PASSWORD"]}
+
+	if value, err := store.Get("mysql", "MYSQL_PASSWORD"); err == nil {
+		t.Errorf("decrypted a moved value: %q", value)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+
+	newer, _ := json.Marshal(map[string]interface{}{"version": CurrentVersion + 1})
+	path := filepath.Join(dir, "newer.json")
+	os.WriteFile(path, newer, 0600)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "only supports up to version") {
+		t.Errorf("expected a version error, got %v", err)
+	}
+
+	path = filepath.Join(dir, "invalid.json")
+	os.WriteFile(path, []byte("{"), 0600)
+	if _, err := Load(path); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestNewWithPassphrase(t *testing.T) {
+	if _, err := NewWithPassphrase(""); err == nil {
+		t.Error("expected an error for an empty passphrase")
+	}
+
+	store, err := NewWithPassphrase("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.KeySource != KeySourcePassphrase || store.Iterations != defaultIterations || store.Salt == "" {
+		t.Errorf("unexpected store %+v", store)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"MYSQL_PASSWORD", "_TOKEN", "a1"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q): %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "1PASSWORD", "MY-PASSWORD", "PASS WORD", "X=Y"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q): expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package stack
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+)
+
+const (
+	// passwordLength es la longitud de las contraseñas generadas
+	passwordLength = 24
+	// passwordAlphabet evita símbolos para poder usar las contraseñas en URLs y shells sin escaparlas
+	passwordAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// CredentialVars son las variables de entorno con contraseñas que se generan al azar
+var CredentialVars = []string{
+	"POSTGRES_PASSWORD",
+	"MYSQL_PASSWORD",
+	"MYSQL_ROOT_PASSWORD",
+	"MARIADB_PASSWORD",
+	"MARIADB_ROOT_PASSWORD",
+	"MONGO_INITDB_ROOT_PASSWORD",
+	"NEO4J_AUTH",
+	"ELASTIC_PASSWORD",
+	"REDIS_PASSWORD",
+	"RABBITMQ_DEFAULT_PASS",
+}
+
+// EnsureCredentials genera las contraseñas de un servicio en su primer
+// arranque si credentials.generate lo incluye. Si el servicio ya tiene datos
+// no se generan, porque la base de datos seguiría usando las anteriores.
+func EnsureCredentials(service, basePath string, opts Options) error {
+	cfg, err := config.LoadConfig()
+	if err != nil || !cfg.GeneratesCredentials(service) {
+		return err
+	}
+
+	content, err := compose.Read(filepath.Join(basePath, service))
+	if err != nil {
+		return err
+	}
+
+	store, _, err := opts.loadSecrets()
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, name := range DeclaredCredentials(content) {
+		if store == nil || !store.Has(service, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	hasData, err := HasData(service, basePath, opts)
+	if err != nil {
+		logger.Warnf("Warning: cannot check the volumes of %s, not generating credentials: %v", service, err)
+		return nil
+	}
+	if hasData {
+		logger.Warnf("%s already has data created with the credentials of its docker-compose.yml; generate new ones with: infracli rotate-credentials %s --reset", service, service)
+		return nil
+	}
+
+	if err := GenerateCredentials(service, content, missing, opts); err != nil {
+		return err
+	}
+	logger.Infof("Generated random credentials for %s (%s); see them with: infracli info %s --show-secrets", service, strings.Join(missing, ", "), service)
+	return nil
+}
+
+// GenerateCredentials guarda contraseñas nuevas como secretos del servicio
+func GenerateCredentials(service, content string, names []string, opts Options) error {
+	store, path, err := opts.loadSecrets()
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		if store, err = CreateSecrets(opts); err != nil {
+			return err
+		}
+		opts.cacheSecrets(store, path)
+	} else if err := opts.unlock(store); err != nil {
+		return err
+	}
+
+	env := serviceEnvironment(content)
+	for _, name := range names {
+		password, err := randomPassword()
+		if err != nil {
+			return err
+		}
+
+		value := password
+		if name == "NEO4J_AUTH" {
+			// NEO4J_AUTH tiene la forma usuario/contraseña
+			user := "neo4j"
+			if parts := strings.SplitN(env[name], "/", 2); len(parts) == 2 && parts[0] != "" {
+				user = parts[0]
+			}
+			value = user + "/" + password
+		}
+
+		if err := store.Set(service, name, value); err != nil {
+			return err
+		}
+	}
+	return store.Save(path)
+}
+
+// DeclaredCredentials devuelve las variables de credenciales conocidas que
+// declara el docker-compose.yml, en el orden de CredentialVars
+func DeclaredCredentials(content string) []string {
+	env := serviceEnvironment(content)
+
+	var names []string
+	for _, name := range CredentialVars {
+		value, ok := env[name]
+		// NEO4J_AUTH=none desactiva la autenticación
+		if ok && !(name == "NEO4J_AUTH" && strings.EqualFold(value, "none")) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// HasData indica si el proyecto de compose del servicio ya tiene volúmenes
+func HasData(service, basePath string, opts Options) (bool, error) {
+	client, err := opts.docker()
+	if err != nil {
+		return false, err
+	}
+	volumes, err := client.ProjectVolumes(context.Background(), engine.ProjectName(filepath.Join(basePath, service)))
+	if err != nil {
+		return false, err
+	}
+	return len(volumes) > 0, nil
+}
+
+// serviceEnvironment une las variables de entorno de todos los contenedores
+func serviceEnvironment(content string) map[string]string {
+	env := make(map[string]string)
+	for container := range compose.Images(content) {
+		for key, value := range compose.Environment(content, "  "+container+":") {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// randomPassword genera una contraseña alfanumérica con crypto/rand
+func randomPassword() (string, error) {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	password := make([]byte, passwordLength)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("error generating password: %v", err)
+		}
+		password[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package stack
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup hace que al cancelar un hook se termine también cualquier
+// proceso que haya lanzado su shell, y no solo la shell
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package stack
+
+import "os/exec"
+
+// killProcessGroup no hace nada en Windows: al cancelar un hook solo se
+// termina su proceso
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,376 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+)
+
+// Eventos del ciclo de vida en los que se ejecutan hooks
+const (
+	HookPreRun    = "preRun"
+	HookPostRun   = "postRun"
+	HookOnHealthy = "onHealthy"
+	HookPreDown   = "preDown"
+	HookPostDown  = "postDown"
+)
+
+// Políticas ante el fallo de un hook
+const (
+	hookAbort    = "abort"
+	hookContinue = "continue"
+)
+
+const (
+	// defaultHookTimeout es el tiempo máximo de un hook si ni él ni la
+	// configuración indican otro
+	defaultHookTimeout = 5 * time.Minute
+	// hookWaitDelay es lo que se espera a que terminen los procesos hijos de
+	// un hook cancelado antes de cerrar su salida
+	hookWaitDelay = 5 * time.Second
+)
+
+// HookEnv devuelve las variables con la información de conexión del servicio
+// que reciben sus hooks a partir de su docker-compose.yml con los secretos
+// aplicados. Dentro de un contenedor se usan los nombres de la red y los
+// puertos del contenedor.
+type HookEnv func(service, content string, inContainer bool) []string
+
+// hook es un comando que se ejecuta alrededor de un evento del ciclo de vida
+// de un servicio
+type hook struct {
+	Event   string
+	Command string
+	// Container es el servicio compose en el que se ejecuta Command; vacío lo
+	// ejecuta en el host
+	Container string
+	Timeout   time.Duration
+	OnFailure string
+}
+
+// serviceHooks devuelve los hooks de un evento del servicio: primero los de la
+// sección x-infracli de su docker-compose.yml y después los de la configuración.
+// Los x-infracli de un directorio de servicios en el que no se confía no se
+// devuelven; skipped indica cuántos se han descartado.
+func serviceHooks(service, basePath, event string) (hooks []hook, skipped int, err error) {
+	resolved, err := config.LoadResolved()
+	if err != nil {
+		return nil, 0, err
+	}
+	cfg := resolved.Config
+	trusted, err := composeHooksTrusted(resolved, basePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Valores para los hooks que no indican los suyos
+	timeout := defaultHookTimeout
+	if cfg.Hooks.Timeout != "" {
+		if timeout, err = time.ParseDuration(cfg.Hooks.Timeout); err != nil {
+			return nil, 0, fmt.Errorf("invalid hooks.timeout %q: %v", cfg.Hooks.Timeout, err)
+		}
+	}
+	onFailure := hookAbort
+	if cfg.Hooks.OnFailure != "" {
+		onFailure = cfg.Hooks.OnFailure
+	}
+
+	servicePath := filepath.Join(basePath, service)
+	content, err := compose.Read(servicePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, declared := range compose.Hooks(content) {
+		if declared.Event != event {
+			continue
+		}
+		if !trusted {
+			skipped++
+			continue
+		}
+
+		h := hook{Event: event, Command: declared.Command, Timeout: timeout, OnFailure: onFailure}
+		switch {
+		case declared.Command != "" && declared.Exec != "":
+			return nil, 0, fmt.Errorf("%s hook of %s sets both command and exec", event, service)
+		case declared.Exec != "":
+			h.Command = declared.Exec
+			if h.Container, err = hookContainer(service, content, declared.Container); err != nil {
+				return nil, 0, err
+			}
+		case declared.Command == "":
+			return nil, 0, fmt.Errorf("%s hook of %s has no command", event, service)
+		}
+		if declared.Timeout != "" {
+			if h.Timeout, err = time.ParseDuration(declared.Timeout); err != nil || h.Timeout <= 0 {
+				return nil, 0, fmt.Errorf("%s hook of %s has an invalid timeout %q", event, service, declared.Timeout)
+			}
+		}
+		switch declared.OnFailure {
+		case "":
+		case hookAbort, hookContinue:
+			h.OnFailure = declared.OnFailure
+		default:
+			return nil, 0, fmt.Errorf("%s hook of %s has an invalid onFailure %q (expected abort or continue)", event, service, declared.OnFailure)
+		}
+		hooks = append(hooks, h)
+	}
+
+	for _, entry := range cfg.Hooks.Commands {
+		parsed, err := config.ParseHookEntry(entry)
+		if err != nil {
+			return nil, 0, err
+		}
+		if parsed.Service != service || parsed.Event != event {
+			continue
+		}
+		h := hook{Event: event, Command: parsed.Command, Timeout: timeout, OnFailure: onFailure}
+		if parsed.Container != "" {
+			if h.Container, err = hookContainer(service, content, parsed.Container); err != nil {
+				return nil, 0, err
+			}
+		}
+		hooks = append(hooks, h)
+	}
+
+	return hooks, skipped, nil
+}
+
+// composeHooksTrusted indica si se ejecutan los hooks x-infracli de los
+// servicios de basePath. Un servicesPath del archivo de usuario, de un flag o
+// el valor por defecto lo ha elegido el usuario; uno de un archivo de proyecto
+// o del entorno puede venir de un repositorio clonado y solo se acepta si está
+// en hooks.trustedPaths, que tampoco se puede fijar desde ahí.
+func composeHooksTrusted(resolved *config.Resolved, basePath string) (bool, error) {
+	switch resolved.Origins["servicesPath"] {
+	case config.OriginProjectFile, config.OriginEnv:
+	default:
+		return true, nil
+	}
+
+	servicesPath, err := config.ExpandPath(resolved.Config.ServicesPath)
+	if err != nil {
+		return false, err
+	}
+	// Una ruta distinta de la configurada la ha indicado el llamador, por
+	// ejemplo en infracli.Options.ServicesPath
+	if filepath.Clean(basePath) != filepath.Clean(servicesPath) {
+		return true, nil
+	}
+
+	for _, path := range resolved.Config.Hooks.TrustedPaths {
+		path, err := config.ExpandPath(path)
+		if err != nil {
+			return false, err
+		}
+		if filepath.Clean(path) == filepath.Clean(servicesPath) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hookContainer devuelve el servicio compose en el que se ejecuta un hook
+// exec, que se puede indicar también por su container_name. Solo se puede
+// omitir si el servicio tiene un único contenedor.
+func hookContainer(service, content, container string) (string, error) {
+	if container != "" {
+		for composeService, containerName := range compose.ContainerNames(content) {
+			if containerName == container {
+				return composeService, nil
+			}
+		}
+		return container, nil
+	}
+
+	containers := make(map[string]bool)
+	for name := range compose.Images(content) {
+		containers[name] = true
+	}
+	for name := range compose.ContainerNames(content) {
+		containers[name] = true
+	}
+	if len(containers) == 1 {
+		for name := range containers {
+			return name, nil
+		}
+	}
+
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("exec hooks of %s must set container to one of: %s", service, strings.Join(names, ", "))
+}
+
+// HasHooks indica si el servicio tiene hooks para el evento
+func HasHooks(service, basePath, event string) bool {
+	hooks, _, err := serviceHooks(service, basePath, event)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return false
+	}
+	return len(hooks) > 0
+}
+
+// RunHooks ejecuta en orden los hooks de un evento del servicio. Si falla un
+// hook con la política abort los siguientes no se ejecutan y se devuelve el
+// error, para que el llamador cancele la operación; con continue solo se avisa.
+func RunHooks(service, basePath, event string, opts Options) error {
+	hooks, skipped, err := serviceHooks(service, basePath, event)
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		logger.Warnf("Warning: skipping %d %s hook(s) declared in the docker-compose.yml of %s: servicesPath comes from a project file or the environment; add %s to hooks.trustedPaths in your user config to run them", skipped, event, service, basePath)
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	servicePath := filepath.Join(basePath, service)
+	for _, h := range hooks {
+		where := "host"
+		if h.Container != "" {
+			// Sin el contenedor en marcha no hay nada que hacer, por ejemplo
+			// al detener un servicio que ya estaba detenido
+			if !containerRunning(servicePath, h.Container, opts) {
+				logger.Warnf("Warning: skipping %s hook of %s: container %s is not running", event, service, h.Container)
+				continue
+			}
+			where = "container " + h.Container
+		}
+		logger.Infof("Running %s hook of %s in %s: %s", event, service, where, h.Command)
+
+		env, err := hookEnv(service, basePath, event, h.Container != "", opts)
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		output, err := runHook(service, servicePath, h, env)
+		if err == nil {
+			logger.Debugf("%s hook of %s finished in %s", event, service, time.Since(start).Round(time.Millisecond))
+			continue
+		}
+
+		if h.OnFailure == hookContinue {
+			logger.Warnf("Warning: %s hook of %s failed: %v", event, service, err)
+			if output != "" {
+				logger.Warnf("%s", output)
+			}
+			continue
+		}
+		if output != "" {
+			logger.Errorf("%s", output)
+		}
+		return fmt.Errorf("%s hook of %s failed: %v", event, service, err)
+	}
+	return nil
+}
+
+// containerRunning indica si el contenedor del servicio compose está en
+// marcha. Si no se puede consultar a Docker se supone que sí y será
+// docker-compose exec quien falle.
+func containerRunning(servicePath, composeService string, opts Options) bool {
+	client, err := opts.docker()
+	if err != nil {
+		return true
+	}
+	containers, err := client.ServiceContainers(context.Background(), servicePath)
+	if err != nil {
+		logger.Debugf("Cannot list the containers of %s: %v", servicePath, err)
+		return true
+	}
+	for _, container := range containers {
+		if container.Labels[engine.LabelService] == composeService {
+			return container.State == "running"
+		}
+	}
+	return false
+}
+
+// runHook ejecuta un hook con su plazo. En modo debug la salida se registra
+// línea a línea; si no, se devuelve para mostrarla solo si el hook falla.
+func runHook(service, servicePath string, h hook, env []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if h.Container != "" {
+		// Solo se pasan los nombres de las variables: docker-compose toma los
+		// valores de su entorno y las contraseñas no aparecen en la línea de órdenes
+		args := []string{"exec", "-T"}
+		for _, variable := range env {
+			name, _, _ := strings.Cut(variable, "=")
+			args = append(args, "-e", name)
+		}
+		args = append(args, h.Container, "sh", "-c", h.Command)
+		cmd = exec.CommandContext(ctx, "docker-compose", args...)
+	} else {
+		shell, flag := "sh", "-c"
+		if runtime.GOOS == "windows" {
+			shell, flag = "cmd", "/C"
+		}
+		cmd = exec.CommandContext(ctx, shell, flag, h.Command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Dir = servicePath
+	cmd.WaitDelay = hookWaitDelay
+	killProcessGroup(cmd)
+
+	var output []byte
+	var err error
+	if logger.Enabled(slog.LevelDebug) {
+		out := logger.Writer(slog.LevelDebug, "service", service, "hook", h.Event)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		err = cmd.Run()
+		out.Close()
+	} else {
+		output, err = cmd.CombinedOutput()
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", h.Timeout)
+	}
+	return strings.TrimSpace(string(output)), err
+}
+
+// hookEnv devuelve las variables que reciben los hooks: el servicio, el evento
+// y las de opts.HookEnv
+func hookEnv(service, basePath, event string, inContainer bool, opts Options) ([]string, error) {
+	env := []string{
+		"INFRACLI_SERVICE=" + service,
+		"INFRACLI_EVENT=" + event,
+		"INFRACLI_SERVICE_PATH=" + filepath.Join(basePath, service),
+	}
+	if opts.HookEnv == nil {
+		return env, nil
+	}
+
+	content, err := compose.Read(filepath.Join(basePath, service))
+	if err != nil {
+		return nil, err
+	}
+	values, err := ServiceSecrets(service, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the secrets of %s: %w", service, err)
+	}
+	return append(env, opts.HookEnv(service, compose.SetEnvironment(content, values), inContainer)...), nil
+}
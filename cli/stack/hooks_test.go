@@ -0,0 +1,242 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+)
+
+// setupHooks crea el servicio postgres con el docker-compose.yml indicado en
+// directorios temporales. Los hooks de las pruebas escriben en el archivo
+// $HOOK_LOG, cuyas líneas devuelve hookLog.
+func setupHooks(t *testing.T, content string) string {
+	t.Helper()
+
+	basePath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(basePath, "postgres"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(basePath, "postgres", "docker-compose.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(config.ConfigFileEnv, "")
+	t.Setenv("INFRACLI_SERVICES_PATH", "")
+	t.Setenv("HOOK_LOG", filepath.Join(t.TempDir(), "hooks.log"))
+	t.Cleanup(func() { config.SetOptions(config.Options{}) })
+	return basePath
+}
+
+func hookLog(t *testing.T) []string {
+	t.Helper()
+	data, err := os.ReadFile(os.Getenv("HOOK_LOG"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestRunHooksOrderAndEnv(t *testing.T) {
+	basePath := setupHooks(t, `services:
+  db:
+    image: postgres:16
+x-infracli:
+  hooks:
+    preRun:
+      - echo compose-1 $INFRACLI_SERVICE $INFRACLI_EVENT $INFRACLI_HOST >> $HOOK_LOG
+      - command: echo compose-2 >> $HOOK_LOG
+    preDown:
+      - echo compose-preDown >> $HOOK_LOG
+`)
+	config.SetOptions(config.Options{Flags: map[string][]string{"hooks.commands": {
+		"postgres.preRun=echo config $INFRACLI_SERVICE_PATH >> $HOOK_LOG",
+		"redis.preRun=echo other-service >> $HOOK_LOG",
+	}}})
+
+	opts := Options{HookEnv: func(service, content string, inContainer bool) []string {
+		return []string{"INFRACLI_HOST=db.test"}
+	}}
+	if err := RunHooks("postgres", basePath, HookPreRun, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Primero los del docker-compose.yml en orden y después los de la configuración
+	want := []string{
+		"compose-1 postgres preRun db.test",
+		"compose-2",
+		"config " + filepath.Join(basePath, "postgres"),
+	}
+	if got := hookLog(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks ran %q, want %q", got, want)
+	}
+}
+
+func TestRunHooksOnFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		flags     map[string][]string
+		wantErr   bool
+		wantLines []string
+	}{
+		{"abort by default", "", nil, true, nil},
+		{"abort", "\n        onFailure: abort", nil, true, nil},
+		{"continue", "\n        onFailure: continue", nil, false, []string{"after"}},
+		{"continue from the config", "", map[string][]string{"hooks.onFailure": {"continue"}}, false, []string{"after"}},
+		// La política del hook tiene prioridad sobre la de la configuración
+		{"abort over the config", "\n        onFailure: abort", map[string][]string{"hooks.onFailure": {"continue"}}, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			basePath := setupHooks(t, `services:
+  db:
+    image: postgres:16
+x-infracli:
+  hooks:
+    preRun:
+      - command: exit 3`+tt.policy+`
+      - echo after >> $HOOK_LOG
+`)
+			config.SetOptions(config.Options{Flags: tt.flags})
+
+			err := RunHooks("postgres", basePath, HookPreRun, Options{})
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "preRun hook of postgres failed")) {
+				t.Errorf("expected the hook failure, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if got := hookLog(t); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("hooks ran %q, want %q", got, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestRunHooksTimeout(t *testing.T) {
+	basePath := setupHooks(t, `services:
+  db:
+    image: postgres:16
+x-infracli:
+  hooks:
+    preDown:
+      - command: sleep 30
+        timeout: 200ms
+`)
+
+	start := time.Now()
+	err := RunHooks("postgres", basePath, HookPreDown, Options{})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("the hook was not stopped at its timeout, took %s", elapsed)
+	}
+
+	// El plazo de la configuración se aplica a los hooks que no indican el suyo
+	config.SetOptions(config.Options{Flags: map[string][]string{
+		"hooks.commands": {"postgres.postDown=sleep 30"},
+		"hooks.timeout":  {"100ms"},
+	}})
+	if err := RunHooks("postgres", basePath, HookPostDown, Options{}); err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+// TestComposeHooksTrust comprueba que los hooks x-infracli de un servicesPath
+// que viene de un archivo de proyecto o del entorno no se ejecutan salvo que
+// el usuario lo incluya en hooks.trustedPaths
+func TestComposeHooksTrust(t *testing.T) {
+	const content = `services:
+  db:
+    image: postgres:16
+x-infracli:
+  hooks:
+    preRun:
+      - echo compose >> $HOOK_LOG
+`
+
+	tests := []struct {
+		name    string
+		options func(basePath string) config.Options
+		want    []string
+	}{
+		{"user file", func(basePath string) config.Options {
+			writeUserConfig(t, `{"version": 1, "servicesPath": "`+basePath+`"}`)
+			return config.Options{}
+		}, []string{"compose"}},
+		{"flag", func(basePath string) config.Options {
+			return config.Options{Flags: map[string][]string{"servicesPath": {basePath}}}
+		}, []string{"compose"}},
+		{"environment", func(basePath string) config.Options {
+			t.Setenv("INFRACLI_SERVICES_PATH", basePath)
+			return config.Options{}
+		}, nil},
+		{"project file", func(basePath string) config.Options {
+			project := t.TempDir()
+			if err := os.WriteFile(filepath.Join(project, config.ProjectConfigFileName), []byte(`{"servicesPath": "`+basePath+`"}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			return config.Options{WorkingDir: project}
+		}, nil},
+		{"environment in trustedPaths", func(basePath string) config.Options {
+			t.Setenv("INFRACLI_SERVICES_PATH", basePath)
+			writeUserConfig(t, `{"version": 1, "hooks": {"trustedPaths": ["`+basePath+`/"]}}`)
+			return config.Options{}
+		}, []string{"compose"}},
+		{"other path in trustedPaths", func(basePath string) config.Options {
+			t.Setenv("INFRACLI_SERVICES_PATH", basePath)
+			return config.Options{Flags: map[string][]string{"hooks.trustedPaths": {filepath.Join(basePath, "other")}}}
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			basePath := setupHooks(t, content)
+			config.SetOptions(tt.options(basePath))
+
+			if got := HasHooks("postgres", basePath, HookPreRun); got != (tt.want != nil) {
+				t.Errorf("HasHooks = %v, want %v", got, tt.want != nil)
+			}
+			if err := RunHooks("postgres", basePath, HookPreRun, Options{}); err != nil {
+				t.Fatal(err)
+			}
+			if got := hookLog(t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hooks ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// hooks.trustedPaths no se puede fijar desde el repositorio
+	basePath := setupHooks(t, content)
+	t.Setenv("INFRACLI_HOOKS_TRUSTED_PATHS", basePath)
+	if err := RunHooks("postgres", basePath, HookPreRun, Options{}); err == nil || !strings.Contains(err.Error(), "INFRACLI_HOOKS_TRUSTED_PATHS") {
+		t.Errorf("expected hooks.trustedPaths to be rejected from the environment, got %v", err)
+	}
+}
+
+// writeUserConfig escribe el archivo de configuración del usuario
+func writeUserConfig(t *testing.T, content string) {
+	t.Helper()
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, config.ConfigFileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
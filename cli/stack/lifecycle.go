@@ -0,0 +1,49 @@
+package stack
+
+import "fmt"
+
+// Start inicia un servicio igual en la línea de comandos y en el paquete
+// infracli: genera sus credenciales si credentials.generate lo pide, ejecuta
+// los hooks preRun, llama a up con los argumentos de docker-compose que lo
+// arrancan (imágenes fijadas, secretos y redes compartidas incluidos) y
+// ejecuta los hooks postRun. El error de up se devuelve sin envolver.
+func Start(service, basePath string, opts Options, up func(args ...string) error) error {
+	if err := EnsureCredentials(service, basePath, opts); err != nil {
+		return fmt.Errorf("cannot generate the credentials of %s: %w", service, err)
+	}
+	if err := RunHooks(service, basePath, HookPreRun, opts); err != nil {
+		return fmt.Errorf("%w; %s was not started", err, service)
+	}
+
+	composeArgs, cleanup, err := ComposeArgs(service, basePath, opts)
+	if err != nil {
+		return fmt.Errorf("cannot start %s: %w", service, err)
+	}
+	err = up(append(composeArgs, "up", "-d")...)
+	cleanup()
+	if err != nil {
+		return err
+	}
+
+	return RunHooks(service, basePath, HookPostRun, opts)
+}
+
+// Stop detiene un servicio igual en la línea de comandos y en el paquete
+// infracli: ejecuta los hooks preDown, llama a down con los argumentos de
+// docker-compose down, con -v si removeVolumes, y ejecuta los hooks postDown.
+// El error de down se devuelve sin envolver.
+func Stop(service, basePath string, removeVolumes bool, opts Options, down func(args ...string) error) error {
+	if err := RunHooks(service, basePath, HookPreDown, opts); err != nil {
+		return fmt.Errorf("%w; %s was not stopped", err, service)
+	}
+
+	args := []string{"down"}
+	if removeVolumes {
+		args = append(args, "-v")
+	}
+	if err := down(args...); err != nil {
+		return err
+	}
+
+	return RunHooks(service, basePath, HookPostDown, opts)
+}
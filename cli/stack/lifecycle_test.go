@@ -0,0 +1,77 @@
+package stack
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const lifecycleCompose = `services:
+  db:
+    image: postgres:16
+x-infracli:
+  hooks:
+    preRun:
+      - echo preRun >> $HOOK_LOG
+    postRun:
+      - echo postRun >> $HOOK_LOG
+    preDown:
+      - echo preDown >> $HOOK_LOG
+    postDown:
+      - echo postDown >> $HOOK_LOG
+`
+
+// logCompose devuelve una función que hace de docker-compose y anota sus
+// argumentos entre los hooks
+func logCompose(t *testing.T, err error) func(args ...string) error {
+	return func(args ...string) error {
+		f, openErr := os.OpenFile(os.Getenv("HOOK_LOG"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if openErr != nil {
+			t.Fatal(openErr)
+		}
+		defer f.Close()
+		f.WriteString("compose " + args[len(args)-2] + " " + args[len(args)-1] + "\n")
+		return err
+	}
+}
+
+func TestStartAndStop(t *testing.T) {
+	basePath := setupHooks(t, lifecycleCompose)
+
+	if err := Start("postgres", basePath, Options{}, logCompose(t, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Stop("postgres", basePath, true, Options{}, logCompose(t, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"preRun", "compose up -d", "postRun", "preDown", "compose down -v", "postDown"}
+	if got := hookLog(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	basePath := setupHooks(t, strings.Replace(lifecycleCompose, "echo preRun >> $HOOK_LOG", "exit 1", 1))
+
+	// Un hook preRun que falla cancela el arranque
+	err := Start("postgres", basePath, Options{}, logCompose(t, nil))
+	if err == nil || !strings.HasSuffix(err.Error(), "postgres was not started") {
+		t.Errorf("expected the start to be cancelled, got %v", err)
+	}
+	if got := hookLog(t); got != nil {
+		t.Errorf("ran %q after a failed preRun hook", got)
+	}
+
+	// El error de docker-compose se devuelve tal cual y no se ejecuta postRun
+	basePath = setupHooks(t, lifecycleCompose)
+	upErr := errors.New("exit status 1")
+	if err := Start("postgres", basePath, Options{}, logCompose(t, upErr)); err != upErr {
+		t.Errorf("got %v, want the docker-compose error", err)
+	}
+	if got, want := hookLog(t), []string{"preRun", "compose up -d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/lock"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+)
+
+// LockOverride escribe el archivo de compose con las imágenes del servicio
+// fijadas en infracli.lock y devuelve su ruta, o "" si no hay lockfile o no
+// fija ninguna. Los contenedores cuya imagen cambió respecto al lockfile no se
+// fijan: con strict es un error y si no se avisa.
+func LockOverride(service, basePath string, strict bool) (string, error) {
+	lockFile, err := lock.Load(lock.Path(basePath))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	content, err := compose.Read(filepath.Join(basePath, service))
+	if err != nil {
+		return "", err
+	}
+	images := compose.Images(content)
+	for container, image := range images {
+		images[container] = strings.Trim(image, `"'`)
+	}
+
+	pins, stale := lockFile.Pins(service, images)
+	if len(stale) > 0 && strict {
+		return "", fmt.Errorf("infracli.lock is out of date for %s (%s); run 'infracli lock update %s'", service, strings.Join(stale, ", "), service)
+	}
+	for _, container := range stale {
+		entry, _ := lockFile.Lookup(service, container)
+		logger.Warnf("Warning: %s/%s uses %s but the lock has %s; run 'infracli lock update %s'", service, container, images[container], entry.Image, service)
+	}
+	if len(pins) == 0 {
+		return "", nil
+	}
+
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	path, err := lock.WriteOverride(filepath.Join(stateDir, "compose"), service, pins)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Debugf("Using %d pinned images for %s from %s", len(pins), service, path)
+	return path, nil
+}
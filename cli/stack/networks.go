@@ -0,0 +1,191 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+)
+
+const (
+	// SharedNetwork es la red a la que se unen todos los servicios para poder
+	// comunicarse entre ellos por nombre
+	SharedNetwork = "infracli"
+	// LabelManaged marca las redes que crea infracli
+	LabelManaged = "io.infracli.managed"
+	// linksFileName guarda las redes externas de cada servicio
+	linksFileName = "links.json"
+)
+
+// LoadLinks devuelve las redes externas de cada servicio
+func LoadLinks() (map[string][]string, error) {
+	path, err := linksPath()
+	if err != nil {
+		return nil, err
+	}
+
+	links := make(map[string][]string)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return links, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return links, nil
+}
+
+// SaveLinks guarda las redes externas de cada servicio
+func SaveLinks(links map[string][]string) error {
+	path, err := linksPath()
+	if err != nil {
+		return err
+	}
+	for service, networks := range links {
+		if len(networks) == 0 {
+			delete(links, service)
+		}
+	}
+
+	data, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+func linksPath() (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, linksFileName), nil
+}
+
+// Networks devuelve las redes externas de un servicio: la red compartida de
+// infracli seguida de las de 'infracli link'
+func Networks(service string) ([]string, error) {
+	links, err := LoadLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	networks := []string{SharedNetwork}
+	for _, network := range links[service] {
+		if network != SharedNetwork {
+			networks = append(networks, network)
+		}
+	}
+	return networks, nil
+}
+
+// NetworkOverride escribe el archivo de compose que une los contenedores del
+// servicio a sus redes externas con sus alias, y devuelve su ruta. Las redes
+// se crean si no existen, porque docker-compose no crea las externas. Si no se
+// pueden crear el servicio arranca sin ellas y devuelve "".
+func NetworkOverride(service, basePath string, opts Options) (string, error) {
+	networks, err := Networks(service)
+	if err != nil {
+		return "", err
+	}
+
+	for _, network := range networks {
+		if err := EnsureNetwork(network, opts); err != nil {
+			logger.Warnf("Warning: %s will not join its shared networks: %v", service, err)
+			return "", nil
+		}
+	}
+
+	content, err := compose.Read(filepath.Join(basePath, service))
+	if err != nil {
+		return "", err
+	}
+	aliases := compose.NetworkAliases(service, content)
+
+	var override strings.Builder
+	override.WriteString("services:\n")
+	for _, container := range sortedKeys(aliases) {
+		fmt.Fprintf(&override, "  %s:\n    networks:\n", container)
+		// Al declarar redes el servicio deja la red por defecto del proyecto
+		// salvo que la nombre
+		if len(compose.Networks(content, "  "+container+":")) == 0 {
+			override.WriteString("      default: {}\n")
+		}
+		for _, network := range networks {
+			fmt.Fprintf(&override, "      %s:\n        aliases:\n", network)
+			for _, alias := range aliases[container] {
+				fmt.Fprintf(&override, "          - %s\n", alias)
+			}
+		}
+	}
+	override.WriteString("networks:\n")
+	for _, network := range networks {
+		fmt.Fprintf(&override, "  %s:\n    external: true\n", network)
+	}
+
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(stateDir, "compose")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating %s: %v", dir, err)
+	}
+	path := filepath.Join(dir, service+".networks.yml")
+	if err := os.WriteFile(path, []byte(override.String()), 0644); err != nil {
+		return "", fmt.Errorf("error writing %s: %v", path, err)
+	}
+
+	logger.Debugf("Joining %s to networks %s with %s", service, strings.Join(networks, ", "), path)
+	return path, nil
+}
+
+// EnsureNetwork crea la red si no existe. El cliente del engine solo conoce
+// DOCKER_HOST sin TLS, así que si falla se usa la CLI de docker, que entiende
+// también los contextos, ssh:// y TLS.
+func EnsureNetwork(network string, opts Options) error {
+	client, err := opts.docker()
+	if err == nil {
+		err = client.EnsureNetwork(context.Background(), network, map[string]string{LabelManaged: "true"})
+		var apiErr *engine.APIError
+		if err == nil || errors.As(err, &apiErr) {
+			// El demonio respondió: su error es definitivo
+			return err
+		}
+	}
+	logger.Debugf("Cannot create network %s through the engine API (%v); using the docker CLI", network, err)
+
+	if exec.Command("docker", "network", "inspect", network).Run() == nil {
+		return nil
+	}
+	output, err := exec.Command("docker", "network", "create", "--label", LabelManaged+"=true", network).CombinedOutput()
+	if err != nil {
+		// Otro proceso pudo crearla al mismo tiempo
+		if exec.Command("docker", "network", "inspect", network).Run() == nil {
+			return nil
+		}
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("error creating network %s: %s", network, message)
+		}
+		return fmt.Errorf("error creating network %s: %v", network, err)
+	}
+	return nil
+}
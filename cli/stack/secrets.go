@@ -0,0 +1,206 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+	"github.com/solrac97gr/infrastructure/infracli/secrets"
+)
+
+// PassphraseEnv permite indicar la frase de paso del almacén de secretos sin
+// preguntarla, por ejemplo en CI o en las pruebas de integración. Si está
+// definida al crear el almacén no se usa el llavero.
+const PassphraseEnv = "INFRACLI_SECRETS_PASSPHRASE"
+
+// ErrPassphraseRequired indica que el almacén necesita una frase de paso que
+// no se puede obtener sin preguntarla
+var ErrPassphraseRequired = errors.New("the secrets store needs a passphrase")
+
+// EnvPassphrase devuelve la frase de paso de PassphraseEnv
+func EnvPassphrase() (string, error) {
+	if passphrase, ok := os.LookupEnv(PassphraseEnv); ok {
+		return passphrase, nil
+	}
+	return "", fmt.Errorf("%w; set %s", ErrPassphraseRequired, PassphraseEnv)
+}
+
+// LoadSecrets lee el almacén de secretos. Si todavía no existe devuelve nil
+// junto con la ruta donde se creará.
+func LoadSecrets() (*secrets.Store, string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, "", err
+	}
+	path := secrets.Path(configDir)
+
+	store, err := secrets.Load(path)
+	if os.IsNotExist(err) {
+		return nil, path, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return store, path, nil
+}
+
+// SecretsCache guarda el almacén de secretos ya desbloqueado para pedir la
+// frase de paso y derivar la clave una sola vez por comando. No ve los cambios
+// que otros procesos hagan en el almacén, así que no debe durar más que la
+// operación que lo usa. El valor cero es una caché vacía.
+type SecretsCache struct {
+	mu    sync.Mutex
+	store *secrets.Store
+	path  string
+}
+
+// loadSecrets lee el almacén como LoadSecrets o devuelve el de la caché
+func (o Options) loadSecrets() (*secrets.Store, string, error) {
+	if o.Secrets == nil {
+		return LoadSecrets()
+	}
+
+	o.Secrets.mu.Lock()
+	defer o.Secrets.mu.Unlock()
+	if o.Secrets.store != nil {
+		return o.Secrets.store, o.Secrets.path, nil
+	}
+	store, path, err := LoadSecrets()
+	if err == nil && store != nil {
+		o.Secrets.store, o.Secrets.path = store, path
+	}
+	return store, path, err
+}
+
+// cacheSecrets guarda en la caché un almacén recién creado
+func (o Options) cacheSecrets(store *secrets.Store, path string) {
+	if o.Secrets == nil {
+		return
+	}
+	o.Secrets.mu.Lock()
+	defer o.Secrets.mu.Unlock()
+	o.Secrets.store, o.Secrets.path = store, path
+}
+
+// unlock desbloquea el almacén una sola vez aunque varias llamadas compartan la caché
+func (o Options) unlock(store *secrets.Store) error {
+	if o.Secrets != nil {
+		o.Secrets.mu.Lock()
+		defer o.Secrets.mu.Unlock()
+	}
+	return store.Unlock(o.passphrase)
+}
+
+// CreateSecrets crea el almacén con la frase de paso de PassphraseEnv, con la
+// clave en el llavero del sistema o, si no hay llavero, con la frase de paso
+// de opts.NewPassphrase
+func CreateSecrets(opts Options) (*secrets.Store, error) {
+	if passphrase, ok := os.LookupEnv(PassphraseEnv); ok {
+		return secrets.NewWithPassphrase(passphrase)
+	}
+
+	store, err := secrets.NewWithKeyring()
+	if err == nil {
+		logger.Infof("Created the secrets store with its key in the OS keyring")
+		return store, nil
+	}
+	if errors.Is(err, secrets.ErrKeyringUnavailable) {
+		logger.Infof("No OS keyring available; the secrets will be protected with a passphrase")
+	} else {
+		logger.Warnf("Warning: %v; the secrets will be protected with a passphrase", err)
+	}
+
+	passphrase, err := opts.newPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	return secrets.NewWithPassphrase(passphrase)
+}
+
+// ServiceSecrets descifra los secretos de un servicio. Devuelve nil si no tiene.
+func ServiceSecrets(service string, opts Options) (map[string]string, error) {
+	store, _, err := opts.loadSecrets()
+	if err != nil || store == nil || len(store.Names(service)) == 0 {
+		return nil, err
+	}
+	if err := opts.unlock(store); err != nil {
+		return nil, err
+	}
+	return store.Values(service)
+}
+
+// SecretArgs escribe el archivo de entorno y el archivo de compose que aplican
+// los secretos de un servicio y devuelve los argumentos que los usan. cleanup
+// borra el archivo de entorno, que solo hace falta al crear los contenedores.
+// Sin secretos devuelve nil.
+func SecretArgs(service, basePath string, opts Options) (args []string, cleanup func(), err error) {
+	values, err := ServiceSecrets(service, opts)
+	if err != nil || len(values) == 0 {
+		return nil, func() {}, err
+	}
+
+	content, err := compose.Read(filepath.Join(basePath, service))
+	if err != nil {
+		return nil, nil, err
+	}
+	targets := secretTargets(content, values)
+
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	dir := filepath.Join(stateDir, "compose")
+
+	envPath, err := secrets.WriteEnvFile(dir, service, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { os.Remove(envPath) }
+
+	overridePath, err := secrets.WriteOverride(dir, service, targets)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	logger.Debugf("Using %d secrets for %s", len(values), service)
+	return []string{"--env-file", envPath, "-f", overridePath}, cleanup, nil
+}
+
+// secretTargets decide qué contenedores reciben cada secreto: los que declaran
+// la variable en su docker-compose.yml o, si ninguno lo hace, todos
+func secretTargets(content string, values map[string]string) map[string][]string {
+	containers := sortedKeys(compose.Images(content))
+	targets := make(map[string][]string)
+
+	for _, name := range sortedKeys(values) {
+		var declared []string
+		for _, container := range containers {
+			if _, ok := compose.Environment(content, "  "+container+":")[name]; ok {
+				declared = append(declared, container)
+			}
+		}
+		if len(declared) == 0 {
+			declared = containers
+		}
+		for _, container := range declared {
+			targets[container] = append(targets[container], name)
+		}
+	}
+	return targets
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package stack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/solrac97gr/infrastructure/infracli/config"
+	"github.com/solrac97gr/infrastructure/infracli/secrets"
+)
+
+// saveSecrets crea en un directorio de configuración temporal un almacén
+// protegido con frase de paso con los secretos indicados
+func saveSecrets(t *testing.T, values map[string]map[string]string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(config.ConfigFileEnv, "")
+
+	store, err := secrets.NewWithPassphrase("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for service, names := range values {
+		for name, value := range names {
+			if err := store.Set(service, name, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(secrets.Path(configDir)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServiceSecretsUnlocksOnce(t *testing.T) {
+	saveSecrets(t, map[string]map[string]string{
+		"postgres": {"POSTGRES_PASSWORD": "pg-secret"},
+		"mysql":    {"MYSQL_PASSWORD": "my-secret"},
+	})
+	basePath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(basePath, "postgres"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "services:\n  db:\n    image: postgres:16\n    environment:\n      POSTGRES_PASSWORD: postgres\n"
+	if err := os.WriteFile(filepath.Join(basePath, "postgres", "docker-compose.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := 0
+	passphrase := func() (string, error) {
+		prompts++
+		return "passphrase", nil
+	}
+
+	// Sin caché cada llamada pide la frase de paso
+	for i := 0; i < 2; i++ {
+		if _, err := ServiceSecrets("postgres", Options{Passphrase: passphrase}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if prompts != 2 {
+		t.Errorf("asked %d times without a cache, want 2", prompts)
+	}
+
+	// Con caché, como un comando que ejecuta hooks y arranca varios servicios
+	prompts = 0
+	opts := Options{Passphrase: passphrase, Secrets: &SecretsCache{}}
+	for _, service := range []string{"postgres", "mysql", "postgres", "redis"} {
+		if _, err := ServiceSecrets(service, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args, cleanup, err := SecretArgs("postgres", basePath, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if len(args) != 4 {
+		t.Errorf("unexpected secret args %v", args)
+	}
+	if prompts != 1 {
+		t.Errorf("asked %d times with a cache, want 1", prompts)
+	}
+
+	values, err := ServiceSecrets("mysql", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"MYSQL_PASSWORD": "my-secret"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+}
+
+func TestServiceSecretsWithoutPassphrase(t *testing.T) {
+	saveSecrets(t, map[string]map[string]string{"postgres": {"POSTGRES_PASSWORD": "pg-secret"}})
+	t.Setenv(PassphraseEnv, "")
+	os.Unsetenv(PassphraseEnv)
+
+	// Un servicio sin secretos no necesita la frase de paso
+	if values, err := ServiceSecrets("redis", Options{}); err != nil || values != nil {
+		t.Errorf("got %v, %v for a service without secrets", values, err)
+	}
+
+	_, err := ServiceSecrets("postgres", Options{Secrets: &SecretsCache{}})
+	if !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("expected ErrPassphraseRequired, got %v", err)
+	}
+}
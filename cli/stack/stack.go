@@ -0,0 +1,123 @@
+// Package stack prepara los servicios de infracli para docker-compose de la
+// misma forma en la línea de comandos y en el paquete infracli: los archivos
+// que se aplican sobre el docker-compose.yml de cada servicio (imágenes
+// fijadas en infracli.lock, secretos y redes compartidas), las contraseñas
+// generadas al azar y los hooks del ciclo de vida.
+package stack
+
+import (
+	"fmt"
+
+	"github.com/solrac97gr/infrastructure/infracli/compose"
+	"github.com/solrac97gr/infrastructure/infracli/engine"
+	"github.com/solrac97gr/infrastructure/infracli/logger"
+)
+
+// Options controla cómo se preparan los servicios. El valor cero sirve para
+// usarlos sin terminal: la frase de paso se toma de PassphraseEnv.
+type Options struct {
+	// IgnoreLock usa las imágenes del docker-compose.yml aunque exista infracli.lock
+	IgnoreLock bool
+	// StrictLock falla si infracli.lock no está al día con el docker-compose.yml;
+	// si no, se avisa y se usan las imágenes del docker-compose.yml
+	StrictLock bool
+	// Passphrase obtiene la frase de paso del almacén de secretos cuando su
+	// clave no está en el llavero. Por defecto es EnvPassphrase.
+	Passphrase func() (string, error)
+	// NewPassphrase obtiene la frase de paso con la que se crea el almacén si
+	// no hay llavero. Por defecto es EnvPassphrase.
+	NewPassphrase func() (string, error)
+	// Docker es el cliente del demonio; nil usa $DOCKER_HOST
+	Docker *engine.Client
+	// HookEnv devuelve las variables con la información de conexión que
+	// reciben los hooks; sin ella solo reciben el servicio y el evento
+	HookEnv HookEnv
+	// Secrets conserva el almacén de secretos desbloqueado entre las llamadas
+	// que comparten estas opciones; nil lo lee y lo desbloquea en cada una
+	Secrets *SecretsCache
+}
+
+func (o Options) docker() (*engine.Client, error) {
+	if o.Docker != nil {
+		return o.Docker, nil
+	}
+	return engine.NewFromEnv()
+}
+
+func (o Options) passphrase() (string, error) {
+	if o.Passphrase != nil {
+		return o.Passphrase()
+	}
+	return EnvPassphrase()
+}
+
+func (o Options) newPassphrase() (string, error) {
+	if o.NewPassphrase != nil {
+		return o.NewPassphrase()
+	}
+	return EnvPassphrase()
+}
+
+// OverrideArgs devuelve los argumentos de docker-compose con los archivos que
+// se aplican sobre el docker-compose.yml de un servicio: las imágenes fijadas
+// en el lockfile y los secretos guardados. cleanup borra los archivos
+// temporales y se llama cuando termina docker-compose.
+func OverrideArgs(service, basePath string, opts Options) (args []string, cleanup func(), err error) {
+	if !opts.IgnoreLock {
+		lockArgs, err := lockOverrideArgs(service, basePath, opts.StrictLock)
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, lockArgs...)
+	}
+
+	secretArgs, cleanup, err := SecretArgs(service, basePath, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error applying the secrets of %s: %w", service, err)
+	}
+	return append(args, secretArgs...), cleanup, nil
+}
+
+// ComposeArgs devuelve el docker-compose.yml del servicio seguido de los
+// archivos de OverrideArgs y del de sus redes externas. Sin ninguno devuelve
+// nil y docker-compose usa el archivo del directorio.
+func ComposeArgs(service, basePath string, opts Options) ([]string, func(), error) {
+	overrides, cleanup, err := OverrideArgs(service, basePath, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Las redes externas solo se aplican al servicio y no a sus instancias,
+	// para que los alias de DNS no apunten a varios contenedores
+	networkPath, err := NetworkOverride(service, basePath, opts)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("error joining %s to its networks: %v", service, err)
+	}
+	if networkPath != "" {
+		overrides = append(overrides, "-f", networkPath)
+	}
+
+	if len(overrides) == 0 {
+		return nil, cleanup, nil
+	}
+	return append([]string{"-f", compose.FileName}, overrides...), cleanup, nil
+}
+
+// lockOverrideArgs devuelve los argumentos que aplican infracli.lock al
+// servicio. Sin strict, si no se puede aplicar se avisa y se usan las
+// imágenes del docker-compose.yml.
+func lockOverrideArgs(service, basePath string, strict bool) ([]string, error) {
+	overridePath, err := LockOverride(service, basePath, strict)
+	if err != nil {
+		if strict {
+			return nil, err
+		}
+		logger.Warnf("Warning: cannot apply lock file: %v", err)
+		return nil, nil
+	}
+	if overridePath == "" {
+		return nil, nil
+	}
+	return []string{"-f", overridePath}, nil
+}